@@ -9,6 +9,8 @@ require (
 	github.com/go-chi/cors v1.2.2
 	github.com/go-git/go-git/v5 v5.16.2
 	github.com/mdp/qrterminal/v3 v3.2.1
+	github.com/skeema/knownhosts v1.3.1
+	golang.org/x/crypto v0.37.0
 )
 
 require (
@@ -33,12 +35,10 @@ require (
 	github.com/mailru/easyjson v0.7.6 // indirect
 	github.com/pjbgf/sha1cd v0.3.2 // indirect
 	github.com/sergi/go-diff v1.3.2-0.20230802210424-5b0b94c5c0d3 // indirect
-	github.com/skeema/knownhosts v1.3.1 // indirect
 	github.com/swaggo/files v1.0.1 // indirect
 	github.com/swaggo/http-swagger v1.3.4 // indirect
 	github.com/swaggo/swag v1.8.1 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
-	golang.org/x/crypto v0.37.0 // indirect
 	golang.org/x/net v0.39.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
 	golang.org/x/term v0.31.0 // indirect
@@ -35,6 +35,7 @@ import (
 	"gitweb/server/internal/api/handlers"
 	"gitweb/server/internal/auth"
 	"gitweb/server/internal/config"
+	"gitweb/server/internal/credentials"
 	"gitweb/server/internal/registry"
 	"gitweb/server/internal/resources"
 
@@ -120,6 +121,14 @@ func main() {
 
 	pairingManager := auth.NewPairingManager(auth.DefaultPairSessionTTL)
 
+	// Credentials for private remotes; without them gittyd still serves
+	// public and local remotes.
+	credentialStore, err := credentials.Open(filepath.Join(homeDir, ".config", "gitty"), *cfg.MasterPassword)
+	if err != nil {
+		log.Printf("Warning: Failed to open credential store: %v", err)
+		credentialStore = nil
+	}
+
 	// Determine port early for QR payload
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -154,7 +163,7 @@ func main() {
 	// Initialize auth handler with the current session ID
 	authHandler := handlers.NewAuthHandlerWithSession(pairingManager, tokenStore, *cfg.MasterPassword, session.SessionID)
 
-	apiRouter := api.NewRouter(appCtx, dataPath, cfg, reg, pairingManager, tokenStore, authHandler)
+	apiRouter := api.NewRouter(appCtx, dataPath, cfg, reg, credentialStore, pairingManager, tokenStore, authHandler)
 
 	r := chi.NewRouter()
 
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+)
+
+const (
+	// autoFetchTickInterval is how often the scheduler looks for repositories
+	// that are due; fetch intervals are configured in whole minutes.
+	autoFetchTickInterval = time.Minute
+	// maxAutoFetchBackoff caps the delay after repeated fetch failures.
+	maxAutoFetchBackoff = 6 * time.Hour
+)
+
+// autoFetchSchedule tracks when a repository is next due for an automatic fetch.
+type autoFetchSchedule struct {
+	next     time.Time
+	failures int
+}
+
+// StartAutoFetch runs the background scheduler that fetches every repository
+// with auto-fetch enabled on its configured interval. Fetches are admitted by
+// the resource governor; a rejected fetch is retried on the next tick. The
+// scheduler stops when ctx is cancelled, aborting any fetch in flight.
+func (h *RepositoryHandler) StartAutoFetch(ctx context.Context) {
+	if h == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	h.autoFetchMu.Lock()
+	defer h.autoFetchMu.Unlock()
+
+	if h.autoFetchDone != nil {
+		return
+	}
+
+	ticker := h.newPressureTicker(autoFetchTickInterval)
+	doneCh := make(chan struct{})
+	h.autoFetchDone = doneCh
+
+	go func() {
+		defer func() {
+			ticker.Stop()
+
+			h.autoFetchMu.Lock()
+			if h.autoFetchDone == doneCh {
+				h.autoFetchDone = nil
+			}
+			h.autoFetchMu.Unlock()
+
+			close(doneCh)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.Chan():
+				h.runAutoFetch(ctx, now)
+			}
+		}
+	}()
+}
+
+// runAutoFetch fetches every repository that is due at now.
+func (h *RepositoryHandler) runAutoFetch(ctx context.Context, now time.Time) {
+	h.mu.RLock()
+	repos := make([]*models.Repository, 0, len(h.repositories))
+	for _, repo := range h.repositories {
+		repos = append(repos, repo)
+	}
+	h.mu.RUnlock()
+
+	h.fetchMu.Lock()
+	registered := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		registered[repo.ID] = true
+	}
+	for id := range h.fetchSchedules {
+		if !registered[id] {
+			delete(h.fetchSchedules, id)
+		}
+	}
+	h.fetchMu.Unlock()
+
+	for _, repo := range repos {
+		if ctx.Err() != nil {
+			return
+		}
+
+		settings, err := h.loadRepoAppSettings(repo.ID)
+		if err != nil {
+			h.logf("auto-fetch skipped repo=%q err=%v", repo.ID, err)
+			continue
+		}
+		if !settings.Sync.AutoFetch {
+			h.fetchMu.Lock()
+			delete(h.fetchSchedules, repo.ID)
+			h.fetchMu.Unlock()
+			continue
+		}
+
+		interval := time.Duration(settings.Sync.FetchIntervalMinutes) * time.Minute
+		if interval <= 0 {
+			interval = 15 * time.Minute
+		}
+
+		h.fetchMu.Lock()
+		schedule, ok := h.fetchSchedules[repo.ID]
+		if !ok {
+			schedule = &autoFetchSchedule{next: now}
+			if last := settings.LastFetch; last != nil {
+				// Carry on from the fetch recorded before a restart
+				if last.Automatic && !last.Succeeded {
+					schedule.failures = last.ConsecutiveFailures
+				}
+				schedule.next = last.LastFetchAt.Add(autoFetchBackoff(interval, schedule.failures))
+			}
+			h.fetchSchedules[repo.ID] = schedule
+		}
+		due := !now.Before(schedule.next)
+		h.fetchMu.Unlock()
+		if !due {
+			continue
+		}
+
+		admission := h.governor.AdmitExpensive()
+		if !admission.Admitted {
+			h.logf("auto-fetch deferred by resource governor repo=%q reason=%q", repo.ID, admission.Reason)
+			continue
+		}
+		result, err := h.gitService.FetchContext(ctx, repo.Path, "", false)
+		admission.Release()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = git.FetchError(result)
+		}
+
+		h.fetchMu.Lock()
+		if err != nil {
+			schedule.failures++
+			schedule.next = now.Add(autoFetchBackoff(interval, schedule.failures))
+			h.logf("auto-fetch failed repo=%q failures=%d err=%v", repo.ID, schedule.failures, err)
+		} else {
+			schedule.failures = 0
+			schedule.next = now.Add(interval)
+		}
+		h.fetchMu.Unlock()
+
+		h.recordFetch(repo.ID, now, err, true)
+	}
+}
+
+// autoFetchBackoff doubles the interval for each consecutive failure, up to
+// maxAutoFetchBackoff (or the interval itself when that is longer).
+func autoFetchBackoff(interval time.Duration, failures int) time.Duration {
+	limit := maxAutoFetchBackoff
+	if interval > limit {
+		limit = interval
+	}
+
+	delay := interval
+	for i := 0; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// recordFetch stores the outcome of a manual or automatic fetch for the status
+// endpoint, and saves it with the repository settings so it survives a restart.
+func (h *RepositoryHandler) recordFetch(repoID string, at time.Time, err error, automatic bool) {
+	status := models.FetchStatus{
+		LastFetchAt: at,
+		Succeeded:   err == nil,
+		Automatic:   automatic,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	h.fetchMu.Lock()
+	if schedule, ok := h.fetchSchedules[repoID]; ok && automatic {
+		status.ConsecutiveFailures = schedule.failures
+	}
+	recorded := status
+	h.fetchStatuses[repoID] = &recorded
+	h.fetchMu.Unlock()
+
+	h.settingsMu.Lock()
+	settings, err := h.loadRepoAppSettings(repoID)
+	if err == nil {
+		settings.LastFetch = &status
+		err = h.saveRepoAppSettings(repoID, settings)
+	}
+	h.settingsMu.Unlock()
+	if err != nil {
+		h.logf("failed to save fetch status repo=%q err=%v", repoID, err)
+	}
+
+	h.publishEvent(repoID, EventFetchCompleted, status)
+}
+
+// lastFetchStatus returns a copy of the recorded fetch outcome for a repository,
+// with the next scheduled auto-fetch filled in, or nil if it was never fetched.
+func (h *RepositoryHandler) lastFetchStatus(repoID string) *models.FetchStatus {
+	h.fetchMu.Lock()
+	recorded, ok := h.fetchStatuses[repoID]
+	var next *time.Time
+	if schedule, ok := h.fetchSchedules[repoID]; ok {
+		scheduled := schedule.next
+		next = &scheduled
+	}
+	h.fetchMu.Unlock()
+
+	if !ok {
+		// Not fetched since the server started; use the saved outcome
+		settings, err := h.loadRepoAppSettings(repoID)
+		if err != nil || settings.LastFetch == nil {
+			return nil
+		}
+		recorded = settings.LastFetch
+	}
+
+	status := *recorded
+	status.NextFetchAt = next
+	return &status
+}
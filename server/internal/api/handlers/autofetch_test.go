@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitweb/server/internal/models"
+	"gitweb/server/internal/resources"
+)
+
+// newAutoFetchHandler registers a repository with auto-fetch enabled on a
+// 15 minute interval and an origin remote pointing at remoteURL.
+func newAutoFetchHandler(t *testing.T, remoteURL string) (*RepositoryHandler, string) {
+	t.Helper()
+
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	handler.logf = func(string, ...any) {}
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "remote", "add", "origin", remoteURL)
+
+	settings := defaultRepoAppSettings()
+	settings.Sync.AutoFetch = true
+	settings.Sync.FetchIntervalMinutes = 15
+	if err := handler.saveRepoAppSettings("test-repo", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	return handler, repoDir
+}
+
+func TestAutoFetch_FetchesOnInterval(t *testing.T) {
+	bare := t.TempDir()
+	runGitInRepo(t, bare, "init", "--bare")
+	handler, repoDir := newAutoFetchHandler(t, bare)
+	runGitInRepo(t, repoDir, "push", "origin", "HEAD:refs/heads/main")
+
+	start := time.Now()
+	handler.runAutoFetch(context.Background(), start)
+
+	status := handler.lastFetchStatus("test-repo")
+	if status == nil || !status.Succeeded || !status.Automatic {
+		t.Fatalf("expected a successful automatic fetch, got %+v", status)
+	}
+	if status.NextFetchAt == nil || !status.NextFetchAt.Equal(start.Add(15*time.Minute)) {
+		t.Fatalf("expected next fetch in 15 minutes, got %v", status.NextFetchAt)
+	}
+	if revParse(t, repoDir, "refs/remotes/origin/main") == "" {
+		t.Fatal("expected origin/main after auto-fetch")
+	}
+
+	handler.runAutoFetch(context.Background(), start.Add(5*time.Minute))
+	if got := handler.lastFetchStatus("test-repo").LastFetchAt; !got.Equal(start) {
+		t.Fatalf("fetch ran before the interval elapsed (last fetch %v)", got)
+	}
+
+	handler.runAutoFetch(context.Background(), start.Add(15*time.Minute))
+	if got := handler.lastFetchStatus("test-repo").LastFetchAt; !got.Equal(start.Add(15 * time.Minute)) {
+		t.Fatalf("expected fetch once the interval elapsed, last fetch %v", got)
+	}
+}
+
+func TestAutoFetch_BacksOffOnErrorsAndReportsInStatus(t *testing.T) {
+	handler, _ := newAutoFetchHandler(t, filepath.Join(t.TempDir(), "missing.git"))
+
+	start := time.Now()
+	handler.runAutoFetch(context.Background(), start)
+	status := handler.lastFetchStatus("test-repo")
+	if status == nil || status.Succeeded || status.Error == "" || status.ConsecutiveFailures != 1 {
+		t.Fatalf("expected a recorded failure, got %+v", status)
+	}
+	if !status.NextFetchAt.Equal(start.Add(30 * time.Minute)) {
+		t.Fatalf("expected first retry after 30 minutes, got %v", status.NextFetchAt)
+	}
+
+	handler.runAutoFetch(context.Background(), start.Add(30*time.Minute))
+	status = handler.lastFetchStatus("test-repo")
+	if status.ConsecutiveFailures != 2 || !status.NextFetchAt.Equal(start.Add(90*time.Minute)) {
+		t.Fatalf("expected second retry after another hour, got %+v next=%v", status, status.NextFetchAt)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.GetRepositoryStatus(rec, newRepoSettingsRequest(http.MethodGet, "/api/repos/test-repo/status", "test-repo", nil))
+	var repoStatus models.RepositoryStatus
+	if err := json.NewDecoder(rec.Body).Decode(&repoStatus); err != nil {
+		t.Fatal(err)
+	}
+	if repoStatus.LastFetch == nil || repoStatus.LastFetch.Succeeded {
+		t.Fatalf("expected status to report the failed fetch, got %+v", repoStatus.LastFetch)
+	}
+}
+
+func TestAutoFetch_StatusSurvivesRestart(t *testing.T) {
+	handler, _ := newAutoFetchHandler(t, filepath.Join(t.TempDir(), "missing.git"))
+	start := time.Now()
+	handler.runAutoFetch(context.Background(), start)
+
+	restarted := NewRepositoryHandler(handler.dataPath, nil, nil)
+	restarted.logf = func(string, ...any) {}
+	restarted.repositories["test-repo"] = handler.repositories["test-repo"]
+
+	status := restarted.lastFetchStatus("test-repo")
+	if status == nil || status.Succeeded || !status.LastFetchAt.Equal(start) || status.ConsecutiveFailures != 1 {
+		t.Fatalf("expected the saved failure after a restart, got %+v", status)
+	}
+
+	restarted.runAutoFetch(context.Background(), start.Add(10*time.Minute))
+	if got := restarted.lastFetchStatus("test-repo").LastFetchAt; !got.Equal(start) {
+		t.Fatalf("expected the backoff to carry over the restart, last fetch %v", got)
+	}
+	restarted.runAutoFetch(context.Background(), start.Add(30*time.Minute))
+	if status := restarted.lastFetchStatus("test-repo"); status.ConsecutiveFailures != 2 {
+		t.Fatalf("expected the retry to count as the second failure, got %+v", status)
+	}
+}
+
+func TestAutoFetch_SkipsDisabledReposAndDefersToGovernor(t *testing.T) {
+	bare := t.TempDir()
+	runGitInRepo(t, bare, "init", "--bare")
+	handler, _ := newAutoFetchHandler(t, bare)
+	handler.governor = resources.NewGovernor(resources.Config{
+		Enabled:              true,
+		MaxExpensiveInflight: 1,
+		DegradeHighWatermark: 0.85,
+		DegradeLowWatermark:  0.70,
+	})
+
+	held := handler.governor.AdmitExpensive()
+	handler.runAutoFetch(context.Background(), time.Now())
+	if status := handler.lastFetchStatus("test-repo"); status != nil {
+		t.Fatalf("expected fetch to wait for the governor, got %+v", status)
+	}
+	held.Release()
+
+	settings := defaultRepoAppSettings()
+	if err := handler.saveRepoAppSettings("test-repo", settings); err != nil {
+		t.Fatal(err)
+	}
+	handler.runAutoFetch(context.Background(), time.Now())
+	if status := handler.lastFetchStatus("test-repo"); status != nil {
+		t.Fatalf("expected no fetch with auto-fetch disabled, got %+v", status)
+	}
+}
+
+func TestAutoFetch_StopsWhenContextCanceled(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	ticker := newManualPressureTicker()
+	handler.newPressureTicker = func(time.Duration) pressureTicker {
+		return ticker
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler.StartAutoFetch(ctx)
+
+	handler.autoFetchMu.Lock()
+	done := handler.autoFetchDone
+	handler.autoFetchMu.Unlock()
+	if done == nil {
+		t.Fatal("expected auto-fetch scheduler to start")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("auto-fetch scheduler did not stop after context cancellation")
+	}
+}
+
+func TestAutoFetchBackoff_IsCapped(t *testing.T) {
+	if got := autoFetchBackoff(15*time.Minute, 1); got != 30*time.Minute {
+		t.Errorf("expected 30m after one failure, got %v", got)
+	}
+	if got := autoFetchBackoff(15*time.Minute, 20); got != maxAutoFetchBackoff {
+		t.Errorf("expected backoff capped at %v, got %v", maxAutoFetchBackoff, got)
+	}
+	if got := autoFetchBackoff(12*time.Hour, 3); got != 12*time.Hour {
+		t.Errorf("expected intervals above the cap to stay unchanged, got %v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gitweb/server/internal/git"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// blameErrorStatus maps blame errors to HTTP status codes.
+func blameErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidRevision),
+		errors.Is(err, git.ErrBinaryFile):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrRevisionNotFound),
+		errors.Is(err, git.ErrFileNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// @Summary      Blame a file
+// @Description  Attribute each line of a file at a revision to the commit that last changed it, grouped into runs of consecutive lines and syntax highlighted like tokenized diffs
+// @Tags         repositories
+// @Produce      json
+// @Param        id                 path     string  true   "Repository ID"
+// @Param        "*"                path     string  true   "File path"
+// @Param        rev                query    string  false  "Revision to blame at (default HEAD)"
+// @Param        ignore_whitespace  query    bool    false  "Look through commits that only changed whitespace"
+// @Param        ignore_revs        query    bool    false  "Look through the revisions listed in .git-blame-ignore-revs"
+// @Success      200   {object} models.Blame
+// @Failure      400   {string} string "Invalid revision or binary file"
+// @Failure      404   {string} string "Repository, revision or file not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/blame/{filepath} [get]
+func (h *RepositoryHandler) GetBlame(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	filePath := chi.URLParam(r, "*")
+	decodedPath, err := url.PathUnescape(filePath)
+	if err != nil {
+		decodedPath = filePath // fallback to original if decoding fails
+	}
+	if decodedPath == "" {
+		http.Error(w, "File path is required", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := git.BlameOptions{
+		Rev:              query.Get("rev"),
+		IgnoreWhitespace: query.Get("ignore_whitespace") == "true",
+		IgnoreRevs:       query.Get("ignore_revs") == "true",
+	}
+
+	release, ok := h.enterExpensiveOrReject(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	blame, err := h.gitService.Blame(repo.Path, decodedPath, opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to blame file: %v", err), blameErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blame)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestGetBlame(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.GetBlame(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/blame/README.md", nil, "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var blame models.Blame
+	if err := json.NewDecoder(rec.Body).Decode(&blame); err != nil {
+		t.Fatal(err)
+	}
+	if blame.Path != "README.md" || blame.TotalLines != 1 || len(blame.Runs) != 1 || len(blame.Runs[0].Lines[0].Tokens) == 0 {
+		t.Fatalf("unexpected blame: %+v", blame)
+	}
+
+	tests := []struct {
+		repoID, path, query string
+		status              int
+	}{
+		{"missing", "README.md", "", http.StatusNotFound},
+		{"test-repo", "missing.txt", "", http.StatusNotFound},
+		{"test-repo", "README.md", "?rev=nope", http.StatusNotFound},
+		{"test-repo", "README.md", "?rev=-p", http.StatusBadRequest},
+		{"test-repo", "", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.GetBlame(rec, newRouteRequest(http.MethodGet, "/api/repos/"+tt.repoID+"/blame/"+tt.path+tt.query, nil, "id", tt.repoID, "*", tt.path))
+		if rec.Code != tt.status {
+			t.Errorf("blame %s/%s%s: expected status %d, got %d: %s", tt.repoID, tt.path, tt.query, tt.status, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// applyCommitsErrorStatus maps cherry-pick and revert errors to HTTP status codes.
+func applyCommitsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrNoCommits),
+		errors.Is(err, git.ErrCommitNotFound),
+		errors.Is(err, git.ErrInvalidMainline):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrOperationInProgress),
+		errors.Is(err, git.ErrNoOperation),
+		errors.Is(err, git.ErrLocalChanges),
+		errors.Is(err, git.ErrNothingApplied):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeApplyCommitsResult encodes a cherry-pick or revert result, using 409 when it stopped on conflicts.
+func writeApplyCommitsResult(w http.ResponseWriter, result *models.ApplyCommitsResult) {
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status == git.ApplyStatusConflicted {
+		w.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary      Cherry-pick commits
+// @Description  Apply the changes introduced by one or more commits on top of HEAD
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string                      true  "Repository ID"
+// @Param        body  body     models.ApplyCommitsRequest  true  "Request body"
+// @Success      200   {object} models.ApplyCommitsResult
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.ApplyCommitsResult "Cherry-pick stopped on conflicts"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/cherry-pick [post]
+func (h *RepositoryHandler) CherryPick(w http.ResponseWriter, r *http.Request) {
+	h.applyCommits(w, r, h.gitService.CherryPick, "cherry-pick")
+}
+
+// @Summary      Revert commits
+// @Description  Record new commits that undo the changes introduced by one or more commits
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string                      true  "Repository ID"
+// @Param        body  body     models.ApplyCommitsRequest  true  "Request body"
+// @Success      200   {object} models.ApplyCommitsResult
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.ApplyCommitsResult "Revert stopped on conflicts"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/revert [post]
+func (h *RepositoryHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	h.applyCommits(w, r, h.gitService.Revert, "revert")
+}
+
+func (h *RepositoryHandler) applyCommits(w http.ResponseWriter, r *http.Request, apply func(string, models.ApplyCommitsRequest) (*models.ApplyCommitsResult, error), name string) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.ApplyCommitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Commits) == 0 {
+		http.Error(w, "At least one commit is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := apply(repo.Path, req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to %s: %v", name, err), applyCommitsErrorStatus(err))
+		return
+	}
+
+	writeApplyCommitsResult(w, result)
+}
+
+// @Summary      Continue a cherry-pick
+// @Description  Commit the resolved commit and resume the remaining cherry-picks
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {object} models.ApplyCommitsResult
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.ApplyCommitsResult "Conflicts remain or no cherry-pick in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/cherry-pick/continue [post]
+func (h *RepositoryHandler) ContinueCherryPick(w http.ResponseWriter, r *http.Request) {
+	h.continueApplyCommits(w, r, h.gitService.ContinueCherryPick, "cherry-pick")
+}
+
+// @Summary      Continue a revert
+// @Description  Commit the resolved revert and resume the remaining reverts
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {object} models.ApplyCommitsResult
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.ApplyCommitsResult "Conflicts remain or no revert in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/revert/continue [post]
+func (h *RepositoryHandler) ContinueRevert(w http.ResponseWriter, r *http.Request) {
+	h.continueApplyCommits(w, r, h.gitService.ContinueRevert, "revert")
+}
+
+func (h *RepositoryHandler) continueApplyCommits(w http.ResponseWriter, r *http.Request, resume func(string) (*models.ApplyCommitsResult, error), name string) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	result, err := resume(repo.Path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to continue %s: %v", name, err), applyCommitsErrorStatus(err))
+		return
+	}
+
+	writeApplyCommitsResult(w, result)
+}
+
+// @Summary      Abort a cherry-pick
+// @Description  Cancel an in-progress cherry-pick and restore the original HEAD
+// @Tags         repositories
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {string} string "Aborted"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "No cherry-pick in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/cherry-pick/abort [post]
+func (h *RepositoryHandler) AbortCherryPick(w http.ResponseWriter, r *http.Request) {
+	h.abortApplyCommits(w, r, h.gitService.AbortCherryPick, "cherry-pick")
+}
+
+// @Summary      Abort a revert
+// @Description  Cancel an in-progress revert and restore the original HEAD
+// @Tags         repositories
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {string} string "Aborted"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "No revert in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/revert/abort [post]
+func (h *RepositoryHandler) AbortRevert(w http.ResponseWriter, r *http.Request) {
+	h.abortApplyCommits(w, r, h.gitService.AbortRevert, "revert")
+}
+
+func (h *RepositoryHandler) abortApplyCommits(w http.ResponseWriter, r *http.Request, abort func(string) error, name string) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if err := abort(repo.Path); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to abort %s: %v", name, err), applyCommitsErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(fmt.Sprintf(`{"message": "%s aborted successfully"}`, name)))
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestCherryPick_ReportsConflictsLikeStatus(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createConflictingBranches(t, repoDir)
+	featureHash := revParse(t, repoDir, "feature")
+
+	rec := httptest.NewRecorder()
+	handler.CherryPick(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/cherry-pick", []byte(fmt.Sprintf(`{"commits":[%q]}`, featureHash)), "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result models.ApplyCommitsResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "README.md" || result.Conflicts[0].Type != "both-modified" {
+		t.Fatalf("unexpected conflicts: %+v", result.Conflicts)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.AbortCherryPick(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/cherry-pick", nil, "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ContinueRevert(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/cherry-pick", nil, "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 with no revert in progress, got %d", rec.Code)
+	}
+}
+
+func TestRevert_NoCommitAndBadRequests(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+	runGitInRepo(t, repoDir, "commit", "--allow-empty", "-m", "Empty")
+	head := revParse(t, repoDir, "HEAD")
+
+	rec := httptest.NewRecorder()
+	handler.Revert(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/cherry-pick", []byte(`{"commits":["HEAD~1"],"no_commit":true}`), "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if revParse(t, repoDir, "HEAD") != head {
+		t.Fatal("expected no-commit revert to leave HEAD unchanged")
+	}
+
+	for _, body := range []string{`{}`, `{"commits":["nope"]}`} {
+		rec = httptest.NewRecorder()
+		handler.CherryPick(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/cherry-pick", []byte(body), "id", "test-repo"))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400 for %s, got %d: %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gitweb/server/internal/git"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// compareErrorStatus maps compare errors to HTTP status codes.
+func compareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidCompareOptions),
+		errors.Is(err, git.ErrInvalidDiffOptions),
+		errors.Is(err, git.ErrInvalidRevision),
+		errors.Is(err, git.ErrNoCommonAncestor):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrRevisionNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// @Summary      Compare refs
+// @Description  Compare two refs like a pull request of head into base: the commits on head that are not on base, aggregate stats, every changed file, and tokenized diffs for a page of files. three-dot (default) diffs head against its merge base with base; two-dot diffs base and head directly
+// @Tags         repositories
+// @Produce      json
+// @Param        id      path     string  true   "Repository ID"
+// @Param        base    query    string  true   "Base ref"
+// @Param        head    query    string  false  "Head ref (default HEAD)"
+// @Param        mode    query    string  false  "three-dot or two-dot (default three-dot)"
+// @Param        cursor  query    int     false  "Index of the first file to return a diff for"
+// @Param        limit   query    int     false  "File diffs per page (default 20, at most 100)"
+// @Param        ignore_all_space     query  bool    false  "Ignore all whitespace"
+// @Param        ignore_space_change  query  bool    false  "Ignore changes in the amount of whitespace"
+// @Param        ignore_blank_lines   query  bool    false  "Ignore changes whose lines are all blank"
+// @Param        context              query  int     false  "Lines of context around changes (default 3)"
+// @Param        algorithm            query  string  false  "Diff algorithm: myers (default), patience or histogram"
+// @Param        rename_threshold     query  int     false  "Similarity percent for renames and copies (default 50)"
+// @Success      200   {object} models.Compare
+// @Failure      400   {string} string "Invalid options or refs without a common ancestor"
+// @Failure      404   {string} string "Repository or revision not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/compare [get]
+func (h *RepositoryHandler) CompareRefs(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	diffOpts, err := parseDiffOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := git.CompareOptions{
+		Base:   query.Get("base"),
+		Head:   query.Get("head"),
+		Mode:   query.Get("mode"),
+		Cursor: parseQueryInt(r, "cursor", 0),
+		Limit:  parseQueryInt(r, "limit", 20),
+		Diff:   diffOpts,
+	}
+
+	release, ok := h.enterExpensiveOrReject(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	compare, err := h.gitService.Compare(r.Context(), repo.Path, opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to compare: %v", err), compareErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(compare)
+}
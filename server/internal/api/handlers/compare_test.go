@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestCompareRefs(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+	runGitInRepo(t, repoDir, "checkout", "-q", "-b", "agent/feature")
+	if err := os.WriteFile(filepath.Join(repoDir, "feature.txt"), []byte("feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "add", "feature.txt")
+	runGitInRepo(t, repoDir, "commit", "-q", "-m", "Add feature")
+	runGitInRepo(t, repoDir, "checkout", "-q", "master")
+
+	rec := httptest.NewRecorder()
+	handler.CompareRefs(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/compare?base=master&head=agent/feature", nil, "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var compare models.Compare
+	if err := json.NewDecoder(rec.Body).Decode(&compare); err != nil {
+		t.Fatal(err)
+	}
+	if compare.Mode != "three-dot" || len(compare.Commits) != 1 || compare.Stats.FilesChanged != 1 || len(compare.Diffs) != 1 || compare.Diffs[0].Diff.Additions != 1 {
+		t.Fatalf("unexpected compare: %+v", compare)
+	}
+
+	tests := []struct {
+		repoID, query string
+		status        int
+	}{
+		{"missing", "?base=master", http.StatusNotFound},
+		{"test-repo", "", http.StatusBadRequest},
+		{"test-repo", "?base=master&mode=sideways", http.StatusBadRequest},
+		{"test-repo", "?base=master&algorithm=slow", http.StatusBadRequest},
+		{"test-repo", "?base=master&head=nope", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.CompareRefs(rec, newRouteRequest(http.MethodGet, "/api/repos/"+tt.repoID+"/compare"+tt.query, nil, "id", tt.repoID))
+		if rec.Code != tt.status {
+			t.Errorf("compare %s%s: expected status %d, got %d: %s", tt.repoID, tt.query, tt.status, rec.Code, rec.Body.String())
+		}
+	}
+}
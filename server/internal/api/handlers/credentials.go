@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gitweb/server/internal/credentials"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CredentialHandler handles the credential store and managed known_hosts
+// endpoints.
+type CredentialHandler struct {
+	store *credentials.Store
+}
+
+// NewCredentialHandler creates a CredentialHandler. A nil store, when the
+// credential store could not be opened, answers every request with 503.
+func NewCredentialHandler(store *credentials.Store) *CredentialHandler {
+	return &CredentialHandler{store: store}
+}
+
+// credentialErrorStatus maps credential store errors to HTTP status codes.
+func credentialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, credentials.ErrInvalid),
+		errors.Is(err, credentials.ErrNoPendingHostKey),
+		errors.Is(err, credentials.ErrFingerprintMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, credentials.ErrNotFound),
+		errors.Is(err, credentials.ErrHostNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *CredentialHandler) available(w http.ResponseWriter) bool {
+	if h.store == nil {
+		http.Error(w, "Credential store unavailable", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// @Summary      List credentials
+// @Description  List stored credentials without their secrets
+// @Tags         credentials
+// @Produce      json
+// @Success      200   {array}  models.Credential
+// @Failure      503   {string} string "Credential store unavailable"
+// @Security     BearerAuth
+// @Router       /api/credentials [get]
+func (h *CredentialHandler) ListCredentials(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.store.List())
+}
+
+// @Summary      Add a credential
+// @Description  Store an HTTPS token, an SSH private key or an ssh-agent passthrough for a host
+// @Tags         credentials
+// @Accept       json
+// @Produce      json
+// @Param        body  body     models.CredentialRequest  true  "Request body"
+// @Success      201   {object} models.Credential
+// @Failure      400   {string} string "Bad request"
+// @Failure      503   {string} string "Credential store unavailable"
+// @Security     BearerAuth
+// @Router       /api/credentials [post]
+func (h *CredentialHandler) CreateCredential(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+
+	var req models.CredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cred, err := h.store.Add(req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to add credential: %v", err), credentialErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cred)
+}
+
+// @Summary      Update a credential
+// @Description  Replace a credential; without a token or private key the stored secret is kept unless the kind changes
+// @Tags         credentials
+// @Accept       json
+// @Produce      json
+// @Param        credentialId  path     string                    true  "Credential ID"
+// @Param        body          body     models.CredentialRequest  true  "Request body"
+// @Success      200           {object} models.Credential
+// @Failure      400           {string} string "Bad request"
+// @Failure      404           {string} string "Credential not found"
+// @Failure      503           {string} string "Credential store unavailable"
+// @Security     BearerAuth
+// @Router       /api/credentials/{credentialId} [put]
+func (h *CredentialHandler) UpdateCredential(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+
+	var req models.CredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cred, err := h.store.Update(chi.URLParam(r, "credentialId"), req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update credential: %v", err), credentialErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cred)
+}
+
+// @Summary      Delete a credential
+// @Description  Delete a credential; remotes that chose it fail to authenticate until they choose another
+// @Tags         credentials
+// @Param        credentialId  path     string  true  "Credential ID"
+// @Success      200           {string} string "Deleted"
+// @Failure      404           {string} string "Credential not found"
+// @Failure      503           {string} string "Credential store unavailable"
+// @Security     BearerAuth
+// @Router       /api/credentials/{credentialId} [delete]
+func (h *CredentialHandler) DeleteCredential(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+
+	if err := h.store.Delete(chi.URLParam(r, "credentialId")); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete credential: %v", err), credentialErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Credential deleted successfully"}`))
+}
+
+// @Summary      List known hosts
+// @Description  List trusted SSH host keys and the unknown keys waiting to be trusted
+// @Tags         credentials
+// @Produce      json
+// @Success      200   {object} models.KnownHosts
+// @Failure      503   {string} string "Credential store unavailable"
+// @Security     BearerAuth
+// @Router       /api/credentials/known-hosts [get]
+func (h *CredentialHandler) ListKnownHosts(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+
+	hosts, err := h.store.KnownHosts()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list known hosts: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hosts)
+}
+
+// @Summary      Trust a host key
+// @Description  Trust the pending key of a host; the fingerprint must match the key the host presented
+// @Tags         credentials
+// @Accept       json
+// @Produce      json
+// @Param        body  body     models.TrustHostRequest  true  "Host and confirmed fingerprint"
+// @Success      201   {object} models.KnownHost
+// @Failure      400   {string} string "No pending key or fingerprint mismatch"
+// @Failure      503   {string} string "Credential store unavailable"
+// @Security     BearerAuth
+// @Router       /api/credentials/known-hosts [post]
+func (h *CredentialHandler) TrustHost(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+
+	var req models.TrustHostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	host, err := h.store.TrustHost(req.Host, req.Fingerprint)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to trust host: %v", err), credentialErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(host)
+}
+
+// @Summary      Forget a host key
+// @Description  Remove a host's trusted and pending keys, so that its key is confirmed again on the next connection
+// @Tags         credentials
+// @Param        host  path     string  true  "Host, or [host]:port for a port other than 22"
+// @Success      200   {string} string "Removed"
+// @Failure      400   {string} string "Invalid host"
+// @Failure      404   {string} string "Host not found"
+// @Failure      503   {string} string "Credential store unavailable"
+// @Security     BearerAuth
+// @Router       /api/credentials/known-hosts/{host} [delete]
+func (h *CredentialHandler) ForgetHost(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+
+	host, err := url.PathUnescape(chi.URLParam(r, "host"))
+	if err != nil {
+		http.Error(w, "Invalid host", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.ForgetHost(host); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to forget host: %v", err), credentialErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Host removed successfully"}`))
+}
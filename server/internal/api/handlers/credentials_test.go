@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/credentials"
+	"gitweb/server/internal/models"
+)
+
+func TestCredentials_CRUD(t *testing.T) {
+	store, err := credentials.Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewCredentialHandler(store)
+
+	rec := httptest.NewRecorder()
+	handler.CreateCredential(rec, newRouteRequest(http.MethodPost, "/api/credentials/", []byte(`{"name":"GitHub","kind":"https-token","host":"github.com","token":"ghp_secret"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "ghp_secret") {
+		t.Fatal("expected the token not to be returned")
+	}
+	var cred models.Credential
+	if err := json.NewDecoder(rec.Body).Decode(&cred); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CreateCredential(rec, newRouteRequest(http.MethodPost, "/api/credentials/", []byte(`{"name":"GitHub","kind":"https-token"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without a token, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.UpdateCredential(rec, newRouteRequest(http.MethodPut, "/api/credentials/"+cred.ID, []byte(`{"name":"GitHub work","kind":"https-token","host":"github.com"}`), "credentialId", cred.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ListCredentials(rec, newRouteRequest(http.MethodGet, "/api/credentials/", nil))
+	var list []models.Credential
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "GitHub work" {
+		t.Fatalf("expected the updated credential, got %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DeleteCredential(rec, newRouteRequest(http.MethodDelete, "/api/credentials/"+cred.ID, nil, "credentialId", cred.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DeleteCredential(rec, newRouteRequest(http.MethodDelete, "/api/credentials/"+cred.ID, nil, "credentialId", cred.ID))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.TrustHost(rec, newRouteRequest(http.MethodPost, "/api/credentials/", []byte(`{"host":"example.com","fingerprint":"SHA256:x"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without a pending key, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCredentials_UnavailableStore(t *testing.T) {
+	handler := NewCredentialHandler(nil)
+
+	rec := httptest.NewRecorder()
+	handler.ListCredentials(rec, newRouteRequest(http.MethodGet, "/api/credentials/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRemotes_ChooseCredential(t *testing.T) {
+	store, err := credentials.Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, err := store.Add(models.CredentialRequest{Name: "token", Kind: credentials.KindHTTPSToken, Host: "example.com", Token: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	handler.UseCredentials(store)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.AddRemote(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"name":"origin","url":"https://example.com/repo.git","credential":"missing"}`), "id", "test-repo"))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unknown credential, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.AddRemote(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"name":"origin","url":"https://example.com/repo.git","credential":"`+cred.ID+`"}`), "id", "test-repo"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var remote models.RepoRemote
+	if err := json.NewDecoder(rec.Body).Decode(&remote); err != nil {
+		t.Fatal(err)
+	}
+	if remote.Credential != cred.ID {
+		t.Errorf("expected the chosen credential, got %+v", remote)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.UpdateRemote(rec, newRouteRequest(http.MethodPut, "/api/repos/test-repo/remotes", []byte(`{"credential":""}`), "id", "test-repo", "name", "origin"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetRemotes(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/remotes", nil, "id", "test-repo"))
+	var remotes []models.RepoRemote
+	if err := json.NewDecoder(rec.Body).Decode(&remotes); err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 1 || remotes[0].Credential != "" {
+		t.Fatalf("expected the credential to be cleared, got %+v", remotes)
+	}
+}
@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+)
+
+// Event types published on the /api/events stream.
+const (
+	EventStatusChanged  = "status.changed"
+	EventHeadMoved      = "head.moved"
+	EventRefCreated     = "ref.created"
+	EventRefUpdated     = "ref.updated"
+	EventRefDeleted     = "ref.deleted"
+	EventIndexUpdated   = "index.updated"
+	EventFetchCompleted = "fetch.completed"
+	EventJobProgress    = "job.progress"
+
+	// EventResync tells a resuming client that some of the events it missed
+	// are no longer retained, so it should reload any state it caches.
+	EventResync = "resync"
+)
+
+const (
+	eventHistorySize       = 1024
+	eventSubscriberBuffer  = 256
+	eventKeepAliveInterval = 25 * time.Second
+)
+
+// eventHub fans published events out to stream subscribers and keeps the most
+// recent ones so a reconnecting client can resume where it left off.
+type eventHub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []models.Event
+	subscribers map[*eventSubscription]struct{}
+	closed      bool
+}
+
+type eventSubscription struct {
+	repos  map[string]bool // nil receives events for every repository
+	events chan models.Event
+}
+
+func newEventHub() *eventHub {
+	// Seeding IDs from the clock keeps them increasing across restarts, so an
+	// ID handed out by a previous process always predates the history.
+	return &eventHub{
+		nextID:      uint64(time.Now().UnixMicro()),
+		subscribers: make(map[*eventSubscription]struct{}),
+	}
+}
+
+func (sub *eventSubscription) wants(repoID string) bool {
+	return sub.repos == nil || repoID == "" || sub.repos[repoID]
+}
+
+// publish assigns the next ID to an event and delivers it to every interested
+// subscriber.
+func (hub *eventHub) publish(repoID, eventType string, data any) models.Event {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.nextID++
+	event := models.Event{
+		ID:     hub.nextID,
+		Type:   eventType,
+		RepoID: repoID,
+		Time:   time.Now().UTC(),
+		Data:   data,
+	}
+
+	hub.history = append(hub.history, event)
+	if len(hub.history) > eventHistorySize {
+		hub.history = hub.history[len(hub.history)-eventHistorySize:]
+	}
+
+	for sub := range hub.subscribers {
+		if !sub.wants(repoID) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// A subscriber this far behind is dropped; its client reconnects
+			// with the last event ID it saw and catches up from the history.
+			delete(hub.subscribers, sub)
+			close(sub.events)
+		}
+	}
+
+	return event
+}
+
+// subscribe registers a subscriber for repos (every repository when empty).
+// When resuming, it also returns the retained events after lastEventID, or a
+// single resync event if some of them have already been discarded.
+func (hub *eventHub) subscribe(repos []string, lastEventID uint64, resume bool) (*eventSubscription, []models.Event) {
+	sub := &eventSubscription{events: make(chan models.Event, eventSubscriberBuffer)}
+	if len(repos) > 0 {
+		sub.repos = make(map[string]bool, len(repos))
+		for _, repoID := range repos {
+			sub.repos[repoID] = true
+		}
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		close(sub.events)
+		return sub, nil
+	}
+	hub.subscribers[sub] = struct{}{}
+
+	if !resume {
+		return sub, nil
+	}
+
+	firstRetained := hub.nextID - uint64(len(hub.history)) + 1
+	if lastEventID+1 < firstRetained || lastEventID > hub.nextID {
+		return sub, []models.Event{{ID: hub.nextID, Type: EventResync, Time: time.Now().UTC()}}
+	}
+
+	var backlog []models.Event
+	for _, event := range hub.history {
+		if event.ID > lastEventID && sub.wants(event.RepoID) {
+			backlog = append(backlog, event)
+		}
+	}
+	return sub, backlog
+}
+
+func (hub *eventHub) unsubscribe(sub *eventSubscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if _, ok := hub.subscribers[sub]; ok {
+		delete(hub.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// close ends every subscription and refuses new ones.
+func (hub *eventHub) close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.closed = true
+	for sub := range hub.subscribers {
+		delete(hub.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// StartEventStream ends open event streams once ctx is cancelled, so a
+// graceful shutdown does not wait on clients that never disconnect.
+func (h *RepositoryHandler) StartEventStream(ctx context.Context) {
+	if h == nil || ctx == nil {
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		h.events.close()
+	}()
+}
+
+// @Summary      Stream repository events
+// @Description  Server-sent event stream of typed events for the subscribed repositories (all when repos is omitted): status.changed, head.moved, ref.created, ref.updated, ref.deleted, index.updated, fetch.completed and job.progress. Each event carries an id; reconnecting with Last-Event-ID (or last_event_id) replays what was missed, or sends a resync event when that is no longer possible. EventSource cannot send the bearer token, so read the stream with fetch.
+// @Tags         events
+// @Produce      text/event-stream
+// @Param        repos          query   string  false  "Comma-separated repository IDs"
+// @Param        Last-Event-ID  header  string  false  "Resume after this event ID"
+// @Param        last_event_id  query   string  false  "Resume after this event ID, for clients that cannot set headers"
+// @Success      200  {object}  models.Event
+// @Failure      400  {string}  string "Invalid event ID"
+// @Failure      404  {string}  string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/events [get]
+func (h *RepositoryHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	var repoIDs []string
+	for _, repoID := range strings.Split(r.URL.Query().Get("repos"), ",") {
+		if repoID = strings.TrimSpace(repoID); repoID != "" {
+			repoIDs = append(repoIDs, repoID)
+		}
+	}
+
+	repoPaths := make(map[string]string)
+	h.mu.RLock()
+	if len(repoIDs) == 0 {
+		for repoID, repo := range h.repositories {
+			repoPaths[repoID] = repo.Path
+		}
+	}
+	for _, repoID := range repoIDs {
+		repo, exists := h.repositories[repoID]
+		if !exists {
+			h.mu.RUnlock()
+			http.Error(w, fmt.Sprintf("Repository not found: %s", repoID), http.StatusNotFound)
+			return
+		}
+		repoPaths[repoID] = repo.Path
+	}
+	h.mu.RUnlock()
+
+	var lastEventID uint64
+	rawID := r.Header.Get("Last-Event-ID")
+	if rawID == "" {
+		rawID = r.URL.Query().Get("last_event_id")
+	}
+	if rawID != "" {
+		id, err := strconv.ParseUint(rawID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid event ID", http.StatusBadRequest)
+			return
+		}
+		lastEventID = id
+	}
+
+	sub, backlog := h.events.subscribe(repoIDs, lastEventID, rawID != "")
+	defer h.events.unsubscribe(sub)
+
+	release := h.monitorRepositories(repoPaths)
+	defer release()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	for _, event := range backlog {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes one event in server-sent event framing.
+func writeEvent(w io.Writer, event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// repoMonitor watches one repository for as long as any stream subscribes
+// to it.
+type repoMonitor struct {
+	streams int
+	stop    context.CancelFunc
+}
+
+// monitorRepositories makes sure every repository in repoPaths is being
+// monitored and returns a func that releases them again.
+func (h *RepositoryHandler) monitorRepositories(repoPaths map[string]string) func() {
+	if h.watcher == nil {
+		return func() {}
+	}
+
+	h.monitorsMu.Lock()
+	defer h.monitorsMu.Unlock()
+
+	var acquired []string
+	for repoID, repoPath := range repoPaths {
+		monitor := h.monitors[repoID]
+		if monitor == nil {
+			stop, err := h.startRepoMonitor(repoID, repoPath)
+			if err != nil {
+				h.logf("event stream: failed to watch repository %s: %v", repoID, err)
+				continue
+			}
+			monitor = &repoMonitor{stop: stop}
+			h.monitors[repoID] = monitor
+		}
+		monitor.streams++
+		acquired = append(acquired, repoID)
+	}
+
+	return func() {
+		h.monitorsMu.Lock()
+		defer h.monitorsMu.Unlock()
+
+		for _, repoID := range acquired {
+			monitor := h.monitors[repoID]
+			if monitor.streams--; monitor.streams == 0 {
+				monitor.stop()
+				delete(h.monitors, repoID)
+			}
+		}
+	}
+}
+
+// startRepoMonitor subscribes to file system changes in a repository and
+// publishes events for each part of it that a notification says was touched.
+func (h *RepositoryHandler) startRepoMonitor(repoID, repoPath string) (context.CancelFunc, error) {
+	changes, unsubscribe, err := h.watcher.Subscribe(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := h.gitService.ReadRepoState(repoPath)
+	if err != nil {
+		state = &git.RepoState{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case change, ok := <-changes:
+				if !ok {
+					return
+				}
+				if change.Head || change.Refs || change.Index {
+					if next, err := h.gitService.ReadRepoState(repoPath); err == nil {
+						h.publishStateChanges(repoID, state, next)
+						state = next
+					}
+				}
+				if change.Worktree || change.Index || change.Head || change.Refs {
+					h.publishEvent(repoID, EventStatusChanged, nil)
+				}
+			}
+		}
+	}()
+
+	return cancel, nil
+}
+
+// publishStateChanges publishes the HEAD, ref and index events that explain
+// the difference between two snapshots.
+func (h *RepositoryHandler) publishStateChanges(repoID string, old, next *git.RepoState) {
+	if old.Head != next.Head || old.HeadHash != next.HeadHash {
+		event := models.RefEvent{Ref: next.Head, Hash: next.HeadHash, OldHash: old.HeadHash}
+		if old.Head != next.Head {
+			event.OldRef = old.Head
+		}
+		h.publishEvent(repoID, EventHeadMoved, event)
+	}
+
+	names := make([]string, 0, len(old.Refs)+len(next.Refs))
+	for name := range next.Refs {
+		names = append(names, name)
+	}
+	for name := range old.Refs {
+		if _, ok := next.Refs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldHash, hadRef := old.Refs[name]
+		hash, hasRef := next.Refs[name]
+		switch {
+		case !hadRef:
+			h.publishEvent(repoID, EventRefCreated, models.RefEvent{Ref: name, Hash: hash})
+		case !hasRef:
+			h.publishEvent(repoID, EventRefDeleted, models.RefEvent{Ref: name, OldHash: oldHash})
+		case hash != oldHash:
+			h.publishEvent(repoID, EventRefUpdated, models.RefEvent{Ref: name, Hash: hash, OldHash: oldHash})
+		}
+	}
+
+	if !old.IndexModTime.Equal(next.IndexModTime) || old.IndexSize != next.IndexSize {
+		h.publishEvent(repoID, EventIndexUpdated, nil)
+	}
+}
+
+// publishEvent publishes an event for a repository on the event stream.
+func (h *RepositoryHandler) publishEvent(repoID, eventType string, data any) {
+	h.events.publish(repoID, eventType, data)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitweb/server/internal/models"
+)
+
+// openEventStream connects to the event stream and returns a channel of the
+// events it delivers, closed when the stream ends.
+func openEventStream(t *testing.T, server *httptest.Server, query string, lastEventID uint64) (<-chan models.Event, func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(lastEventID, 10))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		cancel()
+		resp.Body.Close()
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	events := make(chan models.Event, 64)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var event models.Event
+			if err := json.Unmarshal([]byte(data), &event); err == nil {
+				events <- event
+			}
+		}
+	}()
+
+	return events, cancel
+}
+
+// nextEvent waits for the next event of type eventType, skipping others.
+func nextEvent(t *testing.T, events <-chan models.Event, eventType string) models.Event {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("stream ended waiting for %s", eventType)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", eventType)
+		}
+	}
+}
+
+func newEventStreamServer(t *testing.T) (*RepositoryHandler, string, *httptest.Server) {
+	t.Helper()
+
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if handler.watcher == nil {
+		t.Skip("file system watcher unavailable")
+	}
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler.StreamEvents))
+	t.Cleanup(server.Close)
+	return handler, repoDir, server
+}
+
+func TestStreamEvents_PublishesRefHeadAndFetchEvents(t *testing.T) {
+	handler, repoDir, server := newEventStreamServer(t)
+	events, closeStream := openEventStream(t, server, "?repos=test-repo", 0)
+	defer closeStream()
+
+	runGitInRepo(t, repoDir, "branch", "feature")
+	created := nextEvent(t, events, EventRefCreated)
+	if created.RepoID != "test-repo" {
+		t.Fatalf("expected event for test-repo, got %+v", created)
+	}
+	data, _ := json.Marshal(created.Data)
+	if !strings.Contains(string(data), `"ref":"refs/heads/feature"`) {
+		t.Fatalf("expected refs/heads/feature to be created, got %s", data)
+	}
+	nextEvent(t, events, EventStatusChanged)
+
+	runGitInRepo(t, repoDir, "checkout", "-q", "feature")
+	moved := nextEvent(t, events, EventHeadMoved)
+	data, _ = json.Marshal(moved.Data)
+	if !strings.Contains(string(data), `"ref":"refs/heads/feature"`) || !strings.Contains(string(data), `"old_ref":"refs/heads/master"`) {
+		t.Fatalf("expected HEAD to move to feature, got %s", data)
+	}
+
+	handler.recordFetch("test-repo", time.Now(), nil, false)
+	fetched := nextEvent(t, events, EventFetchCompleted)
+	if fetched.ID <= moved.ID || fetched.ID <= created.ID {
+		t.Fatalf("expected increasing event IDs, got %d after %d", fetched.ID, moved.ID)
+	}
+	closeStream()
+
+	// Resuming replays everything after the last event seen.
+	resumed, closeResumed := openEventStream(t, server, "?repos=test-repo", created.ID)
+	defer closeResumed()
+	if replayed := nextEvent(t, resumed, EventHeadMoved); replayed.ID != moved.ID {
+		t.Fatalf("expected head.moved %d to be replayed, got %d", moved.ID, replayed.ID)
+	}
+	if replayed := nextEvent(t, resumed, EventFetchCompleted); replayed.ID != fetched.ID {
+		t.Fatalf("expected fetch.completed %d to be replayed, got %d", fetched.ID, replayed.ID)
+	}
+}
+
+func TestStreamEvents_ResyncsWhenHistoryIsGone(t *testing.T) {
+	handler, _, server := newEventStreamServer(t)
+
+	first := handler.events.publish("test-repo", EventStatusChanged, nil)
+	for i := 0; i <= eventHistorySize; i++ {
+		handler.events.publish("test-repo", EventStatusChanged, nil)
+	}
+
+	events, closeStream := openEventStream(t, server, "", first.ID)
+	defer closeStream()
+	if event := <-events; event.Type != EventResync {
+		t.Fatalf("expected resync, got %+v", event)
+	}
+
+	// IDs from before a restart are also out of range.
+	events, closeStream = openEventStream(t, server, "", first.ID+2*eventHistorySize)
+	defer closeStream()
+	if event := <-events; event.Type != EventResync {
+		t.Fatalf("expected resync for an unknown ID, got %+v", event)
+	}
+}
+
+func TestStreamEvents_FiltersRepositoriesAndValidatesRequest(t *testing.T) {
+	handler, _, server := newEventStreamServer(t)
+
+	base := handler.events.publish("", EventStatusChanged, nil)
+	handler.events.publish("other-repo", EventStatusChanged, nil)
+	mine := handler.events.publish("test-repo", EventIndexUpdated, nil)
+
+	events, closeStream := openEventStream(t, server, "?repos=test-repo", base.ID)
+	defer closeStream()
+	if event := <-events; event.ID != mine.ID {
+		t.Fatalf("expected only test-repo events, got %+v", event)
+	}
+
+	for query, want := range map[string]int{
+		"?repos=missing":           http.StatusNotFound,
+		"?last_event_id=not-a-num": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(server.URL + "/api/events" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: expected %d, got %d", query, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestEventHub_DropsSlowSubscribers(t *testing.T) {
+	hub := newEventHub()
+	sub, _ := hub.subscribe(nil, 0, false)
+
+	for i := 0; i <= eventSubscriberBuffer; i++ {
+		hub.publish("test-repo", EventStatusChanged, nil)
+	}
+
+	received := 0
+	for range sub.events {
+		received++
+	}
+	if received != eventSubscriberBuffer {
+		t.Fatalf("expected the subscription to close after %d buffered events, got %d", eventSubscriberBuffer, received)
+	}
+	hub.unsubscribe(sub)
+}
+
+func TestStartEventStream_EndsStreamsOnShutdown(t *testing.T) {
+	handler, _, server := newEventStreamServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	handler.StartEventStream(ctx)
+
+	events, closeStream := openEventStream(t, server, "", 0)
+	defer closeStream()
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected no events after shutdown")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end on shutdown")
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newRouteRequest builds a request to target carrying the chi URL params given
+// as key/value pairs, as the router would set them. Params with an empty value
+// are left unset.
+func newRouteRequest(method, target string, body []byte, params ...string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBuffer(body))
+	ctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			ctx.URLParams.Add(params[i], params[i+1])
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, ctx))
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gitweb/server/internal/git"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary      Get file history
+// @Description  List the commits that changed a file, newest first, following renames and copies, with the file's path, change type and diff stats in each. Fetch an entry's diff from /diff/commit/{hash}/files/{path} with its hash and path. Pages and filters work as for the commit history, with the next cursor in the X-Gitty-Next-Cursor header
+// @Tags         repositories
+// @Produce      json
+// @Param        id       path     string  true   "Repository ID"
+// @Param        "*"      path     string  true   "File path"
+// @Param        ref      query    string  false  "Ref or range to walk (default HEAD)"
+// @Param        author   query    string  false  "Case-insensitive substring of the author name or email"
+// @Param        since    query    string  false  "Only commits authored on or after this RFC 3339 time or date"
+// @Param        until    query    string  false  "Only commits authored on or before this RFC 3339 time or date"
+// @Param        message  query    string  false  "Case-insensitive substring of the commit message"
+// @Param        regex    query    bool    false  "Match message as a regular expression"
+// @Param        cursor   query    string  false  "Cursor of the page to return, from X-Gitty-Next-Cursor"
+// @Param        limit    query    int     false  "Entries per page (default 50)"
+// @Success      200   {array}  models.FileHistoryEntry
+// @Failure      400   {string} string "Invalid filter, ref or cursor"
+// @Failure      404   {string} string "Repository or revision not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/history/{filepath} [get]
+func (h *RepositoryHandler) GetFileHistory(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	filePath := chi.URLParam(r, "*")
+	decodedPath, err := url.PathUnescape(filePath)
+	if err != nil {
+		decodedPath = filePath // fallback to original if decoding fails
+	}
+	if decodedPath == "" {
+		http.Error(w, "File path is required", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := git.LogOptions{
+		Ref:          query.Get("ref"),
+		Path:         decodedPath,
+		Author:       query.Get("author"),
+		Since:        query.Get("since"),
+		Until:        query.Get("until"),
+		Message:      query.Get("message"),
+		MessageRegex: query.Get("regex") == "true",
+		Cursor:       query.Get("cursor"),
+		Limit:        parseQueryInt(r, "limit", 50),
+	}
+
+	release, ok := h.enterExpensiveOrReject(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	entries, next, err := h.gitService.FileHistory(r.Context(), repo.Path, opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get file history: %v", err), commitLogErrorStatus(err))
+		return
+	}
+
+	if next != "" {
+		w.Header().Set("X-Gitty-Next-Cursor", next)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
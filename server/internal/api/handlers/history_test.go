@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestGetFileHistory(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+	runGitInRepo(t, repoDir, "mv", "README.md", "docs.md")
+	runGitInRepo(t, repoDir, "commit", "-m", "Move docs")
+
+	rec := httptest.NewRecorder()
+	handler.GetFileHistory(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/history/docs.md?limit=1", nil, "id", "test-repo", "*", "docs.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var entries []models.FileHistoryEntry
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	next := rec.Header().Get("X-Gitty-Next-Cursor")
+	if len(entries) != 1 || entries[0].ChangeType != "renamed" || entries[0].OldPath != "README.md" || next == "" {
+		t.Fatalf("unexpected first page: %+v, cursor %q", entries, next)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetFileHistory(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/history/docs.md?cursor="+next, nil, "id", "test-repo", "*", "docs.md"))
+	entries = nil
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "README.md" || entries[0].ChangeType != "added" || rec.Header().Get("X-Gitty-Next-Cursor") != "" {
+		t.Fatalf("unexpected last page: %+v", entries)
+	}
+
+	tests := []struct {
+		repoID, path, query string
+		status              int
+	}{
+		{"missing", "docs.md", "", http.StatusNotFound},
+		{"test-repo", "docs.md", "?ref=nope", http.StatusNotFound},
+		{"test-repo", "docs.md", "?cursor=bogus", http.StatusBadRequest},
+		{"test-repo", "", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.GetFileHistory(rec, newRouteRequest(http.MethodGet, "/api/repos/"+tt.repoID+"/history/"+tt.path+tt.query, nil, "id", tt.repoID, "*", tt.path))
+		if rec.Code != tt.status {
+			t.Errorf("history %s/%s%s: expected status %d, got %d: %s", tt.repoID, tt.path, tt.query, tt.status, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gitweb/server/internal/jobs"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Kinds of background jobs.
+const (
+	jobClone                 = "clone"
+	jobPush                  = "push"
+	jobForcePush             = "force-push"
+	jobPull                  = "pull"
+	jobGenerateCommitMessage = "generate-commit-message"
+)
+
+// jobIDHeader names the job behind a request that waited for its job, so a
+// client whose connection drops can pick the job up from /api/jobs.
+const jobIDHeader = "X-Gitty-Job-ID"
+
+// StartJobs cancels unfinished jobs once ctx is cancelled.
+func (h *RepositoryHandler) StartJobs(ctx context.Context) {
+	if h == nil || ctx == nil {
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		h.jobs.Shutdown()
+	}()
+}
+
+// admitJob holds a job until the resource governor has a slot for it, so
+// jobs queue instead of being rejected under load.
+func (h *RepositoryHandler) admitJob(ctx context.Context) (func(), error) {
+	admission, err := h.governor.WaitExpensive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return admission.Release, nil
+}
+
+// publishJob reports job state changes on the event stream.
+func (h *RepositoryHandler) publishJob(job models.Job) {
+	h.publishEvent(job.RepoID, EventJobProgress, job)
+}
+
+// runJob runs fn as a background job. With async=true the job is returned at
+// once with 202 Accepted, to be polled or followed on the event stream;
+// otherwise the request waits for the job and respond writes its outcome. In
+// both cases the job runs on if the client goes away. The started job is
+// returned.
+func (h *RepositoryHandler) runJob(w http.ResponseWriter, r *http.Request, kind, repoID string, fn jobs.Func, respond func(http.ResponseWriter, any, error)) models.Job {
+	job := h.jobs.Start(kind, repoID, fn)
+
+	if r.URL.Query().Get("async") == "true" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/api/jobs/"+job.ID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+		return job
+	}
+
+	w.Header().Set(jobIDHeader, job.ID)
+	finished, err := h.jobs.Wait(r.Context(), job.ID)
+	if err != nil {
+		// The client went away; the job carries on.
+		return job
+	}
+
+	switch finished.Status {
+	case jobs.StatusSucceeded:
+		respond(w, finished.Result, nil)
+	case jobs.StatusCancelled:
+		http.Error(w, "Job cancelled", http.StatusConflict)
+	default:
+		err := h.jobs.Err(job.ID)
+		if err == nil {
+			// Dropped since it finished; only its message is left.
+			err = errors.New(finished.Error)
+		}
+		respond(w, nil, err)
+	}
+	return finished
+}
+
+// @Summary      List jobs
+// @Description  List background jobs, newest first, optionally for one repository. Finished jobs are kept for a while for polling.
+// @Tags         jobs
+// @Produce      json
+// @Param        repo  query    string  false  "Repository ID"
+// @Success      200   {array}  models.Job
+// @Security     BearerAuth
+// @Router       /api/jobs [get]
+func (h *RepositoryHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	list := h.jobs.List(strings.TrimSpace(r.URL.Query().Get("repo")))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary      Get job
+// @Description  Poll a background job's status, progress and, once finished, its result or error
+// @Tags         jobs
+// @Produce      json
+// @Param        jobId  path     string  true  "Job ID"
+// @Success      200    {object} models.Job
+// @Failure      404    {string} string "Job not found"
+// @Security     BearerAuth
+// @Router       /api/jobs/{jobId} [get]
+func (h *RepositoryHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.jobs.Get(chi.URLParam(r, "jobId"))
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// @Summary      Cancel job
+// @Description  Ask a queued or running job to stop; it reports cancelled once its work has returned
+// @Tags         jobs
+// @Produce      json
+// @Param        jobId  path     string  true  "Job ID"
+// @Success      202    {object} models.Job
+// @Failure      404    {string} string "Job not found"
+// @Failure      409    {string} string "Job already finished"
+// @Security     BearerAuth
+// @Router       /api/jobs/{jobId}/cancel [post]
+func (h *RepositoryHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Cancel(chi.URLParam(r, "jobId"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, jobs.ErrJobFinished):
+			status = http.StatusConflict
+		}
+		http.Error(w, fmt.Sprintf("Failed to cancel job: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitweb/server/internal/jobs"
+	"gitweb/server/internal/models"
+	"gitweb/server/internal/resources"
+)
+
+// pollJob polls GetJob until the job reaches status.
+func pollJob(t *testing.T, handler *RepositoryHandler, jobID, status string) models.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := httptest.NewRecorder()
+		handler.GetJob(rec, newRouteRequest(http.MethodGet, "/api/jobs/"+jobID, nil, "jobId", jobID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var job models.Job
+		if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s stayed %q, want %q", jobID, job.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPush_AsyncJobQueuesOnGovernor(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	handler.governor = resources.NewGovernor(resources.Config{
+		Enabled:              true,
+		MaxExpensiveInflight: 1,
+	})
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bare := t.TempDir()
+	runGitInRepo(t, bare, "init", "--bare")
+	runGitInRepo(t, repoDir, "remote", "add", "origin", bare)
+
+	held := handler.governor.AdmitExpensive()
+	if !held.Admitted {
+		t.Fatal("expected to hold the only expensive slot")
+	}
+
+	req := newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"refspec":"refs/heads/master:refs/heads/master"}`), "id", "test-repo")
+	req.URL.RawQuery = "async=true"
+	rec := httptest.NewRecorder()
+	handler.Push(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job models.Job
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+		t.Fatal(err)
+	}
+	if job.Kind != jobPush || job.RepoID != "test-repo" || rec.Header().Get("Location") != "/api/jobs/"+job.ID {
+		t.Fatalf("unexpected job %+v (Location %q)", job, rec.Header().Get("Location"))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if job := pollJob(t, handler, job.ID, jobs.StatusQueued); job.StartedAt != nil {
+		t.Fatalf("expected job to wait for the governor, got %+v", job)
+	}
+
+	held.Release()
+	pollJob(t, handler, job.ID, jobs.StatusSucceeded)
+	if revParse(t, bare, "refs/heads/master") != revParse(t, repoDir, "HEAD") {
+		t.Error("expected master to be pushed to the remote")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ListJobs(rec, httptest.NewRequest(http.MethodGet, "/api/jobs?repo=test-repo", nil))
+	var list []models.Job
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != job.ID {
+		t.Fatalf("expected the push job to be listed, got %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CancelJob(rec, newRouteRequest(http.MethodPost, "/api/jobs/"+job.ID, nil, "jobId", job.ID))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for a finished job, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.CancelJob(rec, newRouteRequest(http.MethodPost, "/api/jobs/missing", nil, "jobId", "missing"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPush_CancelQueuedJob(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	handler.governor = resources.NewGovernor(resources.Config{
+		Enabled:              true,
+		MaxExpensiveInflight: 1,
+	})
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+	held := handler.governor.AdmitExpensive()
+	defer held.Release()
+
+	req := newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{}`), "id", "test-repo")
+	req.URL.RawQuery = "async=true"
+	rec := httptest.NewRecorder()
+	handler.Push(rec, req)
+	var job models.Job
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CancelJob(rec, newRouteRequest(http.MethodPost, "/api/jobs/"+job.ID, nil, "jobId", job.ID))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if job := pollJob(t, handler, job.ID, jobs.StatusCancelled); job.StartedAt != nil {
+		t.Fatalf("expected the job never to start, got %+v", job)
+	}
+}
+
+func TestPull_SyncRequestReportsJobID(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Pull(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"remote":"missing"}`), "id", "test-repo"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	jobID := rec.Header().Get(jobIDHeader)
+	job, ok := handler.jobs.Get(jobID)
+	if !ok || job.Kind != jobPull || job.Status != jobs.StatusFailed {
+		t.Fatalf("expected a failed pull job for %q, got %+v", jobID, job)
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary      Merge a branch
+// @Description  Merge a branch, tag or commit into the current branch
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string               true  "Repository ID"
+// @Param        body  body     models.MergeRequest  true  "Request body"
+// @Success      200   {object} models.MergeResult
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.MergeResult "Merge stopped on conflicts"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/merge [post]
+func (h *RepositoryHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Source = strings.TrimSpace(req.Source)
+	if req.Source == "" {
+		http.Error(w, "Merge source is required", http.StatusBadRequest)
+		return
+	}
+	if req.Strategy == "" {
+		req.Strategy = git.MergeNoFastForward
+	}
+	if !git.IsValidMergeStrategy(req.Strategy) {
+		http.Error(w, "strategy must be one of ff-only, no-ff, or squash", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.gitService.Merge(repo.Path, req.Source, req.Strategy)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, git.ErrMergeSourceNotFound) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, fmt.Sprintf("Failed to merge: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status == git.MergeStatusConflicted {
+		w.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary      List conflicted files
+// @Description  List unmerged paths with their conflict type
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {array}  models.ConflictFile
+// @Failure      404   {string} string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/conflicts [get]
+func (h *RepositoryHandler) GetConflicts(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	conflicts, err := h.gitService.GetConflicts(repo.Path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get conflicts: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conflicts)
+}
+
+// @Summary      Get conflict detail
+// @Description  Get base, ours and theirs content plus parsed conflict hunks for a path
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Param        "*"   path     string  true  "File path"
+// @Success      200   {object} models.ConflictDetail
+// @Failure      404   {string} string "Repository not found or path not conflicted"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/conflicts/{filepath} [get]
+func (h *RepositoryHandler) GetConflictDetail(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+	filePath := chi.URLParam(r, "*")
+	decodedPath, err := url.PathUnescape(filePath)
+	if err != nil {
+		decodedPath = filePath // fallback to original if decoding fails
+	}
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if decodedPath == "" {
+		http.Error(w, "File path is required", http.StatusBadRequest)
+		return
+	}
+
+	detail, err := h.gitService.GetConflictDetail(repo.Path, decodedPath)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, git.ErrNotConflicted) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Failed to get conflict: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// @Summary      Resolve a conflict
+// @Description  Write the chosen or hand-edited result for a conflicted path and mark it resolved
+// @Tags         repositories
+// @Accept       json
+// @Param        id    path     string                         true  "Repository ID"
+// @Param        "*"   path     string                         true  "File path"
+// @Param        body  body     models.ResolveConflictRequest  true  "Request body"
+// @Success      200   {string} string "Resolved"
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository not found or path not conflicted"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/conflicts/{filepath} [post]
+func (h *RepositoryHandler) ResolveConflict(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+	filePath := chi.URLParam(r, "*")
+	decodedPath, err := url.PathUnescape(filePath)
+	if err != nil {
+		decodedPath = filePath // fallback to original if decoding fails
+	}
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if decodedPath == "" {
+		http.Error(w, "File path is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ResolveConflictRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	switch req.Resolution {
+	case git.ResolveOurs, git.ResolveTheirs, git.ResolveBase, git.ResolveManual, git.ResolveHunks, git.ResolveDelete:
+	default:
+		http.Error(w, "resolution must be one of ours, theirs, base, manual, hunks, or delete", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.gitService.ResolveConflict(repo.Path, decodedPath, req); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, git.ErrNotConflicted):
+			status = http.StatusNotFound
+		case errors.Is(err, git.ErrInvalidHunk):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, fmt.Sprintf("Failed to resolve conflict: %v", err), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Conflict resolved successfully"}`))
+}
+
+// @Summary      Continue a merge
+// @Description  Conclude an in-progress merge after all conflicts are resolved
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {object} models.MergeResult
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.MergeResult "Unresolved conflicts or no merge in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/merge/continue [post]
+func (h *RepositoryHandler) ContinueMerge(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	result, err := h.gitService.ContinueMerge(repo.Path)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, git.ErrNoOperation) {
+			status = http.StatusConflict
+		}
+		http.Error(w, fmt.Sprintf("Failed to continue merge: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status == git.MergeStatusConflicted {
+		w.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary      Abort a merge
+// @Description  Abandon an in-progress merge and restore the pre-merge state
+// @Tags         repositories
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {string} string "Aborted"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "No merge in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/merge/abort [post]
+func (h *RepositoryHandler) AbortMerge(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.gitService.AbortMerge(repo.Path); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, git.ErrNoOperation) {
+			status = http.StatusConflict
+		}
+		http.Error(w, fmt.Sprintf("Failed to abort merge: %v", err), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Merge aborted successfully"}`))
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func runGitInRepo(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, output)
+	}
+}
+
+// createConflictingBranches sets up branch "feature" editing README.md in a way
+// that conflicts with a commit on the current branch.
+func createConflictingBranches(t *testing.T, repoDir string) {
+	t.Helper()
+
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+	base := currentBranchName(t, repoDir)
+
+	runGitInRepo(t, repoDir, "checkout", "-b", "feature")
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "commit", "-am", "Edit on feature")
+
+	runGitInRepo(t, repoDir, "checkout", base)
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "commit", "-am", "Edit on main")
+}
+
+func currentBranchName(t *testing.T, dir string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes.TrimSpace(output))
+}
+
+func TestMerge_ReturnsConflictsWith409(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createConflictingBranches(t, repoDir)
+
+	rec := httptest.NewRecorder()
+	handler.Merge(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/merge", []byte(`{"source":"feature","strategy":"no-ff"}`), "id", "test-repo"))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result models.MergeResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != "README.md" {
+		t.Fatalf("expected README.md conflict, got %v", result.Conflicts)
+	}
+}
+
+func TestMerge_FastForward(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+	base := currentBranchName(t, repoDir)
+	runGitInRepo(t, repoDir, "checkout", "-b", "feature")
+	if err := os.WriteFile(filepath.Join(repoDir, "feature.txt"), []byte("feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "add", "feature.txt")
+	runGitInRepo(t, repoDir, "commit", "-m", "Add feature")
+	runGitInRepo(t, repoDir, "checkout", base)
+
+	rec := httptest.NewRecorder()
+	handler.Merge(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/merge", []byte(`{"source":"feature","strategy":"ff-only"}`), "id", "test-repo"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result models.MergeResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Status != "fast-forward" {
+		t.Fatalf("expected fast-forward status, got %q", result.Status)
+	}
+}
+
+func TestMerge_BadRequests(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		repoID string
+		body   string
+		status int
+	}{
+		{name: "missing source", repoID: "test-repo", body: `{}`, status: http.StatusBadRequest},
+		{name: "invalid strategy", repoID: "test-repo", body: `{"source":"main","strategy":"octopus"}`, status: http.StatusBadRequest},
+		{name: "unknown source", repoID: "test-repo", body: `{"source":"nope"}`, status: http.StatusBadRequest},
+		{name: "unknown repo", repoID: "missing", body: `{"source":"main"}`, status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.Merge(rec, newRouteRequest(http.MethodPost, "/api/repos/"+tt.repoID+"/merge", []byte(tt.body), "id", tt.repoID))
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestConflicts_ResolveAndContinue(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createConflictingBranches(t, repoDir)
+
+	rec := httptest.NewRecorder()
+	handler.Merge(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/merge", []byte(`{"source":"feature"}`), "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected merge to conflict, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetConflicts(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/conflicts/", nil, "id", "test-repo"))
+	var files []models.ConflictFile
+	if err := json.Unmarshal(rec.Body.Bytes(), &files); err != nil {
+		t.Fatalf("decode conflicts: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "README.md" || files[0].Type != "both-modified" {
+		t.Fatalf("unexpected conflicts %v", files)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetConflictDetail(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/conflicts/README.md", nil, "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var detail models.ConflictDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode detail: %v", err)
+	}
+	if detail.Ours == nil || detail.Ours.Content != "# Main\n" || len(detail.Hunks) != 1 {
+		t.Fatalf("unexpected detail %+v", detail)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ResolveConflict(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/conflicts/README.md", []byte(`{"resolution":"sideways"}`), "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for bad resolution, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ResolveConflict(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/conflicts/README.md", []byte(`{"resolution":"hunks","hunks":["theirs","ours"]}`), "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a wrong number of hunk resolutions, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ResolveConflict(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/conflicts/README.md", []byte(`{"resolution":"hunks","hunks":["theirs"]}`), "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ContinueMerge(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/merge", nil, "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.AbortMerge(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/merge", nil, "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 with no merge in progress, got %d", rec.Code)
+	}
+}
+
+func TestGetConflictDetail_NotConflicted(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.GetConflictDetail(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/conflicts/README.md", nil, "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
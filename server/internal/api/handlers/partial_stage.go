@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary      Stage hunks or lines
+// @Description  Stage selected hunks and line ranges of a file's unstaged tokenized diff; rejected when the file changed since the diff was generated
+// @Tags         repositories
+// @Accept       json
+// @Param        id    path     string                      true  "Repository ID"
+// @Param        "*"   path     string                      true  "File path"
+// @Param        body  body     models.PartialStageRequest  true  "Request body"
+// @Success      200   {string} string "Staged"
+// @Failure      400   {string} string "Bad request or invalid selection"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "File changed since the diff was generated"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/stage-partial/{filepath} [post]
+func (h *RepositoryHandler) StagePartial(w http.ResponseWriter, r *http.Request) {
+	h.applyPartialStage(w, r, false)
+}
+
+// @Summary      Unstage hunks or lines
+// @Description  Unstage selected hunks and line ranges of a file's staged tokenized diff; rejected when the index changed since the diff was generated
+// @Tags         repositories
+// @Accept       json
+// @Param        id    path     string                      true  "Repository ID"
+// @Param        "*"   path     string                      true  "File path"
+// @Param        body  body     models.PartialStageRequest  true  "Request body"
+// @Success      200   {string} string "Unstaged"
+// @Failure      400   {string} string "Bad request or invalid selection"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "File changed since the diff was generated"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/unstage-partial/{filepath} [post]
+func (h *RepositoryHandler) UnstagePartial(w http.ResponseWriter, r *http.Request) {
+	h.applyPartialStage(w, r, true)
+}
+
+func (h *RepositoryHandler) applyPartialStage(w http.ResponseWriter, r *http.Request, unstage bool) {
+	repoID := chi.URLParam(r, "id")
+	filePath := chi.URLParam(r, "*")
+	decodedPath, err := url.PathUnescape(filePath)
+	if err != nil {
+		decodedPath = filePath // fallback to original if decoding fails
+	}
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if decodedPath == "" {
+		http.Error(w, "File path is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.PartialStageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Fingerprint == "" {
+		http.Error(w, "fingerprint is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Hunks) == 0 && len(req.Lines) == 0 {
+		http.Error(w, "At least one hunk or line range is required", http.StatusBadRequest)
+		return
+	}
+
+	action := "stage"
+	if unstage {
+		action = "unstage"
+		err = h.gitService.UnstagePartial(repo.Path, decodedPath, req)
+	} else {
+		err = h.gitService.StagePartial(repo.Path, decodedPath, req)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to %s changes: %v", action, err), partialStageErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if unstage {
+		w.Write([]byte(`{"message": "Changes unstaged successfully"}`))
+	} else {
+		w.Write([]byte(`{"message": "Changes staged successfully"}`))
+	}
+}
+
+// partialStageErrorStatus maps partial staging errors to HTTP status codes.
+func partialStageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrStaleDiff):
+		return http.StatusConflict
+	case errors.Is(err, git.ErrInvalidSelection):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitweb/server/internal/git"
+)
+
+func TestStagePartial_StagesSelectionAndRejectsStaleDiff(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Test Repository\nline 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := handler.gitService.TokenizeDiffFromPatch(repoDir, "README.md", false, 0, 50, git.DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]any{"fingerprint": diff.Fingerprint, "hunks": []int{0}})
+
+	rec := httptest.NewRecorder()
+	handler.StagePartial(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", body, "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.StagePartial(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", body, "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for a stale diff, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	staged, err := handler.gitService.TokenizeDiffFromPatch(repoDir, "README.md", true, 0, 50, git.DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = json.Marshal(map[string]any{
+		"fingerprint": staged.Fingerprint,
+		"lines":       []map[string]any{{"side": "new", "start": 2}},
+	})
+
+	rec = httptest.NewRecorder()
+	handler.UnstagePartial(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", body, "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStagePartial_RejectsInvalidRequests(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing fingerprint", `{"hunks":[0]}`},
+		{"empty selection", `{"fingerprint":"abc"}`},
+		{"invalid body", `{`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.StagePartial(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", []byte(tt.body), "id", "test-repo", "*", "README.md"))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// rebaseErrorStatus maps rebase errors to HTTP status codes.
+func rebaseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidRebasePlan):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrOperationInProgress),
+		errors.Is(err, git.ErrNoOperation),
+		errors.Is(err, git.ErrLocalChanges):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeRebaseResult encodes a rebase result, using 409 when the rebase paused.
+func writeRebaseResult(w http.ResponseWriter, result *models.RebaseResult) {
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status == git.RebaseStatusConflicted || result.Status == git.RebaseStatusStopped {
+		w.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary      Start an interactive rebase
+// @Description  Replay an explicit plan of pick, reword, squash, fixup and drop steps onto a base; pauses on conflicts
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string                true  "Repository ID"
+// @Param        body  body     models.RebaseRequest  true  "Request body"
+// @Success      200   {object} models.RebaseResult
+// @Failure      400   {string} string "Invalid plan"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.RebaseResult "Rebase paused on conflicts"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/rebase [post]
+func (h *RepositoryHandler) Rebase(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.RebaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Onto = strings.TrimSpace(req.Onto)
+	if req.Onto == "" {
+		http.Error(w, "Rebase base is required", http.StatusBadRequest)
+		return
+	}
+
+	release, ok := h.enterExpensiveOrReject(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	result, err := h.gitService.Rebase(repo.Path, req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to rebase: %v", err), rebaseErrorStatus(err))
+		return
+	}
+
+	writeRebaseResult(w, result)
+}
+
+// @Summary      Get rebase progress
+// @Description  Report the position of an in-progress rebase
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {object} models.RebaseProgress
+// @Failure      404   {string} string "Repository not found or no rebase in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/rebase [get]
+func (h *RepositoryHandler) GetRebaseProgress(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	progress := h.gitService.GetRebaseProgress(repo.Path)
+	if progress == nil {
+		http.Error(w, "No rebase in progress", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// @Summary      Continue a rebase
+// @Description  Resume a paused rebase once conflicts are resolved
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {object} models.RebaseResult
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.RebaseResult "Rebase paused again or no rebase in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/rebase/continue [post]
+func (h *RepositoryHandler) ContinueRebase(w http.ResponseWriter, r *http.Request) {
+	h.runRebaseCommand(w, r, h.gitService.ContinueRebase, "continue")
+}
+
+// @Summary      Skip a rebase step
+// @Description  Drop the commit the rebase stopped at and resume with the next step
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {object} models.RebaseResult
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {object} models.RebaseResult "Rebase paused again or no rebase in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/rebase/skip [post]
+func (h *RepositoryHandler) SkipRebase(w http.ResponseWriter, r *http.Request) {
+	h.runRebaseCommand(w, r, h.gitService.SkipRebase, "skip")
+}
+
+// @Summary      Abort a rebase
+// @Description  Abandon an in-progress rebase and restore the original branch
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {object} models.RebaseResult
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "No rebase in progress"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/rebase/abort [post]
+func (h *RepositoryHandler) AbortRebase(w http.ResponseWriter, r *http.Request) {
+	h.runRebaseCommand(w, r, h.gitService.AbortRebase, "abort")
+}
+
+func (h *RepositoryHandler) runRebaseCommand(w http.ResponseWriter, r *http.Request, command func(string) (*models.RebaseResult, error), name string) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	result, err := command(repo.Path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to %s rebase: %v", name, err), rebaseErrorStatus(err))
+		return
+	}
+
+	writeRebaseResult(w, result)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func revParse(t *testing.T, dir, rev string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", rev)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestRebase_ConflictThenAbort(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createConflictingBranches(t, repoDir)
+	base := currentBranchName(t, repoDir)
+	runGitInRepo(t, repoDir, "checkout", "feature")
+	featureHash := revParse(t, repoDir, "HEAD")
+
+	body := fmt.Sprintf(`{"onto":%q,"steps":[{"action":"pick","hash":%q}]}`, base, featureHash)
+	rec := httptest.NewRecorder()
+	handler.Rebase(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/rebase", []byte(body), "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result models.RebaseResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if result.Status != "conflicted" || len(result.Conflicts) != 1 || result.Conflicts[0].Path != "README.md" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetRebaseProgress(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/rebase", nil, "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.AbortRebase(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/rebase", nil, "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if head := revParse(t, repoDir, "HEAD"); head != featureHash {
+		t.Fatalf("expected abort to restore %s, got %s", featureHash, head)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ContinueRebase(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/rebase", nil, "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 with no rebase in progress, got %d", rec.Code)
+	}
+}
+
+func TestRebase_BadRequests(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "missing base", body: `{"steps":[{"action":"pick","hash":"HEAD"}]}`},
+		{name: "empty plan", body: `{"onto":"HEAD"}`},
+		{name: "invalid action", body: `{"onto":"HEAD","steps":[{"action":"edit","hash":"HEAD"}]}`},
+		{name: "reword without message", body: `{"onto":"HEAD","steps":[{"action":"reword","hash":"HEAD"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.Rebase(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/rebase", []byte(tt.body), "id", "test-repo"))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitweb/server/internal/credentials"
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// remoteErrorStatus maps remote, fetch, push and pull errors to HTTP status codes.
+func remoteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidRemoteName),
+		errors.Is(err, git.ErrRemoteURLRequired),
+		errors.Is(err, git.ErrInvalidRemoteURL),
+		errors.Is(err, git.ErrInvalidRefSpec),
+		errors.Is(err, git.ErrUnsupportedPullStrategy),
+		errors.Is(err, credentials.ErrNotFound),
+		errors.Is(err, credentials.ErrInvalid),
+		errors.Is(err, credentials.ErrUnsuitable):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrAuthenticationRequired),
+		errors.Is(err, git.ErrAuthorizationFailed):
+		return http.StatusForbidden
+	case errors.Is(err, git.ErrRemoteNotFound),
+		errors.Is(err, git.ErrRemoteBranchNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, git.ErrRemoteExists),
+		errors.Is(err, git.ErrDiverged),
+		errors.Is(err, git.ErrOperationInProgress),
+		errors.Is(err, git.ErrLocalChanges),
+		errors.Is(err, git.ErrDetachedHead),
+		errors.Is(err, credentials.ErrUnknownHostKey),
+		errors.Is(err, credentials.ErrHostKeyMismatch):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// @Summary      List remotes
+// @Description  List configured remotes and their URLs
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {array}  models.RepoRemote
+// @Failure      404   {string} string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/remotes [get]
+func (h *RepositoryHandler) GetRemotes(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	remotes, err := h.gitService.GetRemotes(repo.Path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get remotes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remotes)
+}
+
+// @Summary      Add a remote
+// @Description  Register a new remote, optionally choosing the credential it authenticates with
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string                   true  "Repository ID"
+// @Param        body  body     models.AddRemoteRequest  true  "Request body"
+// @Success      201   {object} models.RepoRemote
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "Remote already exists"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/remotes [post]
+func (h *RepositoryHandler) AddRemote(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.AddRemoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Credential = strings.TrimSpace(req.Credential)
+	if err := h.checkCredential(req.Credential); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	remote, err := h.gitService.AddRemote(repo.Path, strings.TrimSpace(req.Name), strings.TrimSpace(req.URL))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to add remote: %v", err), remoteErrorStatus(err))
+		return
+	}
+	if req.Credential != "" {
+		if err := h.gitService.SetRemoteCredential(repo.Path, remote.Name, req.Credential); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to set remote credential: %v", err), remoteErrorStatus(err))
+			return
+		}
+		remote.Credential = req.Credential
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(remote)
+}
+
+// @Summary      Update a remote
+// @Description  Rename a remote, change its URL and/or choose its credential; an empty credential goes back to matching by host
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string                      true  "Repository ID"
+// @Param        name  path     string                      true  "Remote name"
+// @Param        body  body     models.UpdateRemoteRequest  true  "Request body"
+// @Success      200   {object} models.RepoRemote
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository or remote not found"
+// @Failure      409   {string} string "Remote already exists"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/remotes/{name} [put]
+func (h *RepositoryHandler) UpdateRemote(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+	name := chi.URLParam(r, "name")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.UpdateRemoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.URL = strings.TrimSpace(req.URL)
+	if req.Credential != nil {
+		id := strings.TrimSpace(*req.Credential)
+		req.Credential = &id
+	}
+	if req.Name == "" && req.URL == "" && req.Credential == nil {
+		http.Error(w, "name, url or credential is required", http.StatusBadRequest)
+		return
+	}
+	if req.Credential != nil {
+		if err := h.checkCredential(*req.Credential); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	remote, err := h.gitService.UpdateRemote(repo.Path, name, req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update remote: %v", err), remoteErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remote)
+}
+
+// @Summary      Remove a remote
+// @Description  Remove a remote and its remote-tracking branches
+// @Tags         repositories
+// @Param        id    path     string  true  "Repository ID"
+// @Param        name  path     string  true  "Remote name"
+// @Success      200   {string} string "Removed"
+// @Failure      404   {string} string "Repository or remote not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/remotes/{name} [delete]
+func (h *RepositoryHandler) RemoveRemote(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+	name := chi.URLParam(r, "name")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.gitService.RemoveRemote(repo.Path, name); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to remove remote: %v", err), remoteErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Remote removed successfully"}`))
+}
+
+// @Summary      Fetch
+// @Description  Fetch one remote, or all remotes when none is given, optionally pruning deleted branches. When fetching all remotes, a remote that fails is reported with its error in the result and the others are still fetched; the request only fails when no remote could be fetched.
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string               true   "Repository ID"
+// @Param        body  body     models.FetchRequest  false  "Remote and prune flag"
+// @Success      200   {object} models.FetchResult
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository or remote not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/fetch [post]
+func (h *RepositoryHandler) Fetch(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.FetchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.gitService.Fetch(repo.Path, strings.TrimSpace(req.Remote), req.Prune)
+	fetchErr := err
+	if err == nil {
+		// Some remotes may have failed while fetching all of them
+		fetchErr = git.FetchError(result)
+	}
+	h.recordFetch(repoID, time.Now(), fetchErr, false)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch: %v", err), remoteErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
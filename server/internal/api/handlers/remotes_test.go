@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestRemotes_CRUD(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.AddRemote(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"name":"upstream","url":"https://example.com/upstream.git"}`), "id", "test-repo"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.AddRemote(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"name":"upstream","url":"https://example.com/other.git"}`), "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for duplicate remote, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.UpdateRemote(rec, newRouteRequest(http.MethodPut, "/api/repos/test-repo/remotes", []byte(`{"name":"fork","url":"https://example.com/fork.git"}`), "id", "test-repo", "name", "upstream"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var remote models.RepoRemote
+	if err := json.NewDecoder(rec.Body).Decode(&remote); err != nil {
+		t.Fatal(err)
+	}
+	if remote.Name != "fork" || remote.URL != "https://example.com/fork.git" {
+		t.Errorf("unexpected remote: %+v", remote)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetRemotes(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/remotes", nil, "id", "test-repo"))
+	var remotes []models.RepoRemote
+	if err := json.NewDecoder(rec.Body).Decode(&remotes); err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 1 || remotes[0].Name != "fork" {
+		t.Fatalf("expected only fork, got %+v", remotes)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.RemoveRemote(rec, newRouteRequest(http.MethodDelete, "/api/repos/test-repo/remotes", nil, "id", "test-repo", "name", "fork"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.RemoveRemote(rec, newRouteRequest(http.MethodDelete, "/api/repos/test-repo/remotes", nil, "id", "test-repo", "name", "fork"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestFetchPushPull_UnknownRemote(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	if _, err := createTestRepository(handler, "test-repo"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+	}{
+		{"fetch", handler.Fetch, `{"remote":"missing","prune":true}`},
+		{"push", handler.Push, `{"remote":"missing","refspec":"main"}`},
+		{"pull", handler.Pull, `{"remote":"missing"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(tt.body), "id", "test-repo"))
+			if rec.Code != http.StatusNotFound {
+				t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPull_UsesRepositoryPullStrategy(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+
+	bare := t.TempDir()
+	runGitInRepo(t, bare, "init", "--bare")
+	runGitInRepo(t, repoDir, "remote", "add", "origin", bare)
+	runGitInRepo(t, repoDir, "push", "origin", "HEAD:refs/heads/main")
+
+	other := t.TempDir()
+	runGitInRepo(t, other, "clone", "-b", "main", bare, ".")
+	if err := os.WriteFile(filepath.Join(other, "remote.txt"), []byte("remote\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, other, "add", "remote.txt")
+	runGitInRepo(t, other, "-c", "user.name=Other", "-c", "user.email=other@example.com", "commit", "-m", "Remote change")
+	runGitInRepo(t, other, "push", "origin", "main")
+
+	if err := os.WriteFile(filepath.Join(repoDir, "local.txt"), []byte("local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "add", "local.txt")
+	runGitInRepo(t, repoDir, "commit", "-m", "Local change")
+
+	settings := defaultRepoAppSettings()
+	settings.Sync.PullStrategy = "fast-forward"
+	if err := handler.saveRepoAppSettings("test-repo", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Pull(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"refspec":"main"}`), "id", "test-repo"))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "1 ahead, 1 behind") {
+		t.Fatalf("expected 409 diverged error, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.Pull(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"refspec":"main","strategy":"squash"}`), "id", "test-repo"))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unknown strategy, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.Pull(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/remotes", []byte(`{"refspec":"main","strategy":"rebase"}`), "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result models.PullResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "rebased" || result.Strategy != "rebase" || result.Ahead != 1 || result.Behind != 1 {
+		t.Fatalf("unexpected pull result: %+v", result)
+	}
+	if revParse(t, repoDir, "HEAD~1") != revParse(t, repoDir, "refs/remotes/origin/main") {
+		t.Error("expected local commit to be rebased onto origin/main")
+	}
+}
@@ -18,7 +18,9 @@ import (
 	"time"
 
 	"gitweb/server/internal/config"
+	"gitweb/server/internal/credentials"
 	"gitweb/server/internal/git"
+	"gitweb/server/internal/jobs"
 	"gitweb/server/internal/models"
 	"gitweb/server/internal/registry"
 	"gitweb/server/internal/resources"
@@ -28,8 +30,9 @@ import (
 )
 
 type repoAppSettings struct {
-	Sync   models.RepoSyncSettings   `json:"sync"`
-	Commit models.RepoCommitSettings `json:"commit"`
+	Sync      models.RepoSyncSettings   `json:"sync"`
+	Commit    models.RepoCommitSettings `json:"commit"`
+	LastFetch *models.FetchStatus       `json:"last_fetch,omitempty"` // outcome of the last fetch, kept across restarts
 }
 
 // ImportRepositoryRequest represents the request body for importing a repository
@@ -56,6 +59,7 @@ type RepositoryHandler struct {
 	settingsMu    sync.Mutex
 	gitService    *git.Service
 	repositories  map[string]*models.Repository
+	cloning       map[string]bool // repository IDs reserved by clone jobs
 	dataPath      string
 	watcher       *git.RepositoryWatcher
 	config        *config.Config
@@ -70,6 +74,20 @@ type RepositoryHandler struct {
 	pressureMonitorStop chan struct{}
 	pressureMonitorDone chan struct{}
 	logf                func(string, ...any)
+
+	autoFetchMu    sync.Mutex
+	autoFetchDone  chan struct{}
+	fetchMu        sync.Mutex
+	fetchSchedules map[string]*autoFetchSchedule
+	fetchStatuses  map[string]*models.FetchStatus
+
+	events     *eventHub
+	monitorsMu sync.Mutex
+	monitors   map[string]*repoMonitor
+
+	jobs *jobs.Manager
+
+	credentials *credentials.Store
 }
 
 func defaultRepoAppSettings() repoAppSettings {
@@ -97,6 +115,7 @@ func NewRepositoryHandler(dataPath string, cfg *config.Config, reg *registry.Reg
 	handler := &RepositoryHandler{
 		gitService:      git.NewService(),
 		repositories:    make(map[string]*models.Repository),
+		cloning:         make(map[string]bool),
 		dataPath:        dataPath,
 		watcher:         watcher,
 		config:          cfg,
@@ -108,8 +127,13 @@ func NewRepositoryHandler(dataPath string, cfg *config.Config, reg *registry.Reg
 		newPressureTicker: func(interval time.Duration) pressureTicker {
 			return realPressureTicker{Ticker: time.NewTicker(interval)}
 		},
-		logf: log.Printf,
+		logf:           log.Printf,
+		fetchSchedules: make(map[string]*autoFetchSchedule),
+		fetchStatuses:  make(map[string]*models.FetchStatus),
+		events:         newEventHub(),
+		monitors:       make(map[string]*repoMonitor),
 	}
+	handler.jobs = jobs.NewManager(handler.admitJob, handler.publishJob)
 
 	// Load repositories at initialization so they're available for all handlers
 	if err := handler.loadRepositories(); err != nil {
@@ -119,6 +143,29 @@ func NewRepositoryHandler(dataPath string, cfg *config.Config, reg *registry.Reg
 	return handler
 }
 
+// UseCredentials authenticates remote operations with credentials from store.
+// A nil store leaves them unauthenticated.
+func (h *RepositoryHandler) UseCredentials(store *credentials.Store) {
+	h.credentials = store
+	if store != nil {
+		h.gitService.SetCredentialProvider(store)
+	}
+}
+
+// checkCredential reports an error when id names a credential that does not
+// exist. An empty id is always valid.
+func (h *RepositoryHandler) checkCredential(id string) error {
+	if id == "" {
+		return nil
+	}
+	if h.credentials != nil {
+		if _, ok := h.credentials.Get(id); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", credentials.ErrNotFound, id)
+}
+
 func retryAfterSeconds(cfg *config.Config) int {
 	if cfg != nil && cfg.ResourceGovernor != nil && cfg.ResourceGovernor.RetryAfterSeconds > 0 {
 		return cfg.ResourceGovernor.RetryAfterSeconds
@@ -284,6 +331,7 @@ func (h *RepositoryHandler) loadRepositories() error {
 			URL:         entry.URL,
 			Description: entry.Description,
 			IsLocal:     entry.URL == "",
+			Bare:        h.gitService.IsBare(entry.Path),
 			CreatedAt:   entry.ImportedAt,
 			UpdatedAt:   time.Now(),
 		}
@@ -295,8 +343,23 @@ func (h *RepositoryHandler) loadRepositories() error {
 
 func (h *RepositoryHandler) isGitRepository(path string) bool {
 	gitPath := filepath.Join(path, ".git")
-	_, err := os.Stat(gitPath)
-	return err == nil
+	if _, err := os.Stat(gitPath); err == nil {
+		return true
+	}
+	// A bare or mirror clone has no .git: the directory itself is the git directory.
+	return h.gitService.IsBare(path)
+}
+
+// RequireWorktree refuses requests for a bare or mirror repository with 409,
+// for routes that read or change the worktree or the index.
+func (h *RepositoryHandler) RequireWorktree(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if repo, exists := h.repositoryByID(chi.URLParam(r, "id")); exists && repo.Bare {
+			http.Error(w, "Repository has no worktree", http.StatusConflict)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // @Summary      List all repositories
@@ -326,13 +389,16 @@ func (h *RepositoryHandler) ListRepositories(w http.ResponseWriter, r *http.Requ
 }
 
 // @Summary      Create a new repository
-// @Description  Create a new Git repository or clone from URL
+// @Description  Create a new Git repository or clone from URL, optionally shallow, single-branch, at a given branch or tag, with submodules, or as a bare or mirror clone, which has no worktree: the endpoints that read or change the worktree or the index answer 409 for it. A clone runs as a background job reporting git's progress: with async=true the job is returned at once, otherwise the request waits for it. A failed clone leaves neither a directory nor a registry entry behind.
 // @Tags         repositories
 // @Accept       json
 // @Produce      json
+// @Param        async query    bool                            false  "Return the clone job immediately instead of waiting"
 // @Param        body  body     models.CreateRepositoryRequest  true  "Request body"
 // @Success      201   {object} models.Repository
+// @Success      202   {object} models.Job
 // @Failure      400   {string} string "Bad request"
+// @Failure      409   {string} string "Repository already exists"
 // @Security     BearerAuth
 // @Router       /api/repos [post]
 func (h *RepositoryHandler) CreateRepository(w http.ResponseWriter, r *http.Request) {
@@ -347,30 +413,88 @@ func (h *RepositoryHandler) CreateRepository(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if req.URL == "" && req.CloneOptions != (models.CloneOptions{}) {
+		http.Error(w, "Clone options require a url", http.StatusBadRequest)
+		return
+	}
+	if err := git.ValidateCloneOptions(req.CloneOptions); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.checkCredential(req.Credential); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	repoID := req.Name
 	repoPath := filepath.Join(h.dataPath, req.Name)
 
-	if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
+	h.mu.Lock()
+	_, exists := h.repositories[repoID]
+	if _, err := os.Stat(repoPath); exists || h.cloning[repoID] || !os.IsNotExist(err) {
+		h.mu.Unlock()
 		http.Error(w, "Repository already exists", http.StatusConflict)
 		return
 	}
-
-	var err error
+	if req.URL != "" {
+		// Hold the name until the clone job has finished, so that a second
+		// request cannot clone into the same directory meanwhile.
+		h.cloning[repoID] = true
+	}
+	h.mu.Unlock()
 
 	if req.URL != "" {
-		_, err = h.gitService.CloneRepository(req.URL, repoPath)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to clone repository: %v", err), http.StatusInternalServerError)
-			return
-		}
-	} else {
-		_, err = h.gitService.InitRepository(repoPath)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to initialize repository: %v", err), http.StatusInternalServerError)
-			return
+		clone := func(ctx context.Context, progress io.Writer) (any, error) {
+			if _, err := h.gitService.CloneContext(ctx, req.URL, repoPath, req.CloneOptions, progress); err != nil {
+				return nil, err
+			}
+			repo, err := h.addCreatedRepository(req, repoPath)
+			if err != nil {
+				os.RemoveAll(repoPath)
+				return nil, err
+			}
+			return repo, nil
 		}
+
+		job := h.runJob(w, r, jobClone, repoID, clone, func(w http.ResponseWriter, repo any, err error) {
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Failed to clone repository: %v", err), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(repo)
+		})
+
+		go func() {
+			h.jobs.Wait(context.Background(), job.ID)
+			h.mu.Lock()
+			delete(h.cloning, repoID)
+			h.mu.Unlock()
+		}()
+		return
+	}
+
+	if _, err := h.gitService.InitRepository(repoPath); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to initialize repository: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	repo, err := h.addCreatedRepository(req, repoPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create repository: %v", err), http.StatusInternalServerError)
+		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(repo)
+}
+
+// addCreatedRepository registers a repository that was just initialized or
+// cloned at repoPath.
+func (h *RepositoryHandler) addCreatedRepository(req models.CreateRepositoryRequest, repoPath string) (*models.Repository, error) {
+	repoID := req.Name
 	repo := &models.Repository{
 		ID:          repoID,
 		Name:        req.Name,
@@ -378,6 +502,7 @@ func (h *RepositoryHandler) CreateRepository(w http.ResponseWriter, r *http.Requ
 		URL:         req.URL,
 		Description: req.Description,
 		IsLocal:     req.URL == "",
+		Bare:        h.gitService.IsBare(repoPath),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -404,8 +529,7 @@ func (h *RepositoryHandler) CreateRepository(w http.ResponseWriter, r *http.Requ
 			ImportedAt:  time.Now(),
 		}
 		if err := h.registry.Add(regEntry); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to persist repository: %v", err), http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("failed to persist repository: %w", err)
 		}
 	}
 
@@ -413,9 +537,7 @@ func (h *RepositoryHandler) CreateRepository(w http.ResponseWriter, r *http.Requ
 	h.repositories[repoID] = repo
 	h.mu.Unlock()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(repo)
+	return repo, nil
 }
 
 // @Summary      Get repository by ID
@@ -487,6 +609,7 @@ func (h *RepositoryHandler) DeleteRepository(w http.ResponseWriter, r *http.Requ
 // @Tags         repositories
 // @Produce      json
 // @Param        id    path     string  true  "Repository ID"
+// @Param        wait  query    bool    false "Long-poll up to 30 seconds for a change; superseded by the /api/events stream"
 // @Success      200   {object} models.RepositoryStatus
 // @Failure      404   {string} string "Repository not found"
 // @Security     BearerAuth
@@ -519,18 +642,29 @@ func (h *RepositoryHandler) GetRepositoryStatus(w http.ResponseWriter, r *http.R
 	}
 
 	status.RepositoryID = repoID
+	status.LastFetch = h.lastFetchStatus(repoID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
 }
 
 // @Summary      Get commit history
-// @Description  Get commit history for a repository
+// @Description  Get one page of the commit history of a ref or range, newest first, optionally filtered. The cursor of the next page is returned in the X-Gitty-Next-Cursor header, which is absent on the last page
 // @Tags         repositories
 // @Produce      json
-// @Param        id    path     string  true  "Repository ID"
+// @Param        id       path     string  true   "Repository ID"
+// @Param        ref      query    string  false  "Ref or range such as main..feature or main...feature (default HEAD)"
+// @Param        path     query    string  false  "Only commits touching this path, following renames"
+// @Param        author   query    string  false  "Case-insensitive substring of the author name or email"
+// @Param        since    query    string  false  "Only commits authored on or after this RFC 3339 time or date"
+// @Param        until    query    string  false  "Only commits authored on or before this RFC 3339 time or date"
+// @Param        message  query    string  false  "Case-insensitive substring of the commit message"
+// @Param        regex    query    bool    false  "Match message as a regular expression"
+// @Param        cursor   query    string  false  "Cursor of the page to return, from X-Gitty-Next-Cursor"
+// @Param        limit    query    int     false  "Commits per page (default 50)"
 // @Success      200   {array}  models.Commit
-// @Failure      404   {string} string "Repository not found"
+// @Failure      400   {string} string "Invalid filter, ref or cursor"
+// @Failure      404   {string} string "Repository or revision not found"
 // @Security     BearerAuth
 // @Router       /api/repos/{id}/commits [get]
 func (h *RepositoryHandler) GetCommitHistory(w http.ResponseWriter, r *http.Request) {
@@ -545,13 +679,25 @@ func (h *RepositoryHandler) GetCommitHistory(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
+	query := r.URL.Query()
+	limitStr := query.Get("limit")
 	limit := 50
 	if limitStr != "" {
 		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
 			limit = parsedLimit
 		}
 	}
+	opts := git.LogOptions{
+		Ref:          query.Get("ref"),
+		Path:         query.Get("path"),
+		Author:       query.Get("author"),
+		Since:        query.Get("since"),
+		Until:        query.Get("until"),
+		Message:      query.Get("message"),
+		MessageRegex: query.Get("regex") == "true",
+		Cursor:       query.Get("cursor"),
+		Limit:        limit,
+	}
 
 	release, ok := h.enterExpensiveOrReject(w, r)
 	if !ok {
@@ -559,16 +705,31 @@ func (h *RepositoryHandler) GetCommitHistory(w http.ResponseWriter, r *http.Requ
 	}
 	defer release()
 
-	commits, err := h.gitService.GetCommitHistory(repo.Path, limit)
+	commits, next, err := h.gitService.CommitLog(r.Context(), repo.Path, opts)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get commit history: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to get commit history: %v", err), commitLogErrorStatus(err))
 		return
 	}
 
+	if next != "" {
+		w.Header().Set("X-Gitty-Next-Cursor", next)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(commits)
 }
 
+// commitLogErrorStatus maps commit log errors to HTTP status codes.
+func commitLogErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidLogOptions):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrRevisionNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // @Summary      List branches
 // @Description  List all branches for a repository
 // @Tags         repositories
@@ -600,7 +761,7 @@ func (h *RepositoryHandler) GetBranches(w http.ResponseWriter, r *http.Request)
 }
 
 // @Summary      Create a commit
-// @Description  Create a new commit with staged changes
+// @Description  Create a new commit with staged changes, signed when commit signing is enabled in the repository settings
 // @Tags         repositories
 // @Accept       json
 // @Param        id    path     string              true  "Repository ID"
@@ -632,7 +793,17 @@ func (h *RepositoryHandler) CreateCommit(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := h.gitService.CreateCommit(repo.Path, req)
+	settings, err := h.loadRepoAppSettings(repoID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load repository settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if settings.Commit.SigningEnabled {
+		err = h.gitService.CreateSignedCommit(repo.Path, req, commitSigningConfig(settings.Commit))
+	} else {
+		err = h.gitService.CreateCommit(repo.Path, req)
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create commit: %v", err), http.StatusInternalServerError)
 		return
@@ -793,7 +964,7 @@ func (h *RepositoryHandler) GetFileContent(w http.ResponseWriter, r *http.Reques
 }
 
 // @Summary      Save file content
-// @Description  Write content to a file in a repository
+// @Description  Write content to a file in a repository, normalizing line endings per the repository's LineEndings setting and .gitattributes
 // @Tags         repositories
 // @Accept       plain
 // @Param        id    path     string  true  "Repository ID"
@@ -826,6 +997,13 @@ func (h *RepositoryHandler) SaveFileContent(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	settings, err := h.loadRepoAppSettings(repoID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load repository settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	content = h.gitService.NormalizeFileContent(repo.Path, decodedPath, settings.Commit.LineEndings, content)
+
 	err = h.gitService.SaveFileContent(repo.Path, decodedPath, content)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to save file: %v", err), http.StatusInternalServerError)
@@ -837,11 +1015,16 @@ func (h *RepositoryHandler) SaveFileContent(w http.ResponseWriter, r *http.Reque
 }
 
 // @Summary      Push to remote
-// @Description  Push current branch to remote
+// @Description  Push to a remote (default origin), optionally limited to a refspec. Runs as a background job: with async=true the job is returned at once, otherwise the request waits for it.
 // @Tags         repositories
-// @Param        id    path     string  true  "Repository ID"
+// @Accept       json
+// @Param        id    path     string              true   "Repository ID"
+// @Param        async query    bool                false  "Return the job immediately instead of waiting"
+// @Param        body  body     models.PushRequest  false  "Remote and refspec"
 // @Success      200   {string} string  "Push result"
+// @Success      202   {object} models.Job
 // @Failure      400   {string} string  "Bad request"
+// @Failure      404   {string} string  "Repository or remote not found"
 // @Failure      500   {string} string  "Internal server error"
 // @Security     BearerAuth
 // @Router       /api/repos/{id}/push [post]
@@ -857,22 +1040,41 @@ func (h *RepositoryHandler) Push(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.gitService.Push(repo.Path)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to push: %v", err), http.StatusInternalServerError)
+	var req models.PushRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message": "Push completed successfully"}`))
+	remote, refSpec := strings.TrimSpace(req.Remote), strings.TrimSpace(req.RefSpec)
+	push := func(ctx context.Context, progress io.Writer) (any, error) {
+		if err := h.gitService.PushContext(ctx, repo.Path, remote, refSpec, progress); err != nil {
+			return nil, err
+		}
+		return map[string]string{"message": "Push completed successfully"}, nil
+	}
+
+	h.runJob(w, r, jobPush, repoID, push, func(w http.ResponseWriter, _ any, err error) {
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to push: %v", err), remoteErrorStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Push completed successfully"}`))
+	})
 }
 
 // @Summary      Force push to remote
-// @Description  Force push current branch to remote
+// @Description  Force push to a remote (default origin), optionally limited to a refspec. Runs as a background job: with async=true the job is returned at once, otherwise the request waits for it.
 // @Tags         repositories
-// @Param        id    path     string  true  "Repository ID"
+// @Accept       json
+// @Param        id    path     string              true   "Repository ID"
+// @Param        async query    bool                false  "Return the job immediately instead of waiting"
+// @Param        body  body     models.PushRequest  false  "Remote and refspec"
 // @Success      200   {string} string  "Success"
+// @Success      202   {object} models.Job
 // @Failure      400   {string} string  "Bad request"
+// @Failure      404   {string} string  "Repository or remote not found"
 // @Failure      500   {string} string  "Internal server error"
 // @Security     BearerAuth
 // @Router       /api/repos/{id}/push/force [post]
@@ -888,14 +1090,28 @@ func (h *RepositoryHandler) ForcePush(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.gitService.ForcePush(repo.Path)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to force push: %v", err), http.StatusInternalServerError)
+	var req models.PushRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message": "Force push completed successfully"}`))
+	remote, refSpec := strings.TrimSpace(req.Remote), strings.TrimSpace(req.RefSpec)
+	forcePush := func(ctx context.Context, progress io.Writer) (any, error) {
+		if err := h.gitService.ForcePushContext(ctx, repo.Path, remote, refSpec, progress); err != nil {
+			return nil, err
+		}
+		return map[string]string{"message": "Force push completed successfully"}, nil
+	}
+
+	h.runJob(w, r, jobForcePush, repoID, forcePush, func(w http.ResponseWriter, _ any, err error) {
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to force push: %v", err), remoteErrorStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Force push completed successfully"}`))
+	})
 }
 
 // @Summary      Import existing repository
@@ -948,6 +1164,7 @@ func (h *RepositoryHandler) ImportRepository(w http.ResponseWriter, r *http.Requ
 		Name:      repoName,
 		Path:      req.Path,
 		IsLocal:   true,
+		Bare:      h.gitService.IsBare(req.Path),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -985,11 +1202,18 @@ func (h *RepositoryHandler) ImportRepository(w http.ResponseWriter, r *http.Requ
 }
 
 // @Summary      Pull from remote
-// @Description  Pull changes from remote
+// @Description  Fetch the current branch's upstream and integrate it using the repository's pull strategy (merge, rebase or fast-forward), unless the request overrides it. Runs as a background job: with async=true the job is returned at once, otherwise the request waits for it.
 // @Tags         repositories
-// @Param        id    path     string  true  "Repository ID"
-// @Success      200   {string} string  "Pull result"
-// @Failure      404   {string} string  "Repository not found"
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string              true   "Repository ID"
+// @Param        async query    bool                false  "Return the job immediately instead of waiting"
+// @Param        body  body     models.PullRequest  false  "Remote, refspec and strategy"
+// @Success      200   {object} models.PullResult
+// @Success      202   {object} models.Job
+// @Failure      400   {string} string  "Bad request"
+// @Failure      404   {string} string  "Repository or remote not found"
+// @Failure      409   {object} models.PullResult "Pull stopped on conflicts, or the branch has diverged under fast-forward"
 // @Security     BearerAuth
 // @Router       /api/repos/{id}/pull [post]
 func (h *RepositoryHandler) Pull(w http.ResponseWriter, r *http.Request) {
@@ -1004,18 +1228,46 @@ func (h *RepositoryHandler) Pull(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.gitService.Pull(repo.Path)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to pull: %v", err), http.StatusInternalServerError)
+	var req models.PullRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message": "Pull completed successfully"}`))
+	strategy := strings.TrimSpace(req.Strategy)
+	if strategy == "" {
+		settings, err := h.loadRepoAppSettings(repoID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to load repository settings: %v", err), http.StatusInternalServerError)
+			return
+		}
+		strategy = settings.Sync.PullStrategy
+	}
+	if !git.IsValidPullStrategy(strategy) {
+		http.Error(w, "strategy must be one of merge, rebase, or fast-forward", http.StatusBadRequest)
+		return
+	}
+
+	remote, refSpec := strings.TrimSpace(req.Remote), strings.TrimSpace(req.RefSpec)
+	pull := func(ctx context.Context, progress io.Writer) (any, error) {
+		return h.gitService.PullContext(ctx, repo.Path, remote, refSpec, strategy, progress)
+	}
+
+	h.runJob(w, r, jobPull, repoID, pull, func(w http.ResponseWriter, result any, err error) {
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to pull: %v", err), remoteErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if result.(*models.PullResult).Status == git.MergeStatusConflicted {
+			w.WriteHeader(http.StatusConflict)
+		}
+		json.NewEncoder(w).Encode(result)
+	})
 }
 
 // @Summary      Stage a file
-// @Description  Stage a file for commit
+// @Description  Stage a file for commit. Text files are stored with LF line endings in the index; the working tree file is left as it is
 // @Tags         repositories
 // @Param        id    path     string  true  "Repository ID"
 // @Param        "*"   path     string  true  "File path pattern"
@@ -1051,7 +1303,7 @@ func (h *RepositoryHandler) StageFile(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary      Stage all files
-// @Description  Stage all modified files
+// @Description  Stage all modified files. Text files are stored with LF line endings in the index; working tree files are left as they are
 // @Tags         repositories
 // @Param        id    path     string  true  "Repository ID"
 // @Success      200   {string} string  "Success"
@@ -1117,12 +1369,19 @@ func (h *RepositoryHandler) UnstageFile(w http.ResponseWriter, r *http.Request)
 }
 
 // @Summary      Get commit details
-// @Description  Get detailed information about a specific commit
+// @Description  Get detailed information about a specific commit. With diff options, each change's patch is git's unified diff
 // @Tags         repositories
 // @Produce      json
 // @Param        id     path     string  true  "Repository ID"
 // @Param        hash   path     string  true  "Commit hash"
+// @Param        ignore_all_space     query  bool    false  "Ignore all whitespace"
+// @Param        ignore_space_change  query  bool    false  "Ignore changes in the amount of whitespace"
+// @Param        ignore_blank_lines   query  bool    false  "Ignore changes whose lines are all blank"
+// @Param        context              query  int     false  "Lines of context around changes (default 3)"
+// @Param        algorithm            query  string  false  "Diff algorithm: myers (default), patience or histogram"
+// @Param        rename_threshold     query  int     false  "Similarity percent for renames and copies (default 50)"
 // @Success      200    {object} models.CommitDetail
+// @Failure      400    {string} string "Invalid diff options"
 // @Failure      404    {string} string "Repository or commit not found"
 // @Security     BearerAuth
 // @Router       /api/repos/{id}/commits/{hash} [get]
@@ -1144,9 +1403,15 @@ func (h *RepositoryHandler) GetCommitDetails(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	commitDetail, err := h.gitService.GetCommitDetails(repo.Path, commitHash)
+	diffOpts, err := parseDiffOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	commitDetail, err := h.gitService.GetCommitDetails(repo.Path, commitHash, diffOpts)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get commit details: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to get commit details: %v", err), diffErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -1195,12 +1460,19 @@ func (h *RepositoryHandler) DeleteBranch(w http.ResponseWriter, r *http.Request)
 }
 
 // @Summary      Get file diff
-// @Description  Get diff for a file
+// @Description  Get diff for a file. The X-Gitty-EOL-Only header is set when only line endings changed
 // @Tags         repositories
 // @Produce      json
 // @Param        id    path     string  true  "Repository ID"
 // @Param        "*"   path     string  true  "File path"
+// @Param        ignore_all_space     query  bool    false  "Ignore all whitespace"
+// @Param        ignore_space_change  query  bool    false  "Ignore changes in the amount of whitespace"
+// @Param        ignore_blank_lines   query  bool    false  "Ignore changes whose lines are all blank"
+// @Param        context              query  int     false  "Lines of context around changes (default 3)"
+// @Param        algorithm            query  string  false  "Diff algorithm: myers (default), patience or histogram"
+// @Param        rename_threshold     query  int     false  "Similarity percent for renames and copies (default 50)"
 // @Success      200   {object} models.DiffResult
+// @Header       200   {string} X-Gitty-EOL-Only "true when the only change is line endings"
 // @Failure      400   {string} string "Bad request"
 // @Failure      404   {string} string "Repository not found"
 // @Security     BearerAuth
@@ -1227,19 +1499,28 @@ func (h *RepositoryHandler) GetFileDiff(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	diffOpts, err := parseDiffOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	release, ok := h.enterExpensiveOrReject(w, r)
 	if !ok {
 		return
 	}
 	defer release()
 
-	diff, err := h.gitService.GetFileDiff(repo.Path, decodedPath)
+	diff, err := h.gitService.GetFileDiff(repo.Path, decodedPath, diffOpts)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get file diff: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to get file diff: %v", err), diffErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
 	w.Header().Set("Content-Type", "text/plain")
+	if git.IsEOLOnlyDiff(diff) {
+		w.Header().Set(eolOnlyHeader, "true")
+	}
 	w.Write([]byte(diff))
 }
 
@@ -1286,15 +1567,21 @@ func (h *RepositoryHandler) GenerateCommitMessage(w http.ResponseWriter, r *http
 		customPrompt = *h.config.ClaudePrompt
 	}
 
-	message, err := h.claudeService.GenerateCommitMessage(nil, customPrompt)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to generate commit message: %v", err), http.StatusInternalServerError)
-		return
+	generate := func(ctx context.Context, _ io.Writer) (any, error) {
+		message, err := h.claudeService.GenerateCommitMessage(ctx, nil, customPrompt)
+		if err != nil {
+			return nil, err
+		}
+		return models.GenerateCommitMessageResponse{Message: message}, nil
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(models.GenerateCommitMessageResponse{
-		Message: message,
+	h.runJob(w, r, jobGenerateCommitMessage, repoID, generate, func(w http.ResponseWriter, result any, err error) {
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to generate commit message: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
 	})
 }
 
@@ -1440,9 +1727,23 @@ func validateCommitSettings(commit models.RepoCommitSettings) error {
 	default:
 		return fmt.Errorf("commit.lineEndings must be one of lf, crlf, or auto")
 	}
+	if !git.IsValidSigningFormat(commit.SigningFormat) {
+		return fmt.Errorf("commit.signingFormat must be openpgp or ssh")
+	}
 	return nil
 }
 
+// eolOnlyHeader marks a plain-text diff whose only change is line endings.
+const eolOnlyHeader = "X-Gitty-EOL-Only"
+
+// commitSigningConfig is the signing key configured in a repository's commit settings.
+func commitSigningConfig(commit models.RepoCommitSettings) git.SigningConfig {
+	return git.SigningConfig{
+		Format: commit.SigningFormat,
+		Key:    strings.TrimSpace(commit.SigningKey),
+	}
+}
+
 func isBasicEmail(email string) bool {
 	at := strings.Index(email, "@")
 	if at <= 0 || at == len(email)-1 {
@@ -1630,7 +1931,7 @@ func (h *RepositoryHandler) GetGitConfig(w http.ResponseWriter, r *http.Request)
 }
 
 // HandleTokenizedFileDiff returns a tokenized (syntax-highlighted) diff for a single file
-// GET /api/repos/{id}/diff/tokenized/*?staged=<bool>&cursor=<int>&limit=<int>
+// GET /api/repos/{id}/diff/tokenized/*?staged=<bool>&cursor=<int>&limit=<int>, with the diff options of parseDiffOptions
 func (h *RepositoryHandler) HandleTokenizedFileDiff(w http.ResponseWriter, r *http.Request) {
 	repoID := chi.URLParam(r, "id")
 
@@ -1661,15 +1962,21 @@ func (h *RepositoryHandler) HandleTokenizedFileDiff(w http.ResponseWriter, r *ht
 	cursor := parseQueryInt(r, "cursor", 0)
 	limit := parseQueryInt(r, "limit", 50)
 
+	diffOpts, err := parseDiffOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	release, ok := h.enterExpensiveOrReject(w, r)
 	if !ok {
 		return
 	}
 	defer release()
 
-	tokenizedDiff, err := h.gitService.TokenizeDiffFromPatch(repo.Path, decodedPath, staged, cursor, limit)
+	tokenizedDiff, err := h.gitService.TokenizeDiffFromPatch(repo.Path, decodedPath, staged, cursor, limit, diffOpts)
 	if err != nil {
-		http.Error(w, "Failed to get tokenized diff: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to get tokenized diff: "+err.Error(), diffErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -1678,7 +1985,7 @@ func (h *RepositoryHandler) HandleTokenizedFileDiff(w http.ResponseWriter, r *ht
 }
 
 // HandleTokenizedCommitDiff returns tokenized diffs for all files in a commit
-// GET /api/repos/{id}/diff/commit/tokenized?hash=<commit>
+// GET /api/repos/{id}/diff/commit/tokenized?hash=<commit>, with the diff options of parseDiffOptions
 func (h *RepositoryHandler) HandleTokenizedCommitDiff(w http.ResponseWriter, r *http.Request) {
 	repoID := chi.URLParam(r, "id")
 
@@ -1697,15 +2004,21 @@ func (h *RepositoryHandler) HandleTokenizedCommitDiff(w http.ResponseWriter, r *
 		return
 	}
 
+	diffOpts, err := parseDiffOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	release, ok := h.enterExpensiveOrReject(w, r)
 	if !ok {
 		return
 	}
 	defer release()
 
-	tokenizedDiff, err := h.gitService.TokenizeCommitDiff(repo.Path, hash)
+	tokenizedDiff, err := h.gitService.TokenizeCommitDiff(repo.Path, hash, diffOpts)
 	if err != nil {
-		status := http.StatusInternalServerError
+		status := diffErrorStatus(err, http.StatusInternalServerError)
 		msg := "Failed to get tokenized commit diff: " + err.Error()
 		if strings.Contains(err.Error(), "commit not found") || strings.Contains(err.Error(), "failed to get commit") {
 			status = http.StatusNotFound
@@ -1720,7 +2033,7 @@ func (h *RepositoryHandler) HandleTokenizedCommitDiff(w http.ResponseWriter, r *
 }
 
 // HandleCommitFileDiff returns a tokenized diff for a specific file at a specific commit
-// GET /api/repos/{id}/diff/commit/{hash}/files/{path}?cursor=<int>&limit=<int>
+// GET /api/repos/{id}/diff/commit/{hash}/files/{path}?cursor=<int>&limit=<int>, with the diff options of parseDiffOptions
 func (h *RepositoryHandler) HandleCommitFileDiff(w http.ResponseWriter, r *http.Request) {
 	repoID := chi.URLParam(r, "id")
 	commitHash := chi.URLParam(r, "hash")
@@ -1755,16 +2068,22 @@ func (h *RepositoryHandler) HandleCommitFileDiff(w http.ResponseWriter, r *http.
 	cursor := parseQueryInt(r, "cursor", 0)
 	limit := parseQueryInt(r, "limit", 50)
 
+	diffOpts, err := parseDiffOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	release, ok := h.enterExpensiveOrReject(w, r)
 	if !ok {
 		return
 	}
 	defer release()
 
-	tokenizedDiff, err := h.gitService.GetCommitFileDiff(repo.Path, commitHash, decodedPath, cursor, limit)
+	tokenizedDiff, err := h.gitService.GetCommitFileDiff(repo.Path, commitHash, decodedPath, cursor, limit, diffOpts)
 	if err != nil {
 		// Determine appropriate status code
-		status := http.StatusInternalServerError
+		status := diffErrorStatus(err, http.StatusInternalServerError)
 		msg := "Failed to get commit file diff: " + err.Error()
 
 		if strings.Contains(err.Error(), "commit not found") {
@@ -1795,3 +2114,38 @@ func parseQueryInt(r *http.Request, key string, defaultValue int) int {
 	}
 	return val
 }
+
+// parseDiffOptions reads the diff options shared by the diff endpoints from
+// the query string.
+func parseDiffOptions(r *http.Request) (git.DiffOptions, error) {
+	query := r.URL.Query()
+	opts := git.DiffOptions{
+		IgnoreAllSpace:    query.Get("ignore_all_space") == "true",
+		IgnoreSpaceChange: query.Get("ignore_space_change") == "true",
+		IgnoreBlankLines:  query.Get("ignore_blank_lines") == "true",
+		Algorithm:         query.Get("algorithm"),
+	}
+	if str := query.Get("context"); str != "" {
+		lines, err := strconv.Atoi(str)
+		if err != nil {
+			return opts, fmt.Errorf("%w: context must be a number", git.ErrInvalidDiffOptions)
+		}
+		opts.Context = &lines
+	}
+	if str := query.Get("rename_threshold"); str != "" {
+		threshold, err := strconv.Atoi(str)
+		if err != nil {
+			return opts, fmt.Errorf("%w: rename_threshold must be a number", git.ErrInvalidDiffOptions)
+		}
+		opts.RenameThreshold = threshold
+	}
+	return opts, nil
+}
+
+// diffErrorStatus returns 400 for invalid diff options and status otherwise.
+func diffErrorStatus(err error, status int) int {
+	if errors.Is(err, git.ErrInvalidDiffOptions) {
+		return http.StatusBadRequest
+	}
+	return status
+}
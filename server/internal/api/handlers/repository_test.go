@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -396,6 +397,13 @@ func TestIsGitRepository(t *testing.T) {
 	if !handler.isGitRepository(tempDir) {
 		t.Error("Directory with .git should be a git repository")
 	}
+
+	// A bare repository has no .git
+	bareDir := t.TempDir()
+	runGitInRepo(t, bareDir, "init", "--bare")
+	if !handler.isGitRepository(bareDir) {
+		t.Error("Bare repository should be a git repository")
+	}
 }
 
 func TestListRepositories(t *testing.T) {
@@ -516,6 +524,42 @@ func TestCreateRepository(t *testing.T) {
 	}
 }
 
+func TestCreateRepository_MirrorCloneHasNoWorktree(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	source, err := createTestRepository(handler, "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(models.CreateRepositoryRequest{Name: "mirror", URL: source, CloneOptions: models.CloneOptions{Mirror: true}})
+	rec := httptest.NewRecorder()
+	handler.CreateRepository(rec, httptest.NewRequest(http.MethodPost, "/api/repos", bytes.NewBuffer(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var repo models.Repository
+	if err := json.NewDecoder(rec.Body).Decode(&repo); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.Bare {
+		t.Fatalf("expected the mirror clone to be marked bare, got %+v", repo)
+	}
+
+	reached := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true })
+	rec = httptest.NewRecorder()
+	handler.RequireWorktree(next).ServeHTTP(rec, newRouteRequest(http.MethodGet, "/api/repos/mirror/status", nil, "id", "mirror"))
+	if rec.Code != http.StatusConflict || reached {
+		t.Fatalf("expected 409 for a worktree route on a bare repository, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.RequireWorktree(next).ServeHTTP(rec, newRouteRequest(http.MethodGet, "/api/repos/source/status", nil, "id", "source"))
+	if !reached {
+		t.Fatalf("expected a repository with a worktree to pass, got %d", rec.Code)
+	}
+}
+
 func TestCreateRepositoryInvalidRequest(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "handler_test")
 	if err != nil {
@@ -835,6 +879,69 @@ func TestGetCommitHistory(t *testing.T) {
 	}
 }
 
+func TestGetCommitHistory_PagesAndFilters(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+	runGitInRepo(t, repoDir, "commit", "--allow-empty", "-m", "Fix bug")
+	runGitInRepo(t, repoDir, "commit", "--allow-empty", "-m", "Add feature")
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/repositories/test-repo/commits"+query, nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("id", "test-repo")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+		w := httptest.NewRecorder()
+		handler.GetCommitHistory(w, req)
+		return w
+	}
+
+	w := get("?limit=1")
+	var commits []models.Commit
+	if err := json.Unmarshal(w.Body.Bytes(), &commits); err != nil {
+		t.Fatal(err)
+	}
+	next := w.Header().Get("X-Gitty-Next-Cursor")
+	if w.Code != http.StatusOK || len(commits) != 1 || commits[0].Message != "Add feature" || next == "" {
+		t.Fatalf("unexpected first page: %d %+v, cursor %q", w.Code, commits, next)
+	}
+
+	w = get("?limit=1&message=fix&cursor=" + next)
+	if err := json.Unmarshal(w.Body.Bytes(), &commits); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(commits) != 1 || commits[0].Message != "Fix bug" || w.Header().Get("X-Gitty-Next-Cursor") != "" {
+		t.Fatalf("expected the page to resume after the commits already listed, got %d %+v", w.Code, commits)
+	}
+
+	w = get("?message=fix")
+	if err := json.Unmarshal(w.Body.Bytes(), &commits); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(commits) != 1 || commits[0].Message != "Fix bug" {
+		t.Fatalf("unexpected filtered commits: %d %+v", w.Code, commits)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"?since=yesterday", http.StatusBadRequest},
+		{"?message=(&regex=true", http.StatusBadRequest},
+		{"?cursor=bogus", http.StatusBadRequest},
+		{"?ref=missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := get(tt.query); w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.query, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
+
 func TestGetCommitHistory_Returns503WhenDegraded(t *testing.T) {
 	tempDir := t.TempDir()
 	handler := NewRepositoryHandler(tempDir, nil, nil)
@@ -1016,6 +1123,111 @@ func TestCreateCommit(t *testing.T) {
 	}
 }
 
+func TestCreateCommit_SignsWhenSigningEnabled(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %v: %s", err, output)
+	}
+
+	settings := defaultRepoAppSettings()
+	settings.Commit.SigningEnabled = true
+	settings.Commit.SigningFormat = "ssh"
+	settings.Commit.SigningKey = key
+	if err := handler.saveRepoAppSettings("test-repo", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "signed.txt"), []byte("signed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"message":"Signed commit","files":["signed.txt"],"author":{"name":"Test User","email":"test@example.com"}}`)
+	rec := httptest.NewRecorder()
+	handler.CreateCommit(rec, newRepoSettingsRequest(http.MethodPost, "/api/repos/test-repo/commit", "test-repo", body))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	detail, err := handler.gitService.GetCommitDetails(repoDir, revParse(t, repoDir, "HEAD"), git.DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Message != "Signed commit" || detail.Signature.Format != "ssh" || detail.Signature.Status == "unsigned" {
+		t.Fatalf("expected an SSH-signed commit, got %+v", detail)
+	}
+}
+
+func TestSaveAndStage_NormalizeLineEndings(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.SaveFileContent(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", []byte("one\r\ntwo\n"), "id", "test-repo", "*", "notes.txt"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	content, err := os.ReadFile(filepath.Join(repoDir, "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "one\ntwo\n" {
+		t.Fatalf("expected LF line endings after save, got %q", content)
+	}
+	runGitInRepo(t, repoDir, "add", "notes.txt")
+	runGitInRepo(t, repoDir, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "-m", "Add notes")
+
+	settings := defaultRepoAppSettings()
+	settings.Commit.LineEndings = "crlf"
+	if err := handler.saveRepoAppSettings("test-repo", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.SaveFileContent(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", []byte("one\ntwo\r\n"), "id", "test-repo", "*", "notes.txt"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetFileDiff(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", nil, "id", "test-repo", "*", "notes.txt"))
+	if rec.Code != http.StatusOK || rec.Header().Get(eolOnlyHeader) != "true" {
+		t.Fatalf("expected diff flagged as EOL-only, got %d %q: %s", rec.Code, rec.Header().Get(eolOnlyHeader), rec.Body.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "other.txt"), []byte("one\ntwo\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	handler.StageFile(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", nil, "id", "test-repo", "*", "other.txt"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	cmd := exec.Command("git", "show", ":other.txt")
+	cmd.Dir = repoDir
+	staged, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(staged) != "one\ntwo\n" {
+		t.Fatalf("expected LF content in the index, got %q", staged)
+	}
+	if content, err := os.ReadFile(filepath.Join(repoDir, "other.txt")); err != nil || string(content) != "one\ntwo\r\n" {
+		t.Fatalf("expected staging to leave the working tree file alone, got %q, %v", content, err)
+	}
+}
+
 func TestCreateBranch(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "handler_test")
 	if err != nil {
@@ -1714,6 +1926,11 @@ func TestRepositorySettingsHandlers_BadPayload(t *testing.T) {
 			method: handler.UpdateRepositorySettingsCommit,
 			req:    newRepoSettingsRequest(http.MethodPut, "/api/repos/"+repoName+"/settings/commit", repoName, []byte(`{"defaultBranch":"","signingEnabled":false,"lineEndings":"tabs"}`)),
 		},
+		{
+			name:   "commit signing format",
+			method: handler.UpdateRepositorySettingsCommit,
+			req:    newRepoSettingsRequest(http.MethodPut, "/api/repos/"+repoName+"/settings/commit", repoName, []byte(`{"defaultBranch":"main","signingEnabled":true,"signingFormat":"x509","lineEndings":"lf"}`)),
+		},
 		{
 			name:   "identity unknown field",
 			method: handler.UpdateRepositorySettingsIdentity,
@@ -1898,3 +2115,140 @@ func TestHandleCommitFileDiff_Returns503WhenGovernorRejects(t *testing.T) {
 
 	assertExpensiveRequestRejected(t, w, resources.ReasonDegradedMode)
 }
+
+func TestDiffEndpoints_ApplyDiffOptions(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := revParse(t, repoDir, "HEAD")
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("#  Test   Repository"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newDiffRequest := func(target, hash, path string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("id", "test-repo")
+		if hash != "" {
+			chiCtx.URLParams.Add("hash", hash)
+		}
+		if path != "" {
+			chiCtx.URLParams.Add("*", path)
+		}
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+	}
+
+	rec := httptest.NewRecorder()
+	handler.HandleTokenizedFileDiff(rec, newDiffRequest("/api/repos/test-repo/diff/tokenized/README.md?ignore_all_space=true", "", "README.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var diff models.TokenizedDiff
+	if err := json.NewDecoder(rec.Body).Decode(&diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Hunks) != 0 {
+		t.Errorf("expected the whitespace change to be ignored, got %+v", diff.Hunks)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     *http.Request
+	}{
+		{"file diff", handler.GetFileDiff, newDiffRequest("/api/repos/test-repo/diff/README.md?context=abc", "", "README.md")},
+		{"tokenized file diff", handler.HandleTokenizedFileDiff, newDiffRequest("/api/repos/test-repo/diff/tokenized/README.md?algorithm=slow", "", "README.md")},
+		{"commit details", handler.GetCommitDetails, newDiffRequest("/api/repos/test-repo/commits/"+head+"?context=-1", head, "")},
+		{"tokenized commit diff", handler.HandleTokenizedCommitDiff, newDiffRequest("/api/repos/test-repo/diff/commit/tokenized?hash="+head+"&algorithm=slow", "", "")},
+		{"commit file diff", handler.HandleCommitFileDiff, newDiffRequest("/api/repos/test-repo/diff/commit/"+head+"/files/README.md?rename_threshold=200", head, "README.md")},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler(rec, tt.req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400 for invalid diff options, got %d: %s", tt.name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCreateRepository_CloneWithOptions(t *testing.T) {
+	dataPath := t.TempDir()
+	reg, err := registry.New(filepath.Join(t.TempDir(), "registry.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewRepositoryHandler(dataPath, nil, reg)
+
+	source, err := createTestRepository(handler, "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, source, "branch", "feature")
+
+	body := `{"name":"clone","url":"file://` + filepath.ToSlash(source) + `","depth":1,"single_branch":true,"ref":"feature"}`
+	rec := httptest.NewRecorder()
+	handler.CreateRepository(rec, httptest.NewRequest(http.MethodPost, "/api/repos", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var repo models.Repository
+	if err := json.NewDecoder(rec.Body).Decode(&repo); err != nil {
+		t.Fatal(err)
+	}
+	if repo.CurrentBranch != "feature" || repo.IsLocal {
+		t.Errorf("unexpected cloned repository: %+v", repo)
+	}
+	if _, ok := reg.Get("clone"); !ok {
+		t.Error("expected the clone to be registered")
+	}
+	if _, err := os.Stat(filepath.Join(repo.Path, ".git", "shallow")); err != nil {
+		t.Errorf("expected a shallow clone: %v", err)
+	}
+}
+
+func TestCreateRepository_FailedCloneLeavesNothingBehind(t *testing.T) {
+	dataPath := t.TempDir()
+	reg, err := registry.New(filepath.Join(t.TempDir(), "registry.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewRepositoryHandler(dataPath, nil, reg)
+
+	rec := httptest.NewRecorder()
+	handler.CreateRepository(rec, httptest.NewRequest(http.MethodPost, "/api/repos", strings.NewReader(`{"name":"clone","depth":1}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for clone options without a url, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := `{"name":"clone","url":"file://` + filepath.ToSlash(t.TempDir()) + `/missing"}`
+	rec = httptest.NewRecorder()
+	handler.CreateRepository(rec, httptest.NewRequest(http.MethodPost, "/api/repos", strings.NewReader(body)))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "Failed to clone repository") {
+		t.Fatalf("expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(dataPath, "clone")); !os.IsNotExist(err) {
+		t.Errorf("expected the partial clone to be removed, got %v", err)
+	}
+	if len(reg.List()) != 0 {
+		t.Errorf("expected no registry entry, got %+v", reg.List())
+	}
+
+	// The name is free again once the job has finished
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		handler.mu.RLock()
+		cloning := handler.cloning["clone"]
+		handler.mu.RUnlock()
+		if !cloning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the repository name to be released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary      Discard file changes
+// @Description  Restore a tracked file from the index (default) or from HEAD; requires confirm
+// @Tags         repositories
+// @Accept       json
+// @Param        id    path     string                 true  "Repository ID"
+// @Param        "*"   path     string                 true  "File path"
+// @Param        body  body     models.DiscardRequest  true  "Request body"
+// @Success      200   {string} string "Discarded"
+// @Failure      400   {string} string "Bad request or missing confirmation"
+// @Failure      404   {string} string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/discard/{filepath} [post]
+func (h *RepositoryHandler) DiscardFile(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+	filePath := chi.URLParam(r, "*")
+	decodedPath, err := url.PathUnescape(filePath)
+	if err != nil {
+		decodedPath = filePath // fallback to original if decoding fails
+	}
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if decodedPath == "" {
+		http.Error(w, "File path is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.DiscardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Source == "" {
+		req.Source = git.DiscardFromIndex
+	}
+	if req.Source != git.DiscardFromIndex && req.Source != git.DiscardFromHead {
+		http.Error(w, "source must be one of index or head", http.StatusBadRequest)
+		return
+	}
+	if !req.Confirm {
+		http.Error(w, "Discarding changes cannot be undone; set confirm to true", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.gitService.DiscardFile(repo.Path, decodedPath, req.Source); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, git.ErrNotTracked) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, fmt.Sprintf("Failed to discard changes: %v", err), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Changes discarded successfully"}`))
+}
+
+// @Summary      Clean untracked files
+// @Description  Delete untracked, non-ignored files; requires confirm unless dry_run is set
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string               true  "Repository ID"
+// @Param        body  body     models.CleanRequest  true  "Request body"
+// @Success      200   {object} models.CleanResult
+// @Failure      400   {string} string "Missing confirmation"
+// @Failure      404   {string} string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/clean [post]
+func (h *RepositoryHandler) CleanUntracked(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.CleanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !req.DryRun && !req.Confirm {
+		http.Error(w, "Deleting untracked files cannot be undone; set confirm to true or use dry_run", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.gitService.CleanUntracked(repo.Path, req.Paths, req.DryRun)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to clean untracked files: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary      Reset the current branch
+// @Description  Move the current branch to a commit with soft, mixed or hard mode; hard requires confirm
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string               true  "Repository ID"
+// @Param        body  body     models.ResetRequest  true  "Request body"
+// @Success      200   {object} models.ResetResult
+// @Failure      400   {string} string "Bad request or missing confirmation"
+// @Failure      404   {string} string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/reset [post]
+func (h *RepositoryHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.ResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = git.ResetMixed
+	}
+	if !git.IsValidResetMode(req.Mode) {
+		http.Error(w, "mode must be one of soft, mixed, or hard", http.StatusBadRequest)
+		return
+	}
+	if req.Mode == git.ResetHard && !req.Confirm {
+		http.Error(w, "A hard reset discards uncommitted changes; set confirm to true", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.gitService.Reset(repo.Path, strings.TrimSpace(req.Target), req.Mode)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, git.ErrResetTargetNotFound) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, fmt.Sprintf("Failed to reset: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDestructiveEndpoints_RequireConfirm(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "scratch.txt"), []byte("scratch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		path    string
+		body    string
+		status  int
+	}{
+		{name: "discard without confirm", handler: handler.DiscardFile, path: "README.md", body: `{}`, status: http.StatusBadRequest},
+		{name: "clean without confirm", handler: handler.CleanUntracked, body: `{}`, status: http.StatusBadRequest},
+		{name: "hard reset without confirm", handler: handler.Reset, body: `{"mode":"hard"}`, status: http.StatusBadRequest},
+		{name: "invalid reset mode", handler: handler.Reset, body: `{"mode":"keep"}`, status: http.StatusBadRequest},
+		{name: "clean dry run", handler: handler.CleanUntracked, body: `{"dry_run":true}`, status: http.StatusOK},
+		{name: "mixed reset", handler: handler.Reset, body: `{"target":"HEAD"}`, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", []byte(tt.body), "id", "test-repo", "*", tt.path))
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(repoDir, "scratch.txt")); err != nil {
+		t.Fatal("expected untracked file to survive rejected and dry-run cleans")
+	}
+
+	rec := httptest.NewRecorder()
+	handler.DiscardFile(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", []byte(`{"source":"head","confirm":true}`), "id", "test-repo", "*", "README.md"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CleanUntracked(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo", []byte(`{"confirm":true}`), "id", "test-repo"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "scratch.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected untracked file to be removed")
+	}
+}
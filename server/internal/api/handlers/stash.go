@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// stashIndexParam parses the {index} URL parameter, writing a 400 response when invalid.
+func stashIndexParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil || index < 0 {
+		http.Error(w, "Invalid stash index", http.StatusBadRequest)
+		return 0, false
+	}
+	return index, true
+}
+
+// stashErrorStatus maps stash errors to HTTP status codes.
+func stashErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrStashNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, git.ErrNothingToStash),
+		errors.Is(err, git.ErrLocalChanges):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// @Summary      List stashes
+// @Description  List stash entries, most recent first
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {array}  models.StashEntry
+// @Failure      404   {string} string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/stashes [get]
+func (h *RepositoryHandler) ListStashes(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	stashes, err := h.gitService.ListStashes(repo.Path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list stashes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stashes)
+}
+
+// @Summary      Stash changes
+// @Description  Save local changes to a new stash entry, optionally including untracked files
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string               true  "Repository ID"
+// @Param        body  body     models.StashRequest  false "Request body"
+// @Success      201   {object} models.StashEntry
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "No local changes to stash"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/stashes [post]
+func (h *RepositoryHandler) PushStash(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.StashRequest
+	// The body is optional; an empty one stashes tracked changes with git's default message.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.gitService.PushStash(repo.Path, req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to stash changes: %v", err), stashErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// @Summary      Show a stash
+// @Description  Get a stash entry with the tokenized diff of every file it changes
+// @Tags         repositories
+// @Produce      json
+// @Param        id     path     string  true  "Repository ID"
+// @Param        index  path     int     true  "Stash index"
+// @Success      200    {object} models.StashDetail
+// @Failure      400    {string} string "Invalid stash index"
+// @Failure      404    {string} string "Repository or stash not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/stashes/{index} [get]
+func (h *RepositoryHandler) GetStash(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	index, ok := stashIndexParam(w, r)
+	if !ok {
+		return
+	}
+
+	release, ok := h.enterExpensiveOrReject(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	detail, err := h.gitService.GetStashDetail(repo.Path, index)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to show stash: %v", err), stashErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// @Summary      Apply a stash
+// @Description  Apply a stash entry to the working tree and keep it in the stash list
+// @Tags         repositories
+// @Produce      json
+// @Param        id     path     string  true  "Repository ID"
+// @Param        index  path     int     true  "Stash index"
+// @Success      200    {object} models.StashApplyResult
+// @Failure      404    {string} string "Repository or stash not found"
+// @Failure      409    {object} models.StashApplyResult "Applying the stash produced conflicts"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/stashes/{index}/apply [post]
+func (h *RepositoryHandler) ApplyStash(w http.ResponseWriter, r *http.Request) {
+	h.applyStash(w, r, false)
+}
+
+// @Summary      Pop a stash
+// @Description  Apply a stash entry and drop it; the entry is kept when applying produces conflicts
+// @Tags         repositories
+// @Produce      json
+// @Param        id     path     string  true  "Repository ID"
+// @Param        index  path     int     true  "Stash index"
+// @Success      200    {object} models.StashApplyResult
+// @Failure      404    {string} string "Repository or stash not found"
+// @Failure      409    {object} models.StashApplyResult "Applying the stash produced conflicts"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/stashes/{index}/pop [post]
+func (h *RepositoryHandler) PopStash(w http.ResponseWriter, r *http.Request) {
+	h.applyStash(w, r, true)
+}
+
+func (h *RepositoryHandler) applyStash(w http.ResponseWriter, r *http.Request, pop bool) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	index, ok := stashIndexParam(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.gitService.ApplyStash(repo.Path, index, pop)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to apply stash: %v", err), stashErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status == git.StashStatusConflicted {
+		w.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary      Drop a stash
+// @Description  Remove a stash entry without applying it
+// @Tags         repositories
+// @Param        id     path     string  true  "Repository ID"
+// @Param        index  path     int     true  "Stash index"
+// @Success      200    {string} string "Dropped"
+// @Failure      404    {string} string "Repository or stash not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/stashes/{index} [delete]
+func (h *RepositoryHandler) DropStash(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	index, ok := stashIndexParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.gitService.DropStash(repo.Path, index); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to drop stash: %v", err), stashErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Stash dropped successfully"}`))
+}
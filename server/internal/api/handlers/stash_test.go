@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestStashes_PushShowAndDrop(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+
+	rec := httptest.NewRecorder()
+	handler.PushStash(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/stashes", nil, "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 with nothing to stash, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.PushStash(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/stashes", []byte(`{"message":"wip"}`), "id", "test-repo"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetStash(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/stashes", nil, "id", "test-repo", "index", "0"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var detail models.StashDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode stash: %v", err)
+	}
+	if detail.Stash.Message != "wip" || len(detail.Files) != 1 || detail.Files[0].Path != "README.md" {
+		t.Fatalf("unexpected stash detail: %+v", detail)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetStash(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/stashes", nil, "id", "test-repo", "index", "abc"))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for invalid index, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DropStash(rec, newRouteRequest(http.MethodDelete, "/api/repos/test-repo/stashes", nil, "id", "test-repo", "index", "0"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.PopStash(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/stashes", nil, "id", "test-repo", "index", "0"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for dropped stash, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// tagErrorStatus maps tag errors to HTTP status codes.
+func tagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidTagName), errors.Is(err, git.ErrTagTargetNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrTagNotFound), errors.Is(err, git.ErrRemoteNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, git.ErrTagExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// @Summary      List tags
+// @Description  List tags with their target commit, tagger and message
+// @Tags         repositories
+// @Produce      json
+// @Param        id    path     string  true  "Repository ID"
+// @Success      200   {array}  models.Tag
+// @Failure      404   {string} string "Repository not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/tags [get]
+func (h *RepositoryHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	tags, err := h.gitService.GetTags(repo.Path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tags: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// @Summary      Create a tag
+// @Description  Create a lightweight tag, or an annotated tag when a message is given, at any commit. Annotated tags are signed when commit signing is enabled
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string                   true  "Repository ID"
+// @Param        body  body     models.CreateTagRequest  true  "Request body"
+// @Success      201   {object} models.Tag
+// @Failure      400   {string} string "Bad request"
+// @Failure      404   {string} string "Repository not found"
+// @Failure      409   {string} string "Tag already exists"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/tags [post]
+func (h *RepositoryHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.CreateTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Tag name is required", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.loadRepoAppSettings(repoID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load repository settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var tag *models.Tag
+	if settings.Commit.SigningEnabled {
+		tag, err = h.gitService.CreateSignedTag(repo.Path, req, commitSigningConfig(settings.Commit))
+	} else {
+		tag, err = h.gitService.CreateTag(repo.Path, req)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create tag: %v", err), tagErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+// @Summary      Delete a tag
+// @Description  Delete a local tag
+// @Tags         repositories
+// @Param        id    path     string  true  "Repository ID"
+// @Param        "*"   path     string  true  "Tag name"
+// @Success      200   {string} string "Deleted"
+// @Failure      404   {string} string "Repository or tag not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/tags/{name} [delete]
+func (h *RepositoryHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+	tagName := chi.URLParam(r, "*")
+	decodedName, err := url.PathUnescape(tagName)
+	if err != nil {
+		decodedName = tagName // fallback to original if decoding fails
+	}
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	if decodedName == "" {
+		http.Error(w, "Tag name is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.gitService.DeleteTag(repo.Path, decodedName); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete tag: %v", err), tagErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Tag deleted successfully"}`))
+}
+
+// @Summary      Push tags
+// @Description  Push one tag, or all tags when no tag is given, to a remote (default origin)
+// @Tags         repositories
+// @Accept       json
+// @Param        id    path     string                  true  "Repository ID"
+// @Param        body  body     models.PushTagsRequest  false "Request body"
+// @Success      200   {string} string "Pushed"
+// @Failure      404   {string} string "Repository, remote or tag not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/tags/push [post]
+func (h *RepositoryHandler) PushTags(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	var req models.PushTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.gitService.PushTags(repo.Path, strings.TrimSpace(req.Remote), strings.TrimSpace(req.Tag)); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to push tags: %v", err), tagErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Tags pushed successfully"}`))
+}
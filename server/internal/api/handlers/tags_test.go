@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestTags_CreateListDelete(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+
+	rec := httptest.NewRecorder()
+	handler.CreateTag(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/tags", []byte(`{"name":"release/v1","message":"First release"}`), "id", "test-repo"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CreateTag(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/tags", []byte(`{"name":"release/v1"}`), "id", "test-repo"))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for duplicate tag, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CreateTag(rec, newRouteRequest(http.MethodPost, "/api/repos/test-repo/tags", []byte(`{"name":"v2","target":"nope"}`), "id", "test-repo"))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unknown target, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetTags(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/tags", nil, "id", "test-repo"))
+	var tags []models.Tag
+	if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil {
+		t.Fatalf("decode tags: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Name != "release/v1" || !tags[0].Annotated || tags[0].Tagger == nil {
+		t.Fatalf("unexpected tags: %+v", tags)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DeleteTag(rec, newRouteRequest(http.MethodDelete, "/api/repos/test-repo/tags", nil, "id", "test-repo", "*", "release%2Fv1"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.DeleteTag(rec, newRouteRequest(http.MethodDelete, "/api/repos/test-repo/tags", nil, "id", "test-repo", "*", "release/v1"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for deleted tag, got %d", rec.Code)
+	}
+}
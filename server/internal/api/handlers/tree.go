@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"gitweb/server/internal/git"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// treeErrorStatus maps revision tree and blob errors to HTTP status codes.
+func treeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrInvalidRevision),
+		errors.Is(err, git.ErrNotFile),
+		errors.Is(err, git.ErrNotDirectory):
+		return http.StatusBadRequest
+	case errors.Is(err, git.ErrRevisionNotFound),
+		errors.Is(err, git.ErrPathNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// revisionPathParams returns the decoded rev and wildcard path of a
+// /tree/{rev}/* or /blob/{rev}/* request.
+func revisionPathParams(r *http.Request) (string, string) {
+	rev := chi.URLParam(r, "rev")
+	if decoded, err := url.PathUnescape(rev); err == nil {
+		rev = decoded
+	}
+	filePath := chi.URLParam(r, "*")
+	if decoded, err := url.PathUnescape(filePath); err == nil {
+		filePath = decoded
+	}
+	return rev, filePath
+}
+
+// @Summary      Get tree at revision
+// @Description  List one directory level of the repository as of a commit, branch or tag, without checking it out. Refs containing slashes must be URL-encoded
+// @Tags         repositories
+// @Produce      json
+// @Param        id      path     string  true   "Repository ID"
+// @Param        rev     path     string  true   "Commit, branch or tag"
+// @Param        "*"     path     string  false  "Directory path (root when empty)"
+// @Param        offset  query    int     false  "Pagination offset"
+// @Param        limit   query    int     false  "Entries per page (default 500)"
+// @Success      200   {object} models.RevisionTree
+// @Failure      400   {string} string "Invalid revision or not a directory"
+// @Failure      404   {string} string "Repository, revision or path not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/tree/{rev}/{path} [get]
+func (h *RepositoryHandler) GetRevisionTree(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	rev, dirPath := revisionPathParams(r)
+	offset := parseQueryInt(r, "offset", 0)
+	limit := parseQueryInt(r, "limit", 500)
+
+	tree, err := h.gitService.ListTree(repo.Path, rev, dirPath, offset, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read tree: %v", err), treeErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// @Summary      Get file at revision
+// @Description  Read a file as of a commit, branch or tag, without checking it out. The JSON response carries the blob's size, mode and whether it is binary, with the content of text files; raw=true returns the bytes instead. Refs containing slashes must be URL-encoded
+// @Tags         repositories
+// @Produce      json
+// @Produce      octet-stream
+// @Param        id   path     string  true   "Repository ID"
+// @Param        rev  path     string  true   "Commit, branch or tag"
+// @Param        "*"  path     string  true   "File path"
+// @Param        raw  query    bool    false  "Return the file's bytes"
+// @Success      200   {object} models.RevisionBlob
+// @Failure      400   {string} string "Invalid revision or not a file"
+// @Failure      404   {string} string "Repository, revision or file not found"
+// @Security     BearerAuth
+// @Router       /api/repos/{id}/blob/{rev}/{path} [get]
+func (h *RepositoryHandler) GetRevisionBlob(w http.ResponseWriter, r *http.Request) {
+	repoID := chi.URLParam(r, "id")
+
+	repo, exists := h.repositoryByID(repoID)
+	if !exists {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+
+	rev, filePath := revisionPathParams(r)
+	if filePath == "" {
+		http.Error(w, "File path is required", http.StatusBadRequest)
+		return
+	}
+
+	blob, data, err := h.gitService.ReadBlob(repo.Path, rev, filePath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read file: %v", err), treeErrorStatus(err))
+		return
+	}
+
+	if r.URL.Query().Get("raw") == "true" {
+		contentType := "text/plain; charset=utf-8"
+		if blob.Binary {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blob)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestGetRevisionTreeAndBlob(t *testing.T) {
+	handler := NewRepositoryHandler(t.TempDir(), nil, nil)
+	repoDir, err := createTestRepository(handler, "test-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGitInRepo(t, repoDir, "config", "user.email", "test@example.com")
+	runGitInRepo(t, repoDir, "config", "user.name", "Test User")
+	runGitInRepo(t, repoDir, "checkout", "-q", "-b", "agent/feature")
+	runGitInRepo(t, repoDir, "mv", "README.md", "NOTES.md")
+	runGitInRepo(t, repoDir, "commit", "-q", "-m", "Rename readme")
+	runGitInRepo(t, repoDir, "checkout", "-q", "master")
+
+	rec := httptest.NewRecorder()
+	handler.GetRevisionTree(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/tree/agent%2Ffeature/", nil, "id", "test-repo", "rev", "agent%2Ffeature"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var tree models.RevisionTree
+	if err := json.NewDecoder(rec.Body).Decode(&tree); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Entries) != 1 || tree.Entries[0].Name != "NOTES.md" || tree.Rev == "" {
+		t.Fatalf("unexpected tree: %+v", tree)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetRevisionBlob(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/blob/agent%2Ffeature/NOTES.md", nil, "id", "test-repo", "rev", "agent%2Ffeature", "*", "NOTES.md"))
+	var blob models.RevisionBlob
+	if err := json.NewDecoder(rec.Body).Decode(&blob); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || blob.Content != "# Test Repository" || blob.Size != 17 || blob.Mode != "100644" || blob.Binary {
+		t.Fatalf("unexpected blob: %d %+v", rec.Code, blob)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetRevisionBlob(rec, newRouteRequest(http.MethodGet, "/api/repos/test-repo/blob/master/README.md?raw=true", nil, "id", "test-repo", "rev", "master", "*", "README.md"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "# Test Repository" || rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("unexpected raw blob: %d %q %s", rec.Code, rec.Body.String(), rec.Header().Get("Content-Type"))
+	}
+
+	tests := []struct {
+		kind, repoID, rev, path string
+		status                  int
+	}{
+		{"tree", "missing", "master", "", http.StatusNotFound},
+		{"tree", "test-repo", "nope", "", http.StatusNotFound},
+		{"tree", "test-repo", "master", "README.md", http.StatusBadRequest},
+		{"blob", "test-repo", "master", "NOTES.md", http.StatusNotFound},
+		{"blob", "test-repo", "-p", "README.md", http.StatusBadRequest},
+		{"blob", "test-repo", "master", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := newRouteRequest(http.MethodGet, "/api/repos/"+tt.repoID+"/"+tt.kind+"/"+tt.rev+"/"+tt.path, nil, "id", tt.repoID, "rev", tt.rev, "*", tt.path)
+		if tt.kind == "tree" {
+			handler.GetRevisionTree(rec, req)
+		} else {
+			handler.GetRevisionBlob(rec, req)
+		}
+		if rec.Code != tt.status {
+			t.Errorf("%s %s@%s:%s: expected status %d, got %d: %s", tt.kind, tt.repoID, tt.rev, tt.path, tt.status, rec.Code, rec.Body.String())
+		}
+	}
+}
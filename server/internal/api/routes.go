@@ -7,6 +7,7 @@ import (
 	"gitweb/server/internal/api/middleware"
 	"gitweb/server/internal/auth"
 	"gitweb/server/internal/config"
+	"gitweb/server/internal/credentials"
 	"gitweb/server/internal/registry"
 
 	"github.com/go-chi/chi/v5"
@@ -14,14 +15,14 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func NewRouter(ctx context.Context, dataPath string, cfg *config.Config, reg *registry.Registry, pm *auth.PairingManager, ts *auth.TokenStore, authHandler *handlers.AuthHandler) *chi.Mux {
+func NewRouter(ctx context.Context, dataPath string, cfg *config.Config, reg *registry.Registry, creds *credentials.Store, pm *auth.PairingManager, ts *auth.TokenStore, authHandler *handlers.AuthHandler) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5176", "http://100.117.191.67:5176"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Last-Event-ID"},
+		ExposedHeaders:   []string{"Link", "Location", "X-Gitty-Job-ID", "X-Gitty-Next-Cursor"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -40,8 +41,9 @@ func NewRouter(ctx context.Context, dataPath string, cfg *config.Config, reg *re
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
 	// Initialize handlers
-	repoHandler := newRepoHandler(ctx, dataPath, cfg, reg)
+	repoHandler := newRepoHandler(ctx, dataPath, cfg, reg, creds)
 	fsHandler := newFsHandler()
+	credHandler := handlers.NewCredentialHandler(creds)
 
 	// Auth handler is passed from main.go with session ID already set
 
@@ -67,18 +69,40 @@ func NewRouter(ctx context.Context, dataPath string, cfg *config.Config, reg *re
 
 		// Repository and filesystem routes
 		r.Route("/api", func(r chi.Router) {
+			r.Get("/events", repoHandler.StreamEvents)
+
+			r.Route("/jobs", func(r chi.Router) {
+				r.Get("/", repoHandler.ListJobs)
+				r.Get("/{jobId}", repoHandler.GetJob)
+				r.Post("/{jobId}/cancel", repoHandler.CancelJob)
+			})
+
+			r.Route("/credentials", func(r chi.Router) {
+				r.Get("/", credHandler.ListCredentials)
+				r.Post("/", credHandler.CreateCredential)
+				r.Get("/known-hosts", credHandler.ListKnownHosts)
+				r.Post("/known-hosts", credHandler.TrustHost)
+				r.Delete("/known-hosts/{host}", credHandler.ForgetHost)
+				r.Put("/{credentialId}", credHandler.UpdateCredential)
+				r.Delete("/{credentialId}", credHandler.DeleteCredential)
+			})
+
 			r.Route("/repos", func(r chi.Router) {
 				r.Get("/", repoHandler.ListRepositories)
 				r.Post("/", repoHandler.CreateRepository)
 				r.Post("/import", repoHandler.ImportRepository)
 
 				r.Route("/{id}", func(r chi.Router) {
+					// Routes registered on worktree need one; bare and mirror clones get 409
+					worktree := r.With(repoHandler.RequireWorktree)
+
 					r.Get("/", repoHandler.GetRepository)
 					r.Delete("/", repoHandler.DeleteRepository)
 
-					r.Get("/status", repoHandler.GetRepositoryStatus)
+					worktree.Get("/status", repoHandler.GetRepositoryStatus)
 					r.Get("/commits", repoHandler.GetCommitHistory)
 					r.Get("/commits/{hash}", repoHandler.GetCommitDetails)
+					r.Get("/compare", repoHandler.CompareRefs)
 					r.Get("/branches", repoHandler.GetBranches)
 					r.Get("/config/git", repoHandler.GetGitConfig)
 					r.Get("/settings", repoHandler.GetRepositorySettings)
@@ -86,30 +110,76 @@ func NewRouter(ctx context.Context, dataPath string, cfg *config.Config, reg *re
 					r.Put("/settings/sync", repoHandler.UpdateRepositorySettingsSync)
 					r.Put("/settings/commit", repoHandler.UpdateRepositorySettingsCommit)
 
-					r.Post("/commit", repoHandler.CreateCommit)
-					r.Post("/generate-commit-message", repoHandler.GenerateCommitMessage)
+					worktree.Post("/commit", repoHandler.CreateCommit)
+					worktree.Post("/generate-commit-message", repoHandler.GenerateCommitMessage)
 					r.Post("/branches", repoHandler.CreateBranch)
-					r.Put("/branches/{branch}", repoHandler.SwitchBranch)
+					worktree.Put("/branches/{branch}", repoHandler.SwitchBranch)
 					r.Delete("/branches/{branch}", repoHandler.DeleteBranch)
-
-					r.Get("/files", repoHandler.GetFileTree)
-					r.Get("/files/*", repoHandler.GetFileContent)
-					r.Put("/files/*", repoHandler.SaveFileContent)
+					worktree.Post("/merge", repoHandler.Merge)
+					worktree.Post("/merge/continue", repoHandler.ContinueMerge)
+					worktree.Post("/merge/abort", repoHandler.AbortMerge)
+					r.Get("/rebase", repoHandler.GetRebaseProgress)
+					worktree.Post("/rebase", repoHandler.Rebase)
+					worktree.Post("/rebase/continue", repoHandler.ContinueRebase)
+					worktree.Post("/rebase/skip", repoHandler.SkipRebase)
+					worktree.Post("/rebase/abort", repoHandler.AbortRebase)
+					worktree.Post("/cherry-pick", repoHandler.CherryPick)
+					worktree.Post("/cherry-pick/continue", repoHandler.ContinueCherryPick)
+					worktree.Post("/cherry-pick/abort", repoHandler.AbortCherryPick)
+					worktree.Post("/revert", repoHandler.Revert)
+					worktree.Post("/revert/continue", repoHandler.ContinueRevert)
+					worktree.Post("/revert/abort", repoHandler.AbortRevert)
+
+					worktree.Get("/conflicts", repoHandler.GetConflicts)
+					worktree.Get("/conflicts/*", repoHandler.GetConflictDetail)
+					worktree.Post("/conflicts/*", repoHandler.ResolveConflict)
+
+					worktree.Get("/stashes", repoHandler.ListStashes)
+					worktree.Post("/stashes", repoHandler.PushStash)
+					worktree.Get("/stashes/{index}", repoHandler.GetStash)
+					worktree.Delete("/stashes/{index}", repoHandler.DropStash)
+					worktree.Post("/stashes/{index}/apply", repoHandler.ApplyStash)
+					worktree.Post("/stashes/{index}/pop", repoHandler.PopStash)
+
+					r.Get("/tags", repoHandler.GetTags)
+					r.Post("/tags", repoHandler.CreateTag)
+					r.Post("/tags/push", repoHandler.PushTags)
+					r.Delete("/tags/*", repoHandler.DeleteTag)
+
+					worktree.Get("/files", repoHandler.GetFileTree)
+					worktree.Get("/files/*", repoHandler.GetFileContent)
+					r.Get("/blame/*", repoHandler.GetBlame)
+					r.Get("/history/*", repoHandler.GetFileHistory)
+					r.Get("/tree/{rev}", repoHandler.GetRevisionTree)
+					r.Get("/tree/{rev}/*", repoHandler.GetRevisionTree)
+					r.Get("/blob/{rev}/*", repoHandler.GetRevisionBlob)
+					worktree.Put("/files/*", repoHandler.SaveFileContent)
 
 					// Specific routes first (before /diff/*)
 					r.Get("/diff/commit/{hash}/files/*", repoHandler.HandleCommitFileDiff)
 					r.Get("/diff/commit/tokenized", repoHandler.HandleTokenizedCommitDiff)
-					r.Get("/diff/tokenized/*", repoHandler.HandleTokenizedFileDiff)
+					worktree.Get("/diff/tokenized/*", repoHandler.HandleTokenizedFileDiff)
 					// General diff route last
-					r.Get("/diff/*", repoHandler.GetFileDiff)
-
-					r.Post("/stage/*", repoHandler.StageFile)
-					r.Post("/stage-all", repoHandler.StageAllFiles)
-					r.Delete("/stage/*", repoHandler.UnstageFile)
-
+					worktree.Get("/diff/*", repoHandler.GetFileDiff)
+
+					worktree.Post("/stage/*", repoHandler.StageFile)
+					worktree.Post("/stage-all", repoHandler.StageAllFiles)
+					worktree.Delete("/stage/*", repoHandler.UnstageFile)
+					worktree.Post("/stage-partial/*", repoHandler.StagePartial)
+					worktree.Post("/unstage-partial/*", repoHandler.UnstagePartial)
+
+					worktree.Post("/discard/*", repoHandler.DiscardFile)
+					worktree.Post("/clean", repoHandler.CleanUntracked)
+					worktree.Post("/reset", repoHandler.Reset)
+
+					r.Get("/remotes", repoHandler.GetRemotes)
+					r.Post("/remotes", repoHandler.AddRemote)
+					r.Put("/remotes/{name}", repoHandler.UpdateRemote)
+					r.Delete("/remotes/{name}", repoHandler.RemoveRemote)
+					r.Post("/fetch", repoHandler.Fetch)
 					r.Post("/push", repoHandler.Push)
 					r.Post("/push/force", repoHandler.ForcePush)
-					r.Post("/pull", repoHandler.Pull)
+					worktree.Post("/pull", repoHandler.Pull)
 				})
 			})
 
@@ -123,9 +193,13 @@ func NewRouter(ctx context.Context, dataPath string, cfg *config.Config, reg *re
 	return r
 }
 
-func newRepoHandler(ctx context.Context, dataPath string, cfg *config.Config, reg *registry.Registry) *handlers.RepositoryHandler {
+func newRepoHandler(ctx context.Context, dataPath string, cfg *config.Config, reg *registry.Registry, creds *credentials.Store) *handlers.RepositoryHandler {
 	repoHandler := handlers.NewRepositoryHandler(dataPath, cfg, reg)
+	repoHandler.UseCredentials(creds)
 	repoHandler.StartPressureMonitor(ctx)
+	repoHandler.StartAutoFetch(ctx)
+	repoHandler.StartEventStream(ctx)
+	repoHandler.StartJobs(ctx)
 	return repoHandler
 }
 
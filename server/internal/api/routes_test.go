@@ -30,7 +30,7 @@ func TestNewRouterListRepositories(t *testing.T) {
 	}
 
 	authHandler := handlers.NewAuthHandlerWithSession(pm, ts, masterPassword, "")
-	r := NewRouter(context.Background(), tempDir, cfg, reg, pm, ts, authHandler)
+	r := NewRouter(context.Background(), tempDir, cfg, reg, nil, pm, ts, authHandler)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/repos", nil)
 	req.Header.Set("Authorization", "Bearer "+rawToken)
@@ -62,7 +62,7 @@ func TestHealthEndpointOpenWithoutBearer(t *testing.T) {
 	reg, _ := registry.New(tempDir + "/registry.json")
 
 	authHandler := handlers.NewAuthHandlerWithSession(pm, ts, masterPassword, "")
-	r := NewRouter(context.Background(), tempDir, cfg, reg, pm, ts, authHandler)
+	r := NewRouter(context.Background(), tempDir, cfg, reg, nil, pm, ts, authHandler)
 
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
 	rr := httptest.NewRecorder()
@@ -84,7 +84,7 @@ func TestAuthPairExchangeOpenWithoutBearer(t *testing.T) {
 	reg, _ := registry.New(tempDir + "/registry.json")
 
 	authHandler := handlers.NewAuthHandlerWithSession(pm, ts, masterPassword, "")
-	r := NewRouter(context.Background(), tempDir, cfg, reg, pm, ts, authHandler)
+	r := NewRouter(context.Background(), tempDir, cfg, reg, nil, pm, ts, authHandler)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/auth/pair/exchange", nil)
 	rr := httptest.NewRecorder()
@@ -107,7 +107,7 @@ func TestApiReposRequiresBearer(t *testing.T) {
 	reg, _ := registry.New(tempDir + "/registry.json")
 
 	authHandler := handlers.NewAuthHandlerWithSession(pm, ts, masterPassword, "")
-	r := NewRouter(context.Background(), tempDir, cfg, reg, pm, ts, authHandler)
+	r := NewRouter(context.Background(), tempDir, cfg, reg, nil, pm, ts, authHandler)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/repos", nil)
 	rr := httptest.NewRecorder()
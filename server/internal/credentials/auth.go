@@ -0,0 +1,230 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"gitweb/server/internal/git"
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+)
+
+// AuthMethod returns the go-git authentication for a remote URL, using the
+// credential with id or, when id is empty, the credential stored for the
+// URL's host. It returns nil when no credential applies.
+func (s *Store) AuthMethod(id, remoteURL string) (transport.AuthMethod, error) {
+	cred, sec, endpoint, err := s.resolve(id, remoteURL)
+	if err != nil || cred == nil {
+		return nil, err
+	}
+
+	switch cred.Kind {
+	case KindHTTPSToken:
+		return &githttp.BasicAuth{Username: cred.Username, Password: sec.Token}, nil
+	case KindSSHKey:
+		auth, err := gitssh.NewPublicKeys(sshUser(cred, endpoint), []byte(sec.PrivateKey), sec.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key of credential %s: %w", cred.ID, err)
+		}
+		auth.HostKeyCallback = s.hosts.callback
+		return &sshAuth{AuthMethod: auth, hosts: s.hosts, hostWithPort: hostWithPort(endpoint)}, nil
+	default:
+		auth, err := gitssh.NewSSHAgentAuth(sshUser(cred, endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to use ssh-agent: %w", err)
+		}
+		auth.HostKeyCallback = s.hosts.callback
+		return &sshAuth{AuthMethod: auth, hosts: s.hosts, hostWithPort: hostWithPort(endpoint)}, nil
+	}
+}
+
+// CommandEnv returns environment variables that authenticate a git command
+// against remoteURL, chosen as in AuthMethod, and a func that removes the
+// files they refer to. The host key of an SSH remote is checked first, so an
+// unknown key is reported as pending just as for go-git connections.
+func (s *Store) CommandEnv(id, remoteURL string) ([]string, func(), error) {
+	cleanup := func() {}
+	cred, sec, endpoint, err := s.resolve(id, remoteURL)
+	if err != nil || cred == nil {
+		return nil, cleanup, err
+	}
+
+	if cred.Kind == KindHTTPSToken {
+		// Scope the header to the remote's host so it never reaches another
+		// one, such as a submodule's.
+		prefix := endpoint.Protocol + "://" + endpoint.Host
+		if endpoint.Port != 0 {
+			prefix += ":" + strconv.Itoa(endpoint.Port)
+		}
+		header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+sec.Token))
+		return configEnv("http."+prefix+"/.extraHeader", header), cleanup, nil
+	}
+
+	if err := s.hosts.verify(hostWithPort(endpoint)); err != nil {
+		return nil, cleanup, err
+	}
+
+	command := []string{
+		"ssh",
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile=" + git.ShellQuote(s.hosts.path),
+		"-o", "GlobalKnownHostsFile=/dev/null",
+	}
+	if endpoint.User == "" {
+		command = append(command, "-l", git.ShellQuote(cred.Username))
+	}
+
+	if cred.Kind == KindSSHKey {
+		keyFile, err := writeKeyFile(sec)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		cleanup = func() { os.Remove(keyFile) }
+		command = append(command, "-o", "IdentitiesOnly=yes", "-o", "IdentityAgent=none", "-i", git.ShellQuote(keyFile))
+	}
+
+	return []string{"GIT_SSH_COMMAND=" + strings.Join(command, " "), "GIT_SSH_VARIANT=ssh"}, cleanup, nil
+}
+
+// resolve finds the credential for a remote URL and decrypts its secret. A
+// credential chosen by id must suit the URL's protocol; one matched by host
+// is only picked when it does.
+func (s *Store) resolve(id, remoteURL string) (*models.Credential, *secret, *transport.Endpoint, error) {
+	endpoint, err := transport.NewEndpoint(remoteURL)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid remote url: %w", err)
+	}
+	if endpoint.Protocol == "file" {
+		return nil, nil, nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rec *record
+	if id != "" {
+		i := s.indexOf(id)
+		if i < 0 {
+			return nil, nil, nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		rec = &s.file.Credentials[i]
+		if !suits(rec.Kind, endpoint.Protocol) {
+			return nil, nil, nil, fmt.Errorf("%w: %s (%s) cannot be used with %s remotes", ErrUnsuitable, id, rec.Kind, endpoint.Protocol)
+		}
+	} else {
+		host := strings.ToLower(endpoint.Host)
+		for i := range s.file.Credentials {
+			candidate := &s.file.Credentials[i]
+			if candidate.Host == host && suits(candidate.Kind, endpoint.Protocol) {
+				rec = candidate
+				break
+			}
+		}
+		if rec == nil {
+			return nil, nil, nil, nil
+		}
+	}
+
+	sec, err := s.secretOf(*rec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cred := rec.Credential
+	return &cred, sec, endpoint, nil
+}
+
+// suits reports whether a credential kind can authenticate over protocol.
+func suits(kind, protocol string) bool {
+	if kind == KindHTTPSToken {
+		return protocol == "https" || protocol == "http"
+	}
+	return protocol == "ssh"
+}
+
+// sshAuth gives go-git connections the managed known_hosts file, asking for
+// host key types that are already trusted (see golang/go#29286).
+type sshAuth struct {
+	gitssh.AuthMethod
+	hosts        *knownHosts
+	hostWithPort string
+}
+
+func (a *sshAuth) ClientConfig() (*ssh.ClientConfig, error) {
+	config, err := a.AuthMethod.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.HostKeyAlgorithms = a.hosts.algorithms(a.hostWithPort)
+	return config, nil
+}
+
+// sshUser prefers the user in the remote URL (git@host:...) over the
+// credential's.
+func sshUser(cred *models.Credential, endpoint *transport.Endpoint) string {
+	if endpoint.User != "" {
+		return endpoint.User
+	}
+	return cred.Username
+}
+
+func hostWithPort(endpoint *transport.Endpoint) string {
+	port := endpoint.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(endpoint.Host, strconv.Itoa(port))
+}
+
+// writeKeyFile writes an unencrypted copy of a credential's private key to a
+// private temporary file for the ssh client.
+func writeKeyFile(sec *secret) (string, error) {
+	var key any
+	var err error
+	if sec.Passphrase != "" {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(sec.PrivateKey), []byte(sec.Passphrase))
+	} else {
+		key, err = ssh.ParseRawPrivateKey([]byte(sec.PrivateKey))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load private key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "gitty-key-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write private key: %w", err)
+	}
+	defer file.Close()
+	if err := file.Chmod(0o600); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write private key: %w", err)
+	}
+	if _, err := file.Write(pem.EncodeToMemory(block)); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write private key: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// configEnv passes a git config setting through the environment, after any
+// settings already passed that way.
+func configEnv(key, value string) []string {
+	count, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	return []string{
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", count+1),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", count, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, value),
+	}
+}
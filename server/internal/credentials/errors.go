@@ -0,0 +1,26 @@
+package credentials
+
+import "errors"
+
+// Errors that Store methods and SSH host key checks wrap, with the details.
+// Callers tell them apart with errors.Is.
+var (
+	// ErrNotFound means there is no credential with that ID.
+	ErrNotFound = errors.New("credential not found")
+	// ErrInvalid means a credential is missing a field or holds an unusable key.
+	ErrInvalid = errors.New("invalid credential")
+	// ErrUnsuitable means the credential's kind does not fit the remote's protocol.
+	ErrUnsuitable = errors.New("unsuitable credential")
+	// ErrUnknownHostKey means the SSH server's key is not trusted yet. It is
+	// kept as pending until it is trusted.
+	ErrUnknownHostKey = errors.New("unknown host key")
+	// ErrHostKeyMismatch means the SSH server presented a key other than the
+	// trusted one.
+	ErrHostKeyMismatch = errors.New("host key mismatch")
+	// ErrNoPendingHostKey means no unknown key was seen for the host.
+	ErrNoPendingHostKey = errors.New("no pending host key")
+	// ErrFingerprintMismatch means the fingerprint to trust is not the pending key's.
+	ErrFingerprintMismatch = errors.New("fingerprint does not match the pending host key")
+	// ErrHostNotFound means the host has no trusted key.
+	ErrHostNotFound = errors.New("host not found")
+)
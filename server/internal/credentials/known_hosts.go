@@ -0,0 +1,249 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitweb/server/internal/models"
+
+	"github.com/skeema/knownhosts"
+	"golang.org/x/crypto/ssh"
+)
+
+// hostCheckTimeout bounds the connection made to check a host key before a
+// git command connects on its own.
+const hostCheckTimeout = 15 * time.Second
+
+// knownHosts is the managed known_hosts file. A host key seen for the first
+// time is not trusted: the connection fails and the key waits, as pending,
+// for the user to confirm its fingerprint.
+type knownHosts struct {
+	mu      sync.Mutex
+	path    string
+	pending map[string]ssh.PublicKey // by normalized host
+}
+
+func newKnownHosts(path string) (*knownHosts, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create known hosts file: %w", err)
+	}
+	file.Close()
+
+	return &knownHosts{path: path, pending: make(map[string]ssh.PublicKey)}, nil
+}
+
+// callback is the ssh.HostKeyCallback for connections to remotes. The file
+// is read on every connection so that newly trusted keys apply at once.
+func (k *knownHosts) callback(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	db, err := knownhosts.NewDB(k.path)
+	if err != nil {
+		return fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	err = db.HostKeyCallback()(hostname, remote, key)
+	host := knownhosts.Normalize(hostname)
+	switch {
+	case err == nil:
+		return nil
+	case knownhosts.IsHostKeyChanged(err):
+		return fmt.Errorf("%w for %s: the server presented %s %s, which is not the trusted key", ErrHostKeyMismatch, host, key.Type(), ssh.FingerprintSHA256(key))
+	case knownhosts.IsHostUnknown(err):
+		k.mu.Lock()
+		k.pending[host] = key
+		k.mu.Unlock()
+		return fmt.Errorf("%w for %s: %s %s must be trusted before connecting", ErrUnknownHostKey, host, key.Type(), ssh.FingerprintSHA256(key))
+	}
+	return err
+}
+
+// algorithms returns the host key algorithms to ask a host for, so that a
+// host with a trusted key is not asked for a key of another type.
+func (k *knownHosts) algorithms(hostWithPort string) []string {
+	db, err := knownhosts.NewDB(k.path)
+	if err != nil {
+		return nil
+	}
+	return db.HostKeyAlgorithms(hostWithPort)
+}
+
+// verify connects to an SSH host just far enough to check its key, for
+// commands that run the ssh client themselves and cannot report an unknown
+// key as pending.
+func (k *knownHosts) verify(hostWithPort string) error {
+	var hostErr error
+	checked := false
+	config := &ssh.ClientConfig{
+		User: defaultUsername,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			checked = true
+			hostErr = k.callback(hostname, remote, key)
+			return hostErr
+		},
+		HostKeyAlgorithms: k.algorithms(hostWithPort),
+		Timeout:           hostCheckTimeout,
+	}
+
+	client, err := ssh.Dial("tcp", hostWithPort, config)
+	if client != nil {
+		client.Close()
+	}
+	switch {
+	case hostErr != nil:
+		return hostErr
+	case !checked:
+		return fmt.Errorf("failed to connect to %s: %w", hostWithPort, err)
+	}
+	// Authentication is up to the command itself
+	return nil
+}
+
+// list returns the trusted and pending host keys.
+func (k *knownHosts) list() (models.KnownHosts, error) {
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return models.KnownHosts{}, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	result := models.KnownHosts{Trusted: []models.KnownHost{}, Pending: []models.KnownHost{}}
+	for rest := data; ; {
+		_, hosts, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return models.KnownHosts{}, fmt.Errorf("failed to parse known hosts: %w", err)
+		}
+		for _, host := range hosts {
+			result.Trusted = append(result.Trusted, knownHost(host, key))
+		}
+		rest = next
+	}
+
+	k.mu.Lock()
+	for host, key := range k.pending {
+		result.Pending = append(result.Pending, knownHost(host, key))
+	}
+	k.mu.Unlock()
+	sort.Slice(result.Pending, func(a, b int) bool {
+		return result.Pending[a].Host < result.Pending[b].Host
+	})
+
+	return result, nil
+}
+
+// trust adds a pending host key to the file, provided the fingerprint the
+// user confirmed is the one of the key that was seen.
+func (k *knownHosts) trust(host, fingerprint string) (models.KnownHost, error) {
+	host = knownhosts.Normalize(strings.TrimSpace(host))
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.pending[host]
+	if !ok {
+		return models.KnownHost{}, fmt.Errorf("%w for %s", ErrNoPendingHostKey, host)
+	}
+	if ssh.FingerprintSHA256(key) != strings.TrimSpace(fingerprint) {
+		return models.KnownHost{}, fmt.Errorf("%w for %s", ErrFingerprintMismatch, host)
+	}
+
+	file, err := os.OpenFile(k.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return models.KnownHost{}, fmt.Errorf("failed to open known hosts: %w", err)
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, knownhosts.Line([]string{host}, key)); err != nil {
+		return models.KnownHost{}, fmt.Errorf("failed to write known hosts: %w", err)
+	}
+
+	delete(k.pending, host)
+	return knownHost(host, key), nil
+}
+
+// forget removes a host's trusted and pending keys.
+func (k *knownHosts) forget(host string) error {
+	host = knownhosts.Normalize(strings.TrimSpace(host))
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	_, found := k.pending[host]
+	delete(k.pending, host)
+
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		_, hosts, key, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			// Comments, blank and unparsable lines are kept as they are
+			out.WriteString(line + "\n")
+			continue
+		}
+
+		remaining := make([]string, 0, len(hosts))
+		for _, h := range hosts {
+			if h != host {
+				remaining = append(remaining, h)
+			}
+		}
+		switch {
+		case len(remaining) == len(hosts):
+			out.WriteString(line + "\n")
+		case len(remaining) > 0:
+			found = true
+			out.WriteString(knownhosts.Line(remaining, key) + "\n")
+		default:
+			found = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	if !found {
+		return fmt.Errorf("%w: %s", ErrHostNotFound, host)
+	}
+	if err := os.WriteFile(k.path, out.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write known hosts: %w", err)
+	}
+	return nil
+}
+
+func knownHost(host string, key ssh.PublicKey) models.KnownHost {
+	return models.KnownHost{
+		Host:        host,
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+	}
+}
+
+// KnownHosts returns the trusted host keys and the keys waiting to be trusted.
+func (s *Store) KnownHosts() (models.KnownHosts, error) {
+	return s.hosts.list()
+}
+
+// TrustHost trusts the pending key of host, whose fingerprint must match.
+func (s *Store) TrustHost(host, fingerprint string) (models.KnownHost, error) {
+	return s.hosts.trust(host, fingerprint)
+}
+
+// ForgetHost removes the keys of host, so that it is trusted anew on the next
+// connection.
+func (s *Store) ForgetHost(host string) error {
+	return s.hosts.forget(host)
+}
@@ -0,0 +1,140 @@
+package credentials
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestSSHServer serves SSH handshakes with a fresh host key and returns
+// its address and key.
+func newTestSSHServer(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("host key signer: %v", err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if sconn, _, _, err := ssh.NewServerConn(conn, config); err == nil {
+					sconn.Close()
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), signer.PublicKey()
+}
+
+func TestKnownHosts_TrustOnFirstUse(t *testing.T) {
+	store, err := Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	addr, key := newTestSSHServer(t)
+	_, port, _ := net.SplitHostPort(addr)
+	host := "[127.0.0.1]:" + port
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	err = store.hosts.verify(addr)
+	if !errors.Is(err, ErrUnknownHostKey) || !strings.Contains(err.Error(), "unknown host key for "+host) || !strings.Contains(err.Error(), fingerprint) {
+		t.Fatalf("expected an unknown host key error with the fingerprint, got %v", err)
+	}
+
+	hosts, err := store.KnownHosts()
+	if err != nil {
+		t.Fatalf("list known hosts: %v", err)
+	}
+	if len(hosts.Trusted) != 0 || len(hosts.Pending) != 1 || hosts.Pending[0].Host != host || hosts.Pending[0].Fingerprint != fingerprint {
+		t.Fatalf("expected one pending host, got %+v", hosts)
+	}
+
+	if _, err := store.TrustHost(host, "SHA256:wrong"); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("expected a fingerprint mismatch, got %v", err)
+	}
+	trusted, err := store.TrustHost(host, fingerprint)
+	if err != nil {
+		t.Fatalf("trust host: %v", err)
+	}
+	if trusted.KeyType != ssh.KeyAlgoED25519 {
+		t.Errorf("expected an ed25519 key, got %+v", trusted)
+	}
+
+	if err := store.hosts.verify(addr); err != nil {
+		t.Fatalf("expected the trusted host to verify, got %v", err)
+	}
+	hosts, err = store.KnownHosts()
+	if err != nil {
+		t.Fatalf("list known hosts: %v", err)
+	}
+	if len(hosts.Trusted) != 1 || len(hosts.Pending) != 0 || hosts.Trusted[0].Host != host {
+		t.Fatalf("expected one trusted host, got %+v", hosts)
+	}
+	if _, err := store.TrustHost(host, fingerprint); !errors.Is(err, ErrNoPendingHostKey) {
+		t.Errorf("expected nothing left to trust, got %v", err)
+	}
+}
+
+func TestKnownHosts_MismatchAndForget(t *testing.T) {
+	store, err := Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	addr, key := newTestSSHServer(t)
+	_, port, _ := net.SplitHostPort(addr)
+	host := "[127.0.0.1]:" + port
+
+	// Trust another key for the same host
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	otherKey, err := ssh.NewPublicKey(other.Public())
+	if err != nil {
+		t.Fatalf("public key: %v", err)
+	}
+	store.hosts.pending[host] = otherKey
+	if _, err := store.TrustHost(host, ssh.FingerprintSHA256(otherKey)); err != nil {
+		t.Fatalf("trust host: %v", err)
+	}
+
+	err = store.hosts.verify(addr)
+	if !errors.Is(err, ErrHostKeyMismatch) || !strings.Contains(err.Error(), "host key mismatch for "+host) || !strings.Contains(err.Error(), ssh.FingerprintSHA256(key)) {
+		t.Fatalf("expected a host key mismatch, got %v", err)
+	}
+
+	if err := store.ForgetHost(host); err != nil {
+		t.Fatalf("forget host: %v", err)
+	}
+	if err := store.ForgetHost(host); !errors.Is(err, ErrHostNotFound) {
+		t.Errorf("expected the host to be gone, got %v", err)
+	}
+	if err := store.hosts.verify(addr); !errors.Is(err, ErrUnknownHostKey) {
+		t.Errorf("expected the forgotten host to be unknown again, got %v", err)
+	}
+}
@@ -0,0 +1,417 @@
+// Package credentials stores the HTTPS tokens and SSH keys gittyd uses to
+// reach private remotes, encrypted at rest with a key derived from the master
+// password, together with a managed known_hosts file for SSH remotes.
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitweb/server/internal/models"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/ssh"
+)
+
+// Credential kinds.
+const (
+	KindHTTPSToken = "https-token"
+	KindSSHKey     = "ssh-key"
+	KindSSHAgent   = "ssh-agent"
+)
+
+// defaultUsername is used when a credential does not name a user. Git hosts
+// accept any user name with a token, and "git" for SSH.
+const defaultUsername = "git"
+
+// Key derivation parameters (Argon2id).
+const (
+	kdfTime    = 1
+	kdfMemory  = 64 * 1024
+	kdfThreads = 4
+	keyLength  = 32
+	saltLength = 16
+)
+
+// checkValue is encrypted with the derived key so that opening the store with
+// another master password fails instead of yielding garbage.
+const checkValue = "gitty-credentials"
+
+// Store manages credentials with file-backed persistence. Only metadata is
+// kept in plain text; secrets are sealed with AES-GCM.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	key   []byte
+	file  storeFile
+	hosts *knownHosts
+}
+
+type storeFile struct {
+	Salt        []byte   `json:"salt"`
+	Check       []byte   `json:"check"`
+	Credentials []record `json:"credentials"`
+}
+
+type record struct {
+	models.Credential
+	Secret []byte `json:"secret,omitempty"`
+}
+
+// secret is the sealed part of a credential.
+type secret struct {
+	Token      string `json:"token,omitempty"`
+	PrivateKey string `json:"private_key,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// Open loads (or creates) the credential store and known_hosts file in dir,
+// unlocking it with masterPassword.
+func Open(dir, masterPassword string) (*Store, error) {
+	if masterPassword == "" {
+		return nil, errors.New("credential store: master password is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create credential directory: %w", err)
+	}
+
+	hosts, err := newKnownHosts(filepath.Join(dir, "known_hosts"))
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: filepath.Join(dir, "credentials.json"), hosts: hosts}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+		s.key = deriveKey(masterPassword, salt)
+		check, err := seal(s.key, "check", []byte(checkValue))
+		if err != nil {
+			return nil, err
+		}
+		s.file = storeFile{Salt: salt, Check: check, Credentials: []record{}}
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read credential store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.file); err != nil {
+		return nil, fmt.Errorf("decode credential store: %w", err)
+	}
+	s.key = deriveKey(masterPassword, s.file.Salt)
+	if check, err := open(s.key, "check", s.file.Check); err != nil || string(check) != checkValue {
+		return nil, errors.New("credential store: master password does not match the one the store was encrypted with")
+	}
+	return s, nil
+}
+
+// List returns the stored credentials, without their secrets, by name.
+func (s *Store) List() []models.Credential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]models.Credential, 0, len(s.file.Credentials))
+	for _, rec := range s.file.Credentials {
+		list = append(list, rec.Credential)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return strings.ToLower(list[a].Name) < strings.ToLower(list[b].Name)
+	})
+	return list
+}
+
+// Get returns a credential, without its secret, by ID.
+func (s *Store) Get(id string) (models.Credential, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.indexOf(id); i >= 0 {
+		return s.file.Credentials[i].Credential, true
+	}
+	return models.Credential{}, false
+}
+
+// Add validates and stores a new credential.
+func (s *Store) Add(req models.CredentialRequest) (models.Credential, error) {
+	id, err := newID()
+	if err != nil {
+		return models.Credential{}, err
+	}
+
+	now := time.Now().UTC()
+	rec := record{Credential: models.Credential{ID: id, CreatedAt: now, UpdatedAt: now}}
+	if err := s.apply(&rec, req, nil); err != nil {
+		return models.Credential{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.file.Credentials = append(s.file.Credentials, rec)
+	if err := s.save(); err != nil {
+		s.file.Credentials = s.file.Credentials[:len(s.file.Credentials)-1] // roll back
+		return models.Credential{}, err
+	}
+	return rec.Credential, nil
+}
+
+// Update replaces a credential. A request without a secret keeps the stored
+// secret, as long as the kind is unchanged.
+func (s *Store) Update(id string, req models.CredentialRequest) (models.Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return models.Credential{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	previous := s.file.Credentials[i]
+	existing, err := s.secretOf(previous)
+	if err != nil {
+		return models.Credential{}, err
+	}
+	if req.Kind != previous.Kind {
+		existing = nil
+	}
+
+	rec := record{Credential: models.Credential{ID: id, CreatedAt: previous.CreatedAt, UpdatedAt: time.Now().UTC()}}
+	if err := s.apply(&rec, req, existing); err != nil {
+		return models.Credential{}, err
+	}
+
+	s.file.Credentials[i] = rec
+	if err := s.save(); err != nil {
+		s.file.Credentials[i] = previous // roll back
+		return models.Credential{}, err
+	}
+	return rec.Credential, nil
+}
+
+// Delete removes a credential. Remotes that chose it fail to authenticate
+// until they choose another one.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	previous := s.file.Credentials
+	s.file.Credentials = append(append([]record{}, previous[:i]...), previous[i+1:]...)
+	if err := s.save(); err != nil {
+		s.file.Credentials = previous // roll back
+		return err
+	}
+	return nil
+}
+
+// apply validates req and fills rec with its metadata and sealed secret.
+// existing, when set, is the stored secret a request without one keeps.
+func (s *Store) apply(rec *record, req models.CredentialRequest, existing *secret) error {
+	rec.Name = strings.TrimSpace(req.Name)
+	rec.Kind = strings.TrimSpace(req.Kind)
+	rec.Host = strings.ToLower(strings.TrimSpace(req.Host))
+	rec.Username = strings.TrimSpace(req.Username)
+	if rec.Username == "" {
+		rec.Username = defaultUsername
+	}
+
+	if rec.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if strings.ContainsAny(rec.Host, "/@ \t") {
+		return fmt.Errorf("%w: invalid host %q", ErrInvalid, req.Host)
+	}
+
+	var sec secret
+	switch rec.Kind {
+	case KindHTTPSToken:
+		sec.Token = strings.TrimSpace(req.Token)
+		if sec.Token == "" && existing != nil {
+			sec.Token = existing.Token
+		}
+		if sec.Token == "" {
+			return fmt.Errorf("%w: token is required", ErrInvalid)
+		}
+	case KindSSHKey:
+		sec.PrivateKey, sec.Passphrase = req.PrivateKey, req.Passphrase
+		if strings.TrimSpace(sec.PrivateKey) == "" && existing != nil {
+			sec = *existing
+		}
+		if strings.TrimSpace(sec.PrivateKey) == "" {
+			return fmt.Errorf("%w: private_key is required", ErrInvalid)
+		}
+		signer, err := parsePrivateKey(sec.PrivateKey, sec.Passphrase)
+		if err != nil {
+			return err
+		}
+		rec.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	case KindSSHAgent:
+		// The key stays in the agent
+	default:
+		return fmt.Errorf("%w: unsupported kind %q", ErrInvalid, req.Kind)
+	}
+
+	if sec == (secret{}) {
+		rec.Secret = nil
+		return nil
+	}
+	plaintext, err := json.Marshal(sec)
+	if err != nil {
+		return fmt.Errorf("marshal secret: %w", err)
+	}
+	rec.Secret, err = seal(s.key, rec.ID, plaintext)
+	return err
+}
+
+// secretOf decrypts a credential's secret. Must be called with s.mu held.
+func (s *Store) secretOf(rec record) (*secret, error) {
+	sec := &secret{}
+	if len(rec.Secret) == 0 {
+		return sec, nil
+	}
+	plaintext, err := open(s.key, rec.ID, rec.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credential %s: %w", rec.ID, err)
+	}
+	if err := json.Unmarshal(plaintext, sec); err != nil {
+		return nil, fmt.Errorf("failed to decode credential %s: %w", rec.ID, err)
+	}
+	return sec, nil
+}
+
+// indexOf returns the position of a credential, or -1. Must be called with
+// s.mu held.
+func (s *Store) indexOf(id string) int {
+	for i, rec := range s.file.Credentials {
+		if rec.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// save atomically writes the store to disk using a temp file + rename.
+// Must be called with s.mu held.
+func (s *Store) save() (err error) {
+	data, err := json.MarshalIndent(s.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal credential store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".credentials-*.json")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Ensure temp file is cleaned up on failure.
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if err = tmp.Chmod(0600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err = os.Rename(tmpName, s.path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
+
+// parsePrivateKey checks that a private key can be used, decrypting it with
+// passphrase when it is encrypted.
+func parsePrivateKey(privateKey, passphrase string) (ssh.Signer, error) {
+	var signer ssh.Signer
+	var err error
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(privateKey))
+	}
+
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case errors.As(err, &missing):
+		return nil, fmt.Errorf("%w: private key is encrypted and needs a passphrase", ErrInvalid)
+	case err != nil:
+		return nil, fmt.Errorf("%w: invalid private key: %v", ErrInvalid, err)
+	}
+	return signer, nil
+}
+
+func deriveKey(masterPassword string, salt []byte) []byte {
+	return argon2.IDKey([]byte(masterPassword), salt, kdfTime, kdfMemory, kdfThreads, keyLength)
+}
+
+// seal encrypts plaintext with AES-GCM, binding it to aad (the credential ID)
+// so that sealed secrets cannot be swapped between credentials. The nonce is
+// prepended to the result.
+func seal(key []byte, aad string, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, []byte(aad)), nil
+}
+
+func open(key []byte, aad string, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed value is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, []byte(aad))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate credential id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
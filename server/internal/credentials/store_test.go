@@ -0,0 +1,213 @@
+package credentials
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestKey(t *testing.T) string {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return string(pem.EncodeToMemory(block))
+}
+
+func TestStore_AddPersistsEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, "master")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+
+	token, err := store.Add(models.CredentialRequest{Name: "GitHub", Kind: KindHTTPSToken, Host: "GitHub.com", Token: "ghp_secret"})
+	if err != nil {
+		t.Fatalf("add token: %v", err)
+	}
+	if token.Host != "github.com" || token.Username != defaultUsername {
+		t.Errorf("expected normalized host and default user, got %+v", token)
+	}
+	key, err := store.Add(models.CredentialRequest{Name: "Deploy key", Kind: KindSSHKey, Host: "gitlab.com", PrivateKey: newTestKey(t)})
+	if err != nil {
+		t.Fatalf("add key: %v", err)
+	}
+	if !strings.HasPrefix(key.PublicKey, "ssh-ed25519 ") {
+		t.Errorf("expected the public key to be derived, got %q", key.PublicKey)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "credentials.json"))
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	if strings.Contains(string(data), "ghp_secret") || strings.Contains(string(data), "PRIVATE KEY") {
+		t.Fatal("expected secrets to be encrypted at rest")
+	}
+
+	reopened, err := Open(dir, "master")
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	list := reopened.List()
+	if len(list) != 2 || list[0].Name != "Deploy key" || list[1].Name != "GitHub" {
+		t.Fatalf("expected both credentials sorted by name, got %+v", list)
+	}
+	auth, err := reopened.AuthMethod("", "https://github.com/owner/repo.git")
+	if err != nil {
+		t.Fatalf("auth method: %v", err)
+	}
+	if basic, ok := auth.(*githttp.BasicAuth); !ok || basic.Password != "ghp_secret" {
+		t.Errorf("expected the decrypted token, got %#v", auth)
+	}
+
+	if _, err := Open(dir, "other"); err == nil || !strings.Contains(err.Error(), "master password does not match") {
+		t.Fatalf("expected a wrong master password to be rejected, got %v", err)
+	}
+}
+
+func TestStore_UpdateAndDelete(t *testing.T) {
+	store, err := Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+
+	cred, err := store.Add(models.CredentialRequest{Name: "GitHub", Kind: KindHTTPSToken, Host: "github.com", Token: "first"})
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	// No token keeps the stored one
+	updated, err := store.Update(cred.ID, models.CredentialRequest{Name: "GitHub work", Kind: KindHTTPSToken, Host: "github.com", Username: "me"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Name != "GitHub work" || updated.Username != "me" || !updated.CreatedAt.Equal(cred.CreatedAt) {
+		t.Errorf("unexpected update result %+v", updated)
+	}
+	auth, err := store.AuthMethod(cred.ID, "https://github.com/owner/repo.git")
+	if err != nil {
+		t.Fatalf("auth method: %v", err)
+	}
+	if basic := auth.(*githttp.BasicAuth); basic.Username != "me" || basic.Password != "first" {
+		t.Errorf("expected the kept token with the new user, got %+v", basic)
+	}
+
+	// Changing the kind needs a new secret
+	if _, err := store.Update(cred.ID, models.CredentialRequest{Name: "GitHub", Kind: KindSSHKey, Host: "github.com"}); !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), "private_key is required") {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+
+	if err := store.Delete(cred.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := store.Get(cred.ID); ok {
+		t.Error("expected the credential to be gone")
+	}
+	if err := store.Delete(cred.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestStore_AddValidates(t *testing.T) {
+	store, err := Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+
+	requests := []models.CredentialRequest{
+		{Kind: KindHTTPSToken, Token: "x"},
+		{Name: "n", Kind: "password", Token: "x"},
+		{Name: "n", Kind: KindHTTPSToken},
+		{Name: "n", Kind: KindHTTPSToken, Host: "user@github.com", Token: "x"},
+		{Name: "n", Kind: KindSSHKey, PrivateKey: "not a key"},
+	}
+	for _, req := range requests {
+		if _, err := store.Add(req); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Add(%+v) = %v, want an invalid credential error", req, err)
+		}
+	}
+	if len(store.List()) != 0 {
+		t.Error("expected nothing to be stored")
+	}
+}
+
+func TestStore_ResolvesByHostAndKind(t *testing.T) {
+	store, err := Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	token, err := store.Add(models.CredentialRequest{Name: "token", Kind: KindHTTPSToken, Host: "example.com", Token: "t"})
+	if err != nil {
+		t.Fatalf("add token: %v", err)
+	}
+	if _, err := store.Add(models.CredentialRequest{Name: "key", Kind: KindSSHKey, Host: "example.com", PrivateKey: newTestKey(t)}); err != nil {
+		t.Fatalf("add key: %v", err)
+	}
+
+	auth, err := store.AuthMethod("", "git@example.com:owner/repo.git")
+	if err != nil {
+		t.Fatalf("ssh auth: %v", err)
+	}
+	if auth == nil || auth.Name() != "ssh-public-keys" {
+		t.Errorf("expected the SSH key for an SSH remote, got %#v", auth)
+	}
+
+	if auth, err := store.AuthMethod("", "https://other.example/repo.git"); err != nil || auth != nil {
+		t.Errorf("expected no credential for another host, got %#v, %v", auth, err)
+	}
+	if auth, err := store.AuthMethod("", "file:///tmp/repo"); err != nil || auth != nil {
+		t.Errorf("expected no credential for a file remote, got %#v, %v", auth, err)
+	}
+	if _, err := store.AuthMethod(token.ID, "ssh://git@example.com/repo.git"); !errors.Is(err, ErrUnsuitable) || !strings.Contains(err.Error(), "cannot be used with ssh remotes") {
+		t.Errorf("expected a protocol mismatch, got %v", err)
+	}
+	if _, err := store.AuthMethod("missing", "https://example.com/repo.git"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestStore_CommandEnvScopesTokenToHost(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+
+	store, err := Open(t.TempDir(), "master")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	cred, err := store.Add(models.CredentialRequest{Name: "token", Kind: KindHTTPSToken, Host: "example.com", Username: "me", Token: "t"})
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	env, cleanup, err := store.CommandEnv(cred.ID, "https://example.com:8443/repo.git")
+	if err != nil {
+		t.Fatalf("command env: %v", err)
+	}
+	defer cleanup()
+
+	header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("me:t"))
+	want := []string{
+		"GIT_CONFIG_COUNT=2",
+		"GIT_CONFIG_KEY_1=http.https://example.com:8443/.extraHeader",
+		"GIT_CONFIG_VALUE_1=" + header,
+	}
+	if strings.Join(env, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got %q", want, env)
+	}
+}
@@ -0,0 +1,262 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitweb/server/internal/models"
+)
+
+// blameIgnoreRevsFile is the conventional file listing revisions, such as
+// mass reformatting commits, that blame should look through.
+const blameIgnoreRevsFile = ".git-blame-ignore-revs"
+
+// maxBlameHighlightLines is the file length above which blame lines are sent
+// as plain text instead of being syntax highlighted.
+const maxBlameHighlightLines = 5000
+
+// BlameOptions controls how Blame attributes lines.
+type BlameOptions struct {
+	Rev              string // commit-ish to blame at; HEAD when empty
+	IgnoreWhitespace bool   // look through commits that only changed whitespace
+	IgnoreRevs       bool   // look through the revisions in .git-blame-ignore-revs
+}
+
+// blameCommit holds the commit headers porcelain output gives once per commit.
+type blameCommit struct {
+	author   models.Author
+	date     time.Time
+	summary  string
+	filename string
+	boundary bool
+}
+
+// Blame attributes every line of a file at a revision to the commit that last
+// changed it, grouping consecutive lines of the same commit into runs. Lines
+// are syntax highlighted like tokenized diffs.
+func (s *Service) Blame(repoPath, filePath string, opts BlameOptions) (*models.Blame, error) {
+	hash, err := s.resolveCommit(repoPath, opts.Rev)
+	if err != nil {
+		return nil, err
+	}
+	rev := strings.TrimSpace(opts.Rev)
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	content, err := s.runGitCommand(repoPath, "cat-file", "blob", hash+":"+filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w at %s: %s", ErrFileNotFound, rev, filePath)
+	}
+	if isBinaryContent([]byte(content)) {
+		return nil, fmt.Errorf("cannot blame %w: %s", ErrBinaryFile, filePath)
+	}
+
+	args := []string{"blame", "--porcelain"}
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	// An empty file name clears any blame.ignoreRevsFile from the config, so
+	// the option decides on its own.
+	ignoreFile := ""
+	if opts.IgnoreRevs {
+		file, cleanup, err := s.ignoreRevsFile(repoPath, hash)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		ignoreFile = file
+	}
+	args = append(args, "--ignore-revs-file="+ignoreFile, hash, "--", filePath)
+
+	output, err := s.runGitCommand(repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	blame, lines, err := parseBlamePorcelain(output)
+	if err != nil {
+		return nil, err
+	}
+	blame.Path = filePath
+	blame.Rev = hash
+
+	var tokens [][]models.Token
+	if len(lines) <= maxBlameHighlightLines {
+		tokens = tokenizeFullSource(lexerForFile(filePath), lines)
+	}
+	i := 0
+	for r := range blame.Runs {
+		if blame.Runs[r].OriginalPath == filePath {
+			blame.Runs[r].OriginalPath = ""
+		}
+		for l := range blame.Runs[r].Lines {
+			if tokens != nil {
+				blame.Runs[r].Lines[l].Tokens = tokens[i]
+			} else {
+				blame.Runs[r].Lines[l].Tokens = []models.Token{{Text: lines[i], Color: defaultColor}}
+			}
+			i++
+		}
+	}
+
+	return blame, nil
+}
+
+// ignoreRevsFile returns the .git-blame-ignore-revs to pass to blame: the
+// worktree's, as git itself would use, or else the one committed at rev, which
+// is written to a temporary file. It returns "" when there is none.
+func (s *Service) ignoreRevsFile(repoPath, rev string) (string, func(), error) {
+	cleanup := func() {}
+
+	path := filepath.Join(repoPath, blameIgnoreRevsFile)
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		return path, cleanup, nil
+	}
+
+	content, err := s.runGitCommand(repoPath, "show", rev+":"+blameIgnoreRevsFile)
+	if err != nil {
+		return "", cleanup, nil
+	}
+	file, err := os.CreateTemp("", "gitty-ignore-revs-*")
+	if err != nil {
+		return "", cleanup, fmt.Errorf("failed to write ignore revs file: %w", err)
+	}
+	defer file.Close()
+	cleanup = func() { os.Remove(file.Name()) }
+	if _, err := file.WriteString(content); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("failed to write ignore revs file: %w", err)
+	}
+	return file.Name(), cleanup, nil
+}
+
+// parseBlamePorcelain turns git blame --porcelain output into runs, without
+// tokens, and returns the file's lines in order.
+func parseBlamePorcelain(output string) (*models.Blame, []string, error) {
+	blame := &models.Blame{Runs: []models.BlameRun{}}
+	commits := make(map[string]*blameCommit)
+	var lines []string
+
+	var current *blameCommit
+	var hash string
+	var origNum, finalNum int
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			if current == nil {
+				return nil, nil, fmt.Errorf("failed to parse blame output")
+			}
+			content := strings.TrimSuffix(line[1:], "\r")
+			lines = append(lines, content)
+			appendBlameLine(blame, hash, current, models.BlameLine{Number: finalNum, OriginalNumber: origNum})
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// A line group starts with "<hash> <orig line> <final line> [<count>]"
+		if len(fields) >= 3 && isHexHash(fields[0]) {
+			orig, err1 := strconv.Atoi(fields[1])
+			final, err2 := strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil {
+				return nil, nil, fmt.Errorf("failed to parse blame output")
+			}
+			hash, origNum, finalNum = fields[0], orig, final
+			current = commits[hash]
+			if current == nil {
+				current = &blameCommit{}
+				commits[hash] = current
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.author.Name = value
+		case "author-mail":
+			current.author.Email = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "author-time":
+			if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.date = time.Unix(sec, 0)
+			}
+		case "author-tz":
+			if loc := parseTZ(value); loc != nil {
+				current.date = current.date.In(loc)
+			}
+		case "summary":
+			current.summary = value
+		case "filename":
+			current.filename = value
+		case "boundary":
+			current.boundary = true
+		}
+	}
+
+	blame.TotalLines = len(lines)
+	return blame, lines, nil
+}
+
+// appendBlameLine adds a line to the last run when it belongs to the same
+// commit, or starts a new run.
+func appendBlameLine(blame *models.Blame, hash string, commit *blameCommit, line models.BlameLine) {
+	if n := len(blame.Runs); n > 0 {
+		last := &blame.Runs[n-1]
+		if last.Hash == hash && last.OriginalPath == commit.filename && last.EndLine == line.Number-1 {
+			last.Lines = append(last.Lines, line)
+			last.EndLine = line.Number
+			return
+		}
+	}
+	blame.Runs = append(blame.Runs, models.BlameRun{
+		Hash:         hash,
+		Author:       commit.author,
+		Date:         commit.date,
+		Summary:      commit.summary,
+		OriginalPath: commit.filename,
+		Boundary:     commit.boundary,
+		StartLine:    line.Number,
+		EndLine:      line.Number,
+		Lines:        []models.BlameLine{line},
+	})
+}
+
+// parseTZ parses a git timezone offset such as "+0200".
+func parseTZ(tz string) *time.Location {
+	if len(tz) != 5 || (tz[0] != '+' && tz[0] != '-') {
+		return nil
+	}
+	hours, err1 := strconv.Atoi(tz[1:3])
+	minutes, err2 := strconv.Atoi(tz[3:5])
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone(tz, offset)
+}
+
+func isHexHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
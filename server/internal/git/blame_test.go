@@ -0,0 +1,135 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBlame_RunsAndOriginalLines(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "main.go", "package main\n\nfunc main() {\n}\n", "Add main")
+	first := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+	commitFile(t, dir, "main.go", "// Command main\npackage main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n", "Say hi")
+	second := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	blame, err := service.Blame(dir, "main.go", BlameOptions{})
+	if err != nil {
+		t.Fatalf("Blame failed: %v", err)
+	}
+	if blame.Rev != second || blame.TotalLines != 6 {
+		t.Fatalf("unexpected blame: rev %s, %d lines", blame.Rev, blame.TotalLines)
+	}
+
+	want := []struct {
+		hash       string
+		start, end int
+	}{
+		{second, 1, 1},
+		{first, 2, 4},
+		{second, 5, 5},
+		{first, 6, 6},
+	}
+	if len(blame.Runs) != len(want) {
+		t.Fatalf("expected %d runs, got %+v", len(want), blame.Runs)
+	}
+	for i, w := range want {
+		run := blame.Runs[i]
+		if run.Hash != w.hash || run.StartLine != w.start || run.EndLine != w.end || len(run.Lines) != w.end-w.start+1 {
+			t.Errorf("run %d: expected %s lines %d-%d, got %s lines %d-%d", i, w.hash[:7], w.start, w.end, run.Hash[:7], run.StartLine, run.EndLine)
+		}
+	}
+
+	run := blame.Runs[1]
+	if run.Author.Name != "Test User" || run.Author.Email != "test@example.com" || run.Summary != "Add main" || run.Date.IsZero() {
+		t.Errorf("unexpected commit details: %+v", run)
+	}
+	if run.Lines[0].Number != 2 || run.Lines[0].OriginalNumber != 1 {
+		t.Errorf("expected line 2 to come from line 1, got %+v", run.Lines[0])
+	}
+	if len(run.Lines[0].Tokens) < 2 || run.Lines[0].Tokens[0].Text != "package" {
+		t.Errorf("expected highlighted tokens, got %+v", run.Lines[0].Tokens)
+	}
+
+	older, err := service.Blame(dir, "main.go", BlameOptions{Rev: "HEAD~1"})
+	if err != nil {
+		t.Fatalf("Blame at HEAD~1 failed: %v", err)
+	}
+	if older.Rev != first || older.TotalLines != 4 || len(older.Runs) != 1 {
+		t.Errorf("expected one run of 4 lines at HEAD~1, got %+v", older)
+	}
+}
+
+func TestBlame_IgnoreWhitespaceAndRevs(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "app.py", "def run():\n  return 1\n", "Add app")
+	first := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+	commitFile(t, dir, "app.py", "def run():\n    return 1\n", "Reindent")
+	commitFile(t, dir, "app.py", "def run() :\n    return 1\n", "Reformat")
+	reformat := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	blame, err := service.Blame(dir, "app.py", BlameOptions{})
+	if err != nil {
+		t.Fatalf("Blame failed: %v", err)
+	}
+	if len(blame.Runs) != 2 || blame.Runs[0].Summary != "Reformat" || blame.Runs[1].Summary != "Reindent" {
+		t.Fatalf("expected the reformat and reindent commits, got %+v", blame.Runs)
+	}
+
+	blame, err = service.Blame(dir, "app.py", BlameOptions{IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("Blame ignoring whitespace failed: %v", err)
+	}
+	if blame.Runs[len(blame.Runs)-1].Hash != first {
+		t.Errorf("expected the reindented line to be blamed on %s, got %+v", first[:7], blame.Runs)
+	}
+
+	// Without a .git-blame-ignore-revs the option changes nothing
+	blame, err = service.Blame(dir, "app.py", BlameOptions{IgnoreRevs: true})
+	if err != nil {
+		t.Fatalf("Blame without ignore file failed: %v", err)
+	}
+	if blame.Runs[0].Hash != reformat {
+		t.Errorf("expected the reformat commit, got %+v", blame.Runs[0])
+	}
+
+	commitFile(t, dir, ".git-blame-ignore-revs", "# formatting\n"+reformat+"\n", "Ignore reformat")
+	blame, err = service.Blame(dir, "app.py", BlameOptions{IgnoreRevs: true, IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("Blame ignoring revs failed: %v", err)
+	}
+	if len(blame.Runs) != 1 || blame.Runs[0].Hash != first {
+		t.Errorf("expected every line to be blamed on %s, got %+v", first[:7], blame.Runs)
+	}
+	blame, err = service.Blame(dir, "app.py", BlameOptions{})
+	if err != nil {
+		t.Fatalf("Blame failed: %v", err)
+	}
+	if blame.Runs[0].Hash != reformat {
+		t.Errorf("expected revs to be ignored only when asked, got %+v", blame.Runs[0])
+	}
+}
+
+func TestBlame_Errors(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "image.bin", "\x00\x01\x02", "Add binary")
+
+	tests := []struct {
+		path string
+		opts BlameOptions
+		want error
+	}{
+		{"missing.txt", BlameOptions{}, ErrFileNotFound},
+		{"README.md", BlameOptions{Rev: "nope"}, ErrRevisionNotFound},
+		{"README.md", BlameOptions{Rev: "--output=x"}, ErrInvalidRevision},
+		{"image.bin", BlameOptions{}, ErrBinaryFile},
+	}
+	for _, tt := range tests {
+		if _, err := service.Blame(dir, tt.path, tt.opts); !errors.Is(err, tt.want) {
+			t.Errorf("Blame(%s, %+v) = %v, want %v", tt.path, tt.opts, err, tt.want)
+		}
+	}
+}
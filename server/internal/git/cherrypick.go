@@ -0,0 +1,228 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Cherry-pick and revert result statuses.
+const (
+	ApplyStatusCompleted  = "completed"
+	ApplyStatusApplied    = "applied" // no-commit mode: changes are staged but not committed
+	ApplyStatusConflicted = "conflicted"
+)
+
+// CherryPick applies the changes introduced by each commit, in order, on top of HEAD.
+func (s *Service) CherryPick(repoPath string, req models.ApplyCommitsRequest) (*models.ApplyCommitsResult, error) {
+	return s.applyCommits(repoPath, "cherry-pick", req)
+}
+
+// Revert records new commits that undo the changes introduced by each commit, in order.
+func (s *Service) Revert(repoPath string, req models.ApplyCommitsRequest) (*models.ApplyCommitsResult, error) {
+	return s.applyCommits(repoPath, "revert", req)
+}
+
+// applyCommits runs git cherry-pick or git revert. When a commit conflicts the
+// operation stops with the repository in the cherry-picking or reverting state
+// and the conflicted paths are reported instead of an error. In no-commit mode
+// a conflict is rolled back and returned as an error, since git keeps no state
+// to continue or abort it from.
+func (s *Service) applyCommits(repoPath, command string, req models.ApplyCommitsRequest) (*models.ApplyCommitsResult, error) {
+	if op := inProgressOperation(repoPath); op != "" {
+		return nil, fmt.Errorf("%s %w", op, ErrOperationInProgress)
+	}
+	if len(req.Commits) == 0 {
+		return nil, ErrNoCommits
+	}
+	if req.Mainline < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidMainline, req.Mainline)
+	}
+
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	args := []string{command}
+	if req.NoCommit {
+		args = append(args, "--no-commit")
+	} else if command == "revert" {
+		args = append(args, "--no-edit")
+	}
+	if req.Mainline > 0 {
+		args = append(args, "--mainline", strconv.Itoa(req.Mainline))
+	}
+
+	for _, commit := range req.Commits {
+		hash, err := repo.ResolveRevision(plumbing.Revision(commit))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCommitNotFound, commit)
+		}
+		if _, err := repo.CommitObject(*hash); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCommitNotFound, commit)
+		}
+		args = append(args, hash.String())
+	}
+
+	// In no-commit mode git records no CHERRY_PICK_HEAD or REVERT_HEAD to
+	// continue or abort from, so remember the index to roll back to instead.
+	var indexTree string
+	if req.NoCommit {
+		output, err := s.runGitCommand(repoPath, "write-tree")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index: %w", err)
+		}
+		indexTree = strings.TrimSpace(output)
+	}
+
+	if _, err := s.runGitCommand(repoPath, args...); err != nil {
+		conflicts, conflictErr := s.GetConflicts(repoPath)
+		if conflictErr == nil && len(conflicts) > 0 && req.NoCommit {
+			return nil, s.rollBackNoCommit(repoPath, command, indexTree, conflicts)
+		}
+		if conflictErr == nil && len(conflicts) > 0 {
+			return &models.ApplyCommitsResult{
+				Status:    ApplyStatusConflicted,
+				Conflicts: conflicts,
+			}, nil
+		}
+		// A failure that is not a conflict (for example a merge commit without a
+		// mainline) can leave a half-applied sequence behind; roll it back.
+		if _, statErr := os.Stat(filepath.Join(gitDir(repoPath), "sequencer")); statErr == nil {
+			s.runGitCommand(repoPath, command, "--abort")
+		}
+		return nil, fmt.Errorf("failed to %s: %w", command, applyCommitsError(err))
+	}
+
+	result := &models.ApplyCommitsResult{
+		Status:    ApplyStatusCompleted,
+		Conflicts: []models.ConflictFile{},
+	}
+	if req.NoCommit {
+		// git keeps CHERRY_PICK_HEAD/REVERT_HEAD around even in no-commit mode;
+		// drop that state so the staged changes can be committed normally.
+		if _, err := s.runGitCommand(repoPath, command, "--quit"); err != nil {
+			return nil, fmt.Errorf("failed to finish %s: %w", command, err)
+		}
+		result.Status = ApplyStatusApplied
+		return result, nil
+	}
+
+	if result.Hash, err = s.headHash(repoPath); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// rollBackNoCommit undoes a no-commit cherry-pick or revert that stopped on
+// conflicts. Only the paths it touched are restored from indexTree, so changes
+// that were staged or modified beforehand are kept.
+func (s *Service) rollBackNoCommit(repoPath, command, indexTree string, conflicts []models.ConflictFile) error {
+	touched, err := s.runGitCommand(repoPath, "diff-index", "--cached", "--name-only", "-z", indexTree)
+	if err != nil {
+		return fmt.Errorf("failed to roll back %s: %w", command, err)
+	}
+	if touched != "" {
+		if _, err := s.runGitCommandWithInput(repoPath, touched, "restore", "--source="+indexTree, "--staged", "--worktree",
+			"--pathspec-from-file=-", "--pathspec-file-nul"); err != nil {
+			return fmt.Errorf("failed to roll back %s: %w", command, err)
+		}
+	}
+	if _, err := s.runGitCommand(repoPath, command, "--quit"); err != nil {
+		return fmt.Errorf("failed to roll back %s: %w", command, err)
+	}
+
+	paths := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		paths[i] = conflict.Path
+	}
+	return fmt.Errorf("%s stopped on conflicts in %s; %w", command, strings.Join(paths, ", "), ErrNothingApplied)
+}
+
+// applyCommitsError classifies a failed git cherry-pick or revert by the
+// reason git gave for refusing the commits.
+func applyCommitsError(err error) error {
+	msg := err.Error()
+	if strings.Contains(msg, "is a merge but no -m option") || strings.Contains(msg, "mainline was specified") {
+		return fmt.Errorf("%w: %w", ErrInvalidMainline, err)
+	}
+	return withLocalChanges(err)
+}
+
+// ContinueCherryPick resumes a cherry-pick that stopped on conflicts.
+func (s *Service) ContinueCherryPick(repoPath string) (*models.ApplyCommitsResult, error) {
+	return s.continueSequence(repoPath, OperationCherryPick)
+}
+
+// ContinueRevert resumes a revert that stopped on conflicts.
+func (s *Service) ContinueRevert(repoPath string) (*models.ApplyCommitsResult, error) {
+	return s.continueSequence(repoPath, OperationRevert)
+}
+
+func (s *Service) continueSequence(repoPath, operation string) (*models.ApplyCommitsResult, error) {
+	if inProgressOperation(repoPath) != operation {
+		return nil, fmt.Errorf("%s %w", operation, ErrNoOperation)
+	}
+
+	conflicts, err := s.GetConflicts(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) == 0 {
+		_, runErr := s.runGitCommand(repoPath, operation, "--continue")
+		if runErr == nil {
+			hash, err := s.headHash(repoPath)
+			if err != nil {
+				return nil, err
+			}
+			return &models.ApplyCommitsResult{
+				Status:    ApplyStatusCompleted,
+				Hash:      hash,
+				Conflicts: []models.ConflictFile{},
+			}, nil
+		}
+
+		// The next commit in the sequence may conflict in turn.
+		if conflicts, err = s.GetConflicts(repoPath); err != nil {
+			return nil, err
+		}
+		if len(conflicts) == 0 {
+			return nil, fmt.Errorf("failed to continue %s: %w", operation, runErr)
+		}
+	}
+
+	return &models.ApplyCommitsResult{
+		Status:    ApplyStatusConflicted,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// AbortCherryPick cancels an in-progress cherry-pick and restores the original HEAD.
+func (s *Service) AbortCherryPick(repoPath string) error {
+	return s.abortSequence(repoPath, OperationCherryPick)
+}
+
+// AbortRevert cancels an in-progress revert and restores the original HEAD.
+func (s *Service) AbortRevert(repoPath string) error {
+	return s.abortSequence(repoPath, OperationRevert)
+}
+
+func (s *Service) abortSequence(repoPath, operation string) error {
+	if inProgressOperation(repoPath) != operation {
+		return fmt.Errorf("%s %w", operation, ErrNoOperation)
+	}
+
+	if _, err := s.runGitCommand(repoPath, operation, "--abort"); err != nil {
+		return fmt.Errorf("failed to abort %s: %w", operation, err)
+	}
+
+	return nil
+}
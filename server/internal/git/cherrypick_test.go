@@ -0,0 +1,204 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestCherryPick_MultipleCommits(t *testing.T) {
+	service, dir, hashes := newRebaseRepo(t)
+	runGit(t, dir, "checkout", "main")
+
+	result, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{hashes[2], hashes[0]}})
+	if err != nil {
+		t.Fatalf("CherryPick failed: %v", err)
+	}
+	if result.Status != ApplyStatusCompleted || result.Hash == "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if subjects := logSubjects(t, dir, "HEAD~2..HEAD"); strings.Join(subjects, "|") != "Add c|Add a" {
+		t.Fatalf("unexpected commits: %v", subjects)
+	}
+}
+
+func TestCherryPick_NoCommit(t *testing.T) {
+	service, dir, hashes := newRebaseRepo(t)
+	runGit(t, dir, "checkout", "main")
+	before, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{hashes[1]}, NoCommit: true})
+	if err != nil {
+		t.Fatalf("CherryPick failed: %v", err)
+	}
+	if result.Status != ApplyStatusApplied {
+		t.Fatalf("expected applied status, got %+v", result)
+	}
+	if after, _ := service.headHash(dir); after != before {
+		t.Fatal("expected no-commit mode to leave HEAD unchanged")
+	}
+	if staged := runGitOutput(t, dir, "diff", "--cached", "--name-only"); strings.TrimSpace(staged) != "b.txt" {
+		t.Fatalf("expected b.txt staged, got %q", staged)
+	}
+	if op := inProgressOperation(dir); op != "" {
+		t.Fatalf("expected no operation in progress after no-commit cherry-pick, got %q", op)
+	}
+}
+
+func TestCherryPick_NoCommitConflictRollsBack(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "README.md", "# Feature\n", "Edit on feature")
+	commitFile(t, dir, "feature.txt", "feature\n", "Add feature")
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "README.md", "# Main\n", "Edit on main")
+	commitFile(t, dir, "notes.txt", "notes\n", "Add notes")
+	writeTestFile(t, dir, "staged.txt", "staged\n")
+	runGit(t, dir, "add", "staged.txt")
+	writeTestFile(t, dir, "notes.txt", "unstaged\n")
+
+	_, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{"feature~1", "feature"}, NoCommit: true})
+	if !errors.Is(err, ErrNothingApplied) || !strings.Contains(err.Error(), "README.md") {
+		t.Fatalf("expected a conflict error naming README.md, got %v", err)
+	}
+
+	if op := inProgressOperation(dir); op != "" {
+		t.Fatalf("expected no operation in progress after rollback, got %q", op)
+	}
+	if status := runGitOutput(t, dir, "status", "--porcelain"); status != " M notes.txt\nA  staged.txt\n" {
+		t.Fatalf("expected only the earlier changes to remain, got %q", status)
+	}
+	if content := readTestFile(t, dir, "README.md"); content != "# Main\n" {
+		t.Fatalf("expected README.md to be restored, got %q", content)
+	}
+}
+
+func TestCherryPick_MergeCommitNeedsMainline(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "feature.txt", "feature\n", "Add feature")
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "main.txt", "main\n", "Add main")
+	runGit(t, dir, "merge", "--no-ff", "--no-edit", "feature")
+	mergeHash, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "checkout", "-b", "target", "HEAD~1")
+
+	if _, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{mergeHash}}); !errors.Is(err, ErrInvalidMainline) {
+		t.Fatalf("expected cherry-pick of a merge commit without mainline to fail, got %v", err)
+	}
+
+	result, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{mergeHash}, Mainline: 1})
+	if err != nil {
+		t.Fatalf("CherryPick with mainline failed: %v", err)
+	}
+	if result.Status != ApplyStatusCompleted {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature.txt")); err != nil {
+		t.Fatalf("expected feature.txt after picking merge against mainline 1: %v", err)
+	}
+}
+
+func TestCherryPick_FailureRollsBackSequence(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "feature.txt", "feature\n", "Add feature")
+	featureHash, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "checkout", "main")
+	runGit(t, dir, "merge", "--no-ff", "--no-edit", "feature")
+	mergeHash, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "checkout", "-b", "target", "HEAD~1")
+	before, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{featureHash, mergeHash}}); err == nil {
+		t.Fatal("expected cherry-pick of a merge commit without mainline to fail")
+	}
+	if after, _ := service.headHash(dir); after != before {
+		t.Fatal("expected failed sequence to restore the original HEAD")
+	}
+	if _, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{featureHash}}); err != nil {
+		t.Fatalf("expected a new cherry-pick to succeed after rollback: %v", err)
+	}
+}
+
+func TestRevert_ConflictContinueAndAbort(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "README.md", "# One\n", "Edit one")
+	target, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, "README.md", "# Two\n", "Edit two")
+
+	result, err := service.Revert(dir, models.ApplyCommitsRequest{Commits: []string{target}})
+	if err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if result.Status != ApplyStatusConflicted || len(result.Conflicts) != 1 || result.Conflicts[0].Type != ConflictBothModified {
+		t.Fatalf("expected conflicted revert, got %+v", result)
+	}
+
+	status, err := service.GetRepositoryStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Operation != OperationRevert {
+		t.Fatalf("expected status to report revert in progress, got %q", status.Operation)
+	}
+
+	if err := service.AbortRevert(dir); err != nil {
+		t.Fatalf("AbortRevert failed: %v", err)
+	}
+
+	if _, err := service.Revert(dir, models.ApplyCommitsRequest{Commits: []string{target}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ResolveConflict(dir, "README.md", models.ResolveConflictRequest{Resolution: ResolveManual, Content: "# Test\n"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = service.ContinueRevert(dir)
+	if err != nil {
+		t.Fatalf("ContinueRevert failed: %v", err)
+	}
+	if result.Status != ApplyStatusCompleted {
+		t.Fatalf("expected completed revert, got %+v", result)
+	}
+	if subject := strings.TrimSpace(runGitOutput(t, dir, "log", "-1", "--format=%s")); !strings.HasPrefix(subject, "Revert") {
+		t.Fatalf("expected revert commit, got %q", subject)
+	}
+}
+
+func TestCherryPick_InvalidRequests(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	if _, err := service.CherryPick(dir, models.ApplyCommitsRequest{}); !errors.Is(err, ErrNoCommits) {
+		t.Fatalf("expected error for empty commit list, got %v", err)
+	}
+	if _, err := service.CherryPick(dir, models.ApplyCommitsRequest{Commits: []string{"nope"}}); !errors.Is(err, ErrCommitNotFound) {
+		t.Fatalf("expected commit not found error, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// ValidateCloneOptions rejects clone options that contradict each other or
+// could be mistaken for command line flags.
+func ValidateCloneOptions(opts models.CloneOptions) error {
+	switch {
+	case opts.Depth < 0:
+		return fmt.Errorf("invalid clone options: depth must not be negative")
+	case strings.HasPrefix(strings.TrimSpace(opts.ShallowSince), "-"):
+		return fmt.Errorf("invalid clone options: invalid shallow_since %q", opts.ShallowSince)
+	case strings.HasPrefix(strings.TrimSpace(opts.Ref), "-"):
+		return fmt.Errorf("invalid clone options: invalid ref %q", opts.Ref)
+	case opts.Mirror && opts.SingleBranch:
+		return fmt.Errorf("invalid clone options: a mirror clones every branch")
+	case (opts.Bare || opts.Mirror) && opts.RecurseSubmodules:
+		return fmt.Errorf("invalid clone options: submodules need a worktree")
+	}
+	return nil
+}
+
+// CloneContext clones url into path with the git CLI, which supports the
+// shallow, mirror and submodule options that go-git lacks. git's progress
+// output is written to progress when it is not nil. A clone that fails or is
+// cancelled removes the directory it created, so no partial repository is
+// left behind. opts.Credential, when set, authenticates the clone and stays
+// chosen for origin.
+func (s *Service) CloneContext(ctx context.Context, url, path string, opts models.CloneOptions, progress io.Writer) (*git.Repository, error) {
+	if err := ValidateCloneOptions(opts); err != nil {
+		return nil, err
+	}
+
+	_, statErr := os.Stat(path)
+	created := os.IsNotExist(statErr)
+
+	args := []string{"clone", "--progress"}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	if since := strings.TrimSpace(opts.ShallowSince); since != "" {
+		args = append(args, "--shallow-since="+since)
+	}
+	if opts.SingleBranch {
+		args = append(args, "--single-branch")
+	} else if opts.Depth > 0 || strings.TrimSpace(opts.ShallowSince) != "" {
+		// Shallow clones imply --single-branch; keep every branch unless
+		// asked otherwise.
+		args = append(args, "--no-single-branch")
+	}
+	if ref := strings.TrimSpace(opts.Ref); ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	if opts.RecurseSubmodules {
+		args = append(args, "--recurse-submodules")
+		if opts.Depth > 0 {
+			args = append(args, "--shallow-submodules")
+		}
+	}
+	switch {
+	case opts.Mirror:
+		args = append(args, "--mirror")
+	case opts.Bare:
+		args = append(args, "--bare")
+	}
+	args = append(args, "--", url, path)
+
+	var stderr bytes.Buffer
+	output := io.Writer(&stderr)
+	if progress != nil {
+		output = io.MultiWriter(&stderr, progress)
+	}
+
+	var env []string
+	if s.credentials != nil {
+		var cleanup func()
+		var err error
+		env, cleanup, err = s.credentials.CommandEnv(opts.Credential, url)
+		defer cleanup()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := gitCommand(ctx, "", env, args...)
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		if created {
+			os.RemoveAll(path)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := cloneErrorMessage(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git clone failed: %s", msg)
+		}
+		return nil, fmt.Errorf("git clone failed: %w", err)
+	}
+
+	if opts.Credential != "" {
+		if err := s.SetRemoteCredential(path, "origin", opts.Credential); err != nil {
+			if created {
+				os.RemoveAll(path)
+			}
+			return nil, err
+		}
+	}
+
+	return git.PlainOpen(path)
+}
+
+// cloneErrorMessage picks the error out of clone's stderr, which also carries
+// its progress output.
+func cloneErrorMessage(stderr string) string {
+	var messages []string
+	last := ""
+	for _, line := range strings.FieldsFunc(stderr, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		last = line
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
+			messages = append(messages, line)
+		}
+	}
+	if len(messages) > 0 {
+		return strings.Join(messages, "\n")
+	}
+	return last
+}
@@ -0,0 +1,154 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+// newCloneSource creates a repository with three commits on main and a
+// feature branch, and returns a file:// URL for it so that shallow options
+// are honoured.
+func newCloneSource(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "a.txt", "a\n", "Add a")
+	commitFile(t, dir, "b.txt", "b\n", "Add b")
+	runGit(t, dir, "branch", "feature", "HEAD~1")
+	runGit(t, dir, "tag", "v1", "HEAD~2")
+
+	return dir, "file://" + filepath.ToSlash(dir)
+}
+
+func TestCloneContext_ShallowWithProgress(t *testing.T) {
+	service := NewService()
+	_, url := newCloneSource(t)
+	path := filepath.Join(t.TempDir(), "clone")
+
+	var progress bytes.Buffer
+	if _, err := service.CloneContext(context.Background(), url, path, models.CloneOptions{Depth: 1}, &progress); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := strings.TrimSpace(runGitOutput(t, path, "rev-list", "--count", "HEAD")); count != "1" {
+		t.Errorf("expected 1 commit in a depth 1 clone, got %s", count)
+	}
+	if !refExists(path, "refs/remotes/origin/feature") {
+		t.Error("expected a shallow clone to keep every branch unless single_branch is set")
+	}
+	if !strings.Contains(progress.String(), "objects") {
+		t.Errorf("expected git progress output, got %q", progress.String())
+	}
+}
+
+func TestCloneContext_SingleBranchAtRef(t *testing.T) {
+	service := NewService()
+	source, url := newCloneSource(t)
+	path := filepath.Join(t.TempDir(), "clone")
+
+	opts := models.CloneOptions{Ref: "feature", SingleBranch: true}
+	if _, err := service.CloneContext(context.Background(), url, path, opts, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if branch := strings.TrimSpace(runGitOutput(t, path, "branch", "--show-current")); branch != "feature" {
+		t.Errorf("expected feature to be checked out, got %q", branch)
+	}
+	if strings.TrimSpace(runGitOutput(t, path, "rev-parse", "HEAD")) != strings.TrimSpace(runGitOutput(t, source, "rev-parse", "feature")) {
+		t.Error("expected HEAD at the feature branch")
+	}
+	if refExists(path, "refs/remotes/origin/main") {
+		t.Error("expected a single-branch clone not to fetch main")
+	}
+}
+
+func TestCloneContext_Mirror(t *testing.T) {
+	service := NewService()
+	_, url := newCloneSource(t)
+	path := filepath.Join(t.TempDir(), "mirror.git")
+
+	if _, err := service.CloneContext(context.Background(), url, path, models.CloneOptions{Mirror: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if bare := strings.TrimSpace(runGitOutput(t, path, "rev-parse", "--is-bare-repository")); bare != "true" {
+		t.Errorf("expected a bare repository, got %q", bare)
+	}
+	for _, ref := range []string{"refs/heads/main", "refs/heads/feature", "refs/tags/v1"} {
+		if !refExists(path, ref) {
+			t.Errorf("expected mirror to have %s", ref)
+		}
+	}
+}
+
+func TestCloneContext_RecurseSubmodules(t *testing.T) {
+	// File URLs are not allowed for submodules by default
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	service := NewService()
+	_, subURL := newCloneSource(t)
+	dir, url := newCloneSource(t)
+	runGit(t, dir, "submodule", "add", subURL, "lib")
+	runGit(t, dir, "commit", "-m", "Add submodule")
+
+	path := filepath.Join(t.TempDir(), "clone")
+	if _, err := service.CloneContext(context.Background(), url, path, models.CloneOptions{RecurseSubmodules: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "lib", "a.txt")); err != nil {
+		t.Errorf("expected the submodule to be checked out: %v", err)
+	}
+}
+
+func TestCloneContext_FailureRemovesDirectory(t *testing.T) {
+	service := NewService()
+	path := filepath.Join(t.TempDir(), "clone")
+
+	_, err := service.CloneContext(context.Background(), "file://"+filepath.ToSlash(t.TempDir())+"/missing", path, models.CloneOptions{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "git clone failed: fatal:") {
+		t.Fatalf("expected the fatal clone error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the partial clone to be removed, got %v", err)
+	}
+
+	_, url := newCloneSource(t)
+	_, err = service.CloneContext(context.Background(), url, path, models.CloneOptions{Ref: "missing"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected an unknown ref error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the partial clone to be removed, got %v", err)
+	}
+}
+
+func TestValidateCloneOptions(t *testing.T) {
+	tests := []struct {
+		opts  models.CloneOptions
+		valid bool
+	}{
+		{models.CloneOptions{}, true},
+		{models.CloneOptions{Depth: 1, SingleBranch: true, Ref: "v1"}, true},
+		{models.CloneOptions{ShallowSince: "2024-01-01", RecurseSubmodules: true}, true},
+		{models.CloneOptions{Mirror: true, Depth: 5}, true},
+		{models.CloneOptions{Depth: -1}, false},
+		{models.CloneOptions{Ref: "--upload-pack=evil"}, false},
+		{models.CloneOptions{ShallowSince: "-1"}, false},
+		{models.CloneOptions{Mirror: true, SingleBranch: true}, false},
+		{models.CloneOptions{Bare: true, RecurseSubmodules: true}, false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateCloneOptions(tt.opts); (err == nil) != tt.valid {
+			t.Errorf("ValidateCloneOptions(%+v) = %v, want valid %v", tt.opts, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runGitCommand executes a git subcommand inside repoPath and returns its stdout.
+// Operations that go-git does not implement (merge, rebase, stash, ...) go through
+// the git CLI. The environment disables interactive prompts and editors so a
+// request can never block waiting for terminal input.
+func (s *Service) runGitCommand(repoPath string, args ...string) (string, error) {
+	return s.runGitCommandWithEnv(repoPath, nil, args...)
+}
+
+// runGitCommandWithEnv is runGitCommand with extra environment variables, which
+// take precedence over the defaults.
+func (s *Service) runGitCommandWithEnv(repoPath string, env []string, args ...string) (string, error) {
+	return s.execGit(repoPath, env, "", args...)
+}
+
+// runGitCommandWithInput is runGitCommand with input fed to the command's stdin.
+func (s *Service) runGitCommandWithInput(repoPath, input string, args ...string) (string, error) {
+	return s.execGit(repoPath, nil, input, args...)
+}
+
+func (s *Service) execGit(repoPath string, env []string, input string, args ...string) (string, error) {
+	cmd := gitCommand(context.Background(), repoPath, env, args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg != "" {
+			return stdout.String(), fmt.Errorf("git %s failed: %s", args[0], msg)
+		}
+		return stdout.String(), fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+
+	return stdout.String(), nil
+}
+
+// gitCommand prepares a git command in dir with the non-interactive
+// environment, killed when ctx is cancelled.
+func gitCommand(ctx context.Context, dir string, env []string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_EDITOR=true",
+		"GIT_MERGE_AUTOEDIT=no",
+	)
+	cmd.Env = append(cmd.Env, env...)
+	// Helpers such as git-remote-https may outlive a killed git and hold its
+	// output pipes open.
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// gitDir returns the repository's git directory, which is not .git in a
+// linked worktree or a submodule.
+func gitDir(repoPath string) string {
+	output, err := gitCommand(context.Background(), repoPath, nil, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return filepath.Join(repoPath, ".git")
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// gitCommonDir returns the directory holding the refs and config that a linked
+// worktree shares with the main worktree; elsewhere it is the git directory.
+func gitCommonDir(repoPath string) string {
+	output, err := gitCommand(context.Background(), repoPath, nil, "rev-parse", "--path-format=absolute", "--git-common-dir").Output()
+	if err != nil {
+		return gitDir(repoPath)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ShellQuote quotes s for a command line that git runs through the shell, such
+// as GIT_SSH_COMMAND, an editor or an exec line in a rebase todo list.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// unmergedPaths lists the paths that currently have unresolved conflicts in the index.
+func (s *Service) unmergedPaths(repoPath string) ([]string, error) {
+	output, err := s.runGitCommand(repoPath, "diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
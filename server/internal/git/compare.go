@@ -0,0 +1,170 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitweb/server/internal/models"
+)
+
+// Compare modes.
+const (
+	CompareThreeDot = "three-dot"
+	CompareTwoDot   = "two-dot"
+)
+
+// maxCompareCommits caps the commits listed by Compare.
+const maxCompareCommits = 250
+
+// CompareOptions selects the refs Compare compares and the page of file
+// diffs it tokenizes.
+type CompareOptions struct {
+	Base   string      // ref the changes are relative to
+	Head   string      // ref with the changes; HEAD when empty
+	Mode   string      // CompareThreeDot (default) or CompareTwoDot
+	Cursor int         // index into the changed files of the first diff to tokenize
+	Limit  int         // file diffs per page; 20 when not positive, at most 100
+	Diff   DiffOptions // how file changes are diffed
+}
+
+// Compare returns what head changes relative to base, like a pull request:
+// the commits on head that are not on base, and the diff of every changed
+// file. Three-dot mode diffs head against its merge base with base, so
+// changes made on base meanwhile are left out; two-dot mode diffs the two
+// trees directly.
+func (s *Service) Compare(ctx context.Context, repoPath string, opts CompareOptions) (*models.Compare, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = CompareThreeDot
+	}
+	if mode != CompareThreeDot && mode != CompareTwoDot {
+		return nil, fmt.Errorf("%w: mode must be %s or %s", ErrInvalidCompareOptions, CompareThreeDot, CompareTwoDot)
+	}
+	if strings.TrimSpace(opts.Base) == "" {
+		return nil, fmt.Errorf("%w: base is required", ErrInvalidCompareOptions)
+	}
+	if err := opts.Diff.validate(); err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	cursor := opts.Cursor
+	if cursor < 0 {
+		cursor = 0
+	}
+
+	base, err := s.resolveCommit(repoPath, opts.Base)
+	if err != nil {
+		return nil, err
+	}
+	head, err := s.resolveCommit(repoPath, opts.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Compare{
+		Base:  base,
+		Head:  head,
+		Mode:  mode,
+		Files: []models.ChangedFile{},
+		Diffs: []models.TokenizedFileDiff{},
+	}
+
+	from := base
+	if mode == CompareThreeDot {
+		mergeBase, err := s.runGitCommand(repoPath, "merge-base", base, head)
+		if err != nil {
+			return nil, fmt.Errorf("%w between %s and %s", ErrNoCommonAncestor, opts.Base, opts.Head)
+		}
+		result.MergeBase = strings.TrimSpace(mergeBase)
+		from = result.MergeBase
+	}
+
+	commits, _, err := s.CommitLog(ctx, repoPath, LogOptions{Ref: base + ".." + head, Limit: maxCompareCommits})
+	if err != nil {
+		return nil, err
+	}
+	result.Commits = commits
+	count, err := s.runGitCommand(repoPath, "rev-list", "--count", base+".."+head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count commits: %w", err)
+	}
+	result.TotalCommits, _ = strconv.Atoi(strings.TrimSpace(count))
+
+	diffArgs := append([]string{"diff"}, opts.Diff.args()...)
+	output, err := s.runGitCommand(repoPath, append(diffArgs, "--raw", "--numstat", "-z", from, head)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s and %s: %w", opts.Base, opts.Head, err)
+	}
+	if files := parseChangedFiles(output); files != nil {
+		result.Files = files
+	}
+	for _, file := range result.Files {
+		result.Stats.Additions += file.Additions
+		result.Stats.Deletions += file.Deletions
+	}
+	result.Stats.FilesChanged = len(result.Files)
+
+	if cursor > len(result.Files) {
+		cursor = len(result.Files)
+	}
+	end := cursor + limit
+	if end > len(result.Files) {
+		end = len(result.Files)
+	}
+	page := result.Files[cursor:end]
+	if end < len(result.Files) {
+		result.HasMore = true
+		result.NextCursor = end
+	}
+	if len(page) == 0 {
+		return result, nil
+	}
+
+	// Diff only the files of this page, with the old paths of renames so
+	// that git still pairs them up.
+	args := append([]string{"--literal-pathspecs", "-c", "core.quotePath=false"}, diffArgs...)
+	args = append(args, from, head, "--")
+	for _, file := range page {
+		args = append(args, file.Path)
+		if file.OldPath != "" {
+			args = append(args, file.OldPath)
+		}
+	}
+	patch, err := s.runGitCommand(repoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s and %s: %w", opts.Base, opts.Head, err)
+	}
+	patches := make(map[string]string)
+	for _, file := range splitPatchByFile(patch) {
+		patches[file.path] = file.patch
+	}
+
+	for _, file := range page {
+		var tokenized *models.TokenizedDiff
+		if patch := patches[file.Path]; hasDiffHunks(patch) {
+			tokenized = s.TokenizeDiff(patch, file.Path, 0, 9999) // files are paged instead of hunks
+		} else {
+			// Binary, mode-only or pure rename
+			tokenized = &models.TokenizedDiff{
+				Filename: file.Path,
+				Hunks:    []models.DiffHunkTokenized{},
+			}
+		}
+		tokenized.OldFilename = file.OldPath
+		result.Diffs = append(result.Diffs, models.TokenizedFileDiff{
+			Path:       file.Path,
+			ChangeType: file.ChangeType,
+			Diff:       *tokenized,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// newCompareTestRepo creates main and an agent/feature branch that diverged
+// from it: the branch edits, adds, renames and adds a binary file in two
+// commits while main gains main.txt.
+func newCompareTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "lib.txt", "one\ntwo\nthree\nfour\nfive\nsix\n", "Add lib")
+	commitFile(t, dir, "old.txt", "alpha\nbeta\ngamma\ndelta\n", "Add old")
+
+	runGit(t, dir, "checkout", "-q", "-b", "agent/feature")
+	commitFile(t, dir, "lib.txt", "one\ntwo\nTHREE\nfour\nfive\nsix\n", "Shout three")
+	runGit(t, dir, "mv", "old.txt", "moved.txt")
+	commitFile(t, dir, "new.txt", "new\n", "Add new and move old")
+	commitFile(t, dir, "logo.png", "\x89PNG\x00\x01", "Add logo")
+
+	runGit(t, dir, "checkout", "-q", "main")
+	commitFile(t, dir, "main.txt", "main\n", "Work on main")
+	return dir
+}
+
+func TestCompare_ThreeDot(t *testing.T) {
+	service := NewService()
+	dir := newCompareTestRepo(t)
+
+	result, err := service.Compare(context.Background(), dir, CompareOptions{Base: "main", Head: "agent/feature"})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if result.Mode != CompareThreeDot || result.MergeBase == "" || result.TotalCommits != 3 || len(result.Commits) != 3 || result.Commits[0].Message != "Add logo" {
+		t.Fatalf("unexpected commits: %+v", result)
+	}
+
+	files := map[string]string{}
+	for _, file := range result.Files {
+		files[file.Path] = file.ChangeType + " " + file.OldPath
+	}
+	want := map[string]string{
+		"lib.txt":   "modified ",
+		"moved.txt": "renamed old.txt",
+		"new.txt":   "added ",
+		"logo.png":  "added ",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+	for path, w := range want {
+		if files[path] != w {
+			t.Errorf("%s: expected %q, got %q", path, w, files[path])
+		}
+	}
+	if result.Stats.FilesChanged != 4 || result.Stats.Additions != 2 || result.Stats.Deletions != 1 {
+		t.Errorf("unexpected stats: %+v", result.Stats)
+	}
+
+	if len(result.Diffs) != 4 || result.HasMore {
+		t.Fatalf("expected every diff on one page, got %d", len(result.Diffs))
+	}
+	for _, diff := range result.Diffs {
+		switch diff.Path {
+		case "lib.txt":
+			if diff.Diff.Additions != 1 || diff.Diff.Deletions != 1 || len(diff.Diff.Hunks) != 1 {
+				t.Errorf("unexpected lib.txt diff: %+v", diff.Diff)
+			}
+		case "moved.txt":
+			if diff.ChangeType != "renamed" || diff.Diff.OldFilename != "old.txt" || len(diff.Diff.Hunks) != 0 {
+				t.Errorf("expected a pure rename, got %+v", diff)
+			}
+		case "logo.png":
+			if len(diff.Diff.Hunks) != 0 {
+				t.Errorf("expected no hunks for a binary file, got %+v", diff.Diff)
+			}
+		}
+	}
+}
+
+func TestCompare_TwoDotAndPaging(t *testing.T) {
+	service := NewService()
+	dir := newCompareTestRepo(t)
+
+	result, err := service.Compare(context.Background(), dir, CompareOptions{Base: "main", Head: "agent/feature", Mode: CompareTwoDot, Limit: 2})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	// Two-dot also shows main's own change, as a deletion on the way to head
+	if result.MergeBase != "" || result.Stats.FilesChanged != 5 || result.TotalCommits != 3 {
+		t.Fatalf("unexpected two-dot compare: %+v", result)
+	}
+	if len(result.Diffs) != 2 || !result.HasMore || result.NextCursor != 2 || result.Diffs[0].Path != result.Files[0].Path {
+		t.Fatalf("unexpected first page: %d diffs, more %v, next %d", len(result.Diffs), result.HasMore, result.NextCursor)
+	}
+
+	var paths []string
+	for cursor := 0; ; {
+		page, err := service.Compare(context.Background(), dir, CompareOptions{Base: "main", Head: "agent/feature", Mode: CompareTwoDot, Cursor: cursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("Compare at %d failed: %v", cursor, err)
+		}
+		for _, diff := range page.Diffs {
+			paths = append(paths, diff.Path)
+			if diff.Path == "main.txt" && (diff.ChangeType != "deleted" || diff.Diff.Deletions != 1) {
+				t.Errorf("expected main.txt to be deleted, got %+v", diff)
+			}
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(paths) != 5 {
+		t.Errorf("expected 5 diffs across pages, got %v", paths)
+	}
+
+	same, err := service.Compare(context.Background(), dir, CompareOptions{Base: "main", Head: "main"})
+	if err != nil {
+		t.Fatalf("Compare of a ref with itself failed: %v", err)
+	}
+	if len(same.Commits) != 0 || len(same.Files) != 0 || len(same.Diffs) != 0 {
+		t.Errorf("expected an empty compare, got %+v", same)
+	}
+}
+
+func TestCompare_Errors(t *testing.T) {
+	service := NewService()
+	dir := newCompareTestRepo(t)
+	runGit(t, dir, "checkout", "-q", "--orphan", "unrelated")
+	commitFile(t, dir, "other.txt", "other\n", "Unrelated root")
+	runGit(t, dir, "checkout", "-q", "main")
+
+	tests := []struct {
+		opts CompareOptions
+		want error
+	}{
+		{CompareOptions{Head: "agent/feature"}, ErrInvalidCompareOptions},
+		{CompareOptions{Base: "main", Mode: "four-dot"}, ErrInvalidCompareOptions},
+		{CompareOptions{Base: "main", Head: "nope"}, ErrRevisionNotFound},
+		{CompareOptions{Base: "--all"}, ErrInvalidRevision},
+		{CompareOptions{Base: "main", Head: "unrelated"}, ErrNoCommonAncestor},
+	}
+	for _, tt := range tests {
+		if _, err := service.Compare(context.Background(), dir, tt.opts); !errors.Is(err, tt.want) {
+			t.Errorf("Compare(%+v) = %v, want %v", tt.opts, err, tt.want)
+		}
+	}
+
+	if _, err := service.Compare(context.Background(), dir, CompareOptions{Base: "main", Head: "unrelated", Mode: CompareTwoDot}); err != nil {
+		t.Errorf("expected two-dot to compare unrelated histories, got %v", err)
+	}
+}
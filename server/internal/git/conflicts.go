@@ -0,0 +1,511 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// Conflict types, named after the labels git status uses for unmerged paths.
+const (
+	ConflictBothModified  = "both-modified"
+	ConflictBothAdded     = "both-added"
+	ConflictBothDeleted   = "both-deleted"
+	ConflictAddedByUs     = "added-by-us"
+	ConflictAddedByThem   = "added-by-them"
+	ConflictDeletedByUs   = "deleted-by-us"
+	ConflictDeletedByThem = "deleted-by-them"
+)
+
+// Conflict resolutions accepted by ResolveConflict.
+const (
+	ResolveOurs   = "ours"
+	ResolveTheirs = "theirs"
+	ResolveBase   = "base"
+	ResolveManual = "manual"
+	ResolveHunks  = "hunks"
+	ResolveDelete = "delete"
+
+	// ResolveBoth keeps ours followed by theirs for a single hunk.
+	ResolveBoth = "both"
+)
+
+// In-progress operations reported in RepositoryStatus.Operation.
+const (
+	OperationMerge      = "merge"
+	OperationRebase     = "rebase"
+	OperationCherryPick = "cherry-pick"
+	OperationRevert     = "revert"
+)
+
+const (
+	markerOurs   = "<<<<<<<"
+	markerBase   = "|||||||"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// conflictStages holds the index entries for one unmerged path, keyed by stage.
+type conflictStages struct {
+	base   *index.Entry
+	ours   *index.Entry
+	theirs *index.Entry
+}
+
+func (c conflictStages) conflictType() string {
+	switch {
+	case c.base != nil && c.ours != nil && c.theirs != nil:
+		return ConflictBothModified
+	case c.base == nil && c.ours != nil && c.theirs != nil:
+		return ConflictBothAdded
+	case c.base != nil && c.ours != nil:
+		return ConflictDeletedByThem
+	case c.base != nil && c.theirs != nil:
+		return ConflictDeletedByUs
+	case c.ours != nil:
+		return ConflictAddedByUs
+	case c.theirs != nil:
+		return ConflictAddedByThem
+	default:
+		return ConflictBothDeleted
+	}
+}
+
+// readConflictStages groups the higher-stage index entries by path.
+func readConflictStages(repo *git.Repository) (map[string]*conflictStages, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index: %w", err)
+	}
+
+	stages := make(map[string]*conflictStages)
+	for _, entry := range idx.Entries {
+		// Stage 0 holds merged entries. go-git's index.Merged constant is 1, which
+		// collides with AncestorMode, so compare against zero directly.
+		if entry.Stage == 0 {
+			continue
+		}
+
+		c, ok := stages[entry.Name]
+		if !ok {
+			c = &conflictStages{}
+			stages[entry.Name] = c
+		}
+
+		switch entry.Stage {
+		case index.AncestorMode:
+			c.base = entry
+		case index.OurMode:
+			c.ours = entry
+		case index.TheirMode:
+			c.theirs = entry
+		}
+	}
+
+	return stages, nil
+}
+
+// conflictFiles converts grouped stages into a sorted list of conflicted files.
+func conflictFiles(stages map[string]*conflictStages) []models.ConflictFile {
+	files := make([]models.ConflictFile, 0, len(stages))
+	for path, c := range stages {
+		files = append(files, models.ConflictFile{
+			Path: path,
+			Type: c.conflictType(),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files
+}
+
+// inProgressOperation inspects the git directory for state files left behind by
+// a merge, rebase, cherry-pick or revert that has not finished yet. A squash
+// merge writes SQUASH_MSG instead of MERGE_HEAD.
+func inProgressOperation(repoPath string) string {
+	dir := gitDir(repoPath)
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	switch {
+	case exists("rebase-merge"), exists("rebase-apply"):
+		return OperationRebase
+	case exists("MERGE_HEAD"), exists("SQUASH_MSG"):
+		return OperationMerge
+	case exists("CHERRY_PICK_HEAD"):
+		return OperationCherryPick
+	case exists("REVERT_HEAD"):
+		return OperationRevert
+	default:
+		return ""
+	}
+}
+
+// GetConflicts lists the unmerged paths in the repository with their conflict type.
+func (s *Service) GetConflicts(repoPath string) ([]models.ConflictFile, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	stages, err := readConflictStages(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return conflictFiles(stages), nil
+}
+
+// GetConflictDetail returns the base, ours and theirs versions of a conflicted
+// path together with the conflict hunks parsed from the working tree file.
+func (s *Service) GetConflictDetail(repoPath, filePath string) (*models.ConflictDetail, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	stages, err := readConflictStages(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := stages[filePath]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotConflicted, filePath)
+	}
+
+	detail := &models.ConflictDetail{
+		Path:  filePath,
+		Type:  c.conflictType(),
+		Hunks: []models.ConflictHunk{},
+	}
+
+	if detail.Base, err = conflictVersion(repo, c.base); err != nil {
+		return nil, err
+	}
+	if detail.Ours, err = conflictVersion(repo, c.ours); err != nil {
+		return nil, err
+	}
+	if detail.Theirs, err = conflictVersion(repo, c.theirs); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(repoPath, filePath))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read working tree file: %w", err)
+	}
+	detail.Worktree = string(content)
+	detail.Hunks = parseConflictHunks(detail.Worktree)
+
+	return detail, nil
+}
+
+// conflictVersion loads the blob referenced by an index stage entry.
+func conflictVersion(repo *git.Repository, entry *index.Entry) (*models.ConflictVersion, error) {
+	if entry == nil {
+		return nil, nil
+	}
+
+	content, err := readBlob(repo, entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ConflictVersion{
+		Hash:    entry.Hash.String(),
+		Mode:    entry.Mode.String(),
+		Content: string(content),
+		Binary:  isBinaryContent(content),
+	}, nil
+}
+
+// readBlob reads the full content of a blob object.
+func readBlob(repo *git.Repository, hash plumbing.Hash) ([]byte, error) {
+	obj, err := repo.Storer.EncodedObject(plumbing.BlobObject, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %s: %w", hash, err)
+	}
+
+	reader, err := obj.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object reader: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+
+	return content, nil
+}
+
+// isBinaryContent uses the same heuristic as git: a NUL byte in the first 8000 bytes.
+func isBinaryContent(content []byte) bool {
+	limit := len(content)
+	if limit > 8000 {
+		limit = 8000
+	}
+	return bytes.IndexByte(content[:limit], 0) >= 0
+}
+
+// parseConflictHunks finds conflict marker regions in content. Both the default
+// merge style and diff3 style (with a ||||||| base section) are recognized.
+func parseConflictHunks(content string) []models.ConflictHunk {
+	hunks := []models.ConflictHunk{}
+	lines := strings.Split(content, "\n")
+
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+
+	state := outside
+	var current models.ConflictHunk
+
+	for i, raw := range lines {
+		// Markers end in CRLF in files checked out with CRLF line endings; the
+		// sides keep their line endings so a resolution does not change them.
+		line := strings.TrimSuffix(raw, "\r")
+		switch state {
+		case outside:
+			if strings.HasPrefix(line, markerOurs) {
+				current = models.ConflictHunk{
+					Index:     len(hunks),
+					StartLine: i + 1,
+					OursLabel: strings.TrimSpace(strings.TrimPrefix(line, markerOurs)),
+					Ours:      []string{},
+					Theirs:    []string{},
+				}
+				state = inOurs
+			}
+		case inOurs:
+			switch {
+			case strings.HasPrefix(line, markerBase):
+				current.Base = []string{}
+				state = inBase
+			case line == markerSplit:
+				state = inTheirs
+			default:
+				current.Ours = append(current.Ours, raw)
+			}
+		case inBase:
+			if line == markerSplit {
+				state = inTheirs
+			} else {
+				current.Base = append(current.Base, raw)
+			}
+		case inTheirs:
+			if strings.HasPrefix(line, markerTheirs) {
+				current.EndLine = i + 1
+				current.TheirsLabel = strings.TrimSpace(strings.TrimPrefix(line, markerTheirs))
+				hunks = append(hunks, current)
+				state = outside
+			} else {
+				current.Theirs = append(current.Theirs, raw)
+			}
+		}
+	}
+
+	return hunks
+}
+
+// resolveConflictHunks rewrites content by replacing every conflict region with
+// the side chosen for it. choices must contain one entry per hunk.
+func resolveConflictHunks(content string, choices []string) (string, error) {
+	hunks := parseConflictHunks(content)
+	if len(choices) != len(hunks) {
+		return "", fmt.Errorf("%w: expected %d hunk resolutions, got %d", ErrInvalidHunk, len(hunks), len(choices))
+	}
+
+	lines := strings.Split(content, "\n")
+	var out []string
+	next := 0
+
+	for i, hunk := range hunks {
+		out = append(out, lines[next:hunk.StartLine-1]...)
+
+		switch choices[i] {
+		case ResolveOurs:
+			out = append(out, hunk.Ours...)
+		case ResolveTheirs:
+			out = append(out, hunk.Theirs...)
+		case ResolveBoth:
+			out = append(out, hunk.Ours...)
+			out = append(out, hunk.Theirs...)
+		case ResolveBase:
+			if hunk.Base == nil {
+				return "", fmt.Errorf("%w: hunk %d has no base section", ErrInvalidHunk, i)
+			}
+			out = append(out, hunk.Base...)
+		default:
+			return "", fmt.Errorf("%w: unsupported resolution %s", ErrInvalidHunk, choices[i])
+		}
+
+		next = hunk.EndLine
+	}
+	out = append(out, lines[next:]...)
+
+	return strings.Join(out, "\n"), nil
+}
+
+// ResolveConflict writes the chosen result for a conflicted path and marks it
+// resolved in the index.
+func (s *Service) ResolveConflict(repoPath, filePath string, req models.ResolveConflictRequest) error {
+	detail, err := s.GetConflictDetail(repoPath, filePath)
+	if err != nil {
+		return err
+	}
+
+	var version *models.ConflictVersion
+	var content string
+
+	switch req.Resolution {
+	case ResolveOurs:
+		version = detail.Ours
+	case ResolveTheirs:
+		version = detail.Theirs
+	case ResolveBase:
+		version = detail.Base
+	case ResolveManual:
+		content = req.Content
+	case ResolveHunks:
+		content, err = resolveConflictHunks(detail.Worktree, req.Hunks)
+		if err != nil {
+			return err
+		}
+	case ResolveDelete:
+	default:
+		return fmt.Errorf("unsupported resolution: %s", req.Resolution)
+	}
+
+	switch req.Resolution {
+	case ResolveOurs, ResolveTheirs, ResolveBase:
+		if version == nil {
+			// The chosen side does not have the file, so resolving to it removes the path.
+			return s.removeConflictedPath(repoPath, filePath)
+		}
+		content = version.Content
+	case ResolveDelete:
+		return s.removeConflictedPath(repoPath, filePath)
+	}
+
+	fullPath := filepath.Join(repoPath, filePath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+	mode := resolvedFileMode(fullPath, version, detail)
+	if err := os.WriteFile(fullPath, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write resolved file: %w", err)
+	}
+	// WriteFile only applies the mode to a new file.
+	if err := os.Chmod(fullPath, mode); err != nil {
+		return fmt.Errorf("failed to write resolved file: %w", err)
+	}
+
+	if _, err := s.runGitCommand(repoPath, "add", "--", filePath); err != nil {
+		return fmt.Errorf("failed to mark %s resolved: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// resolvedFileMode is the mode to write a resolution with: the chosen side's
+// mode, or else the working tree file's, falling back to ours and then theirs
+// when the file is missing, so resolving keeps the executable bit.
+func resolvedFileMode(fullPath string, version *models.ConflictVersion, detail *models.ConflictDetail) os.FileMode {
+	if version == nil {
+		if info, err := os.Stat(fullPath); err == nil {
+			return info.Mode().Perm()
+		}
+		version = detail.Ours
+		if version == nil {
+			version = detail.Theirs
+		}
+	}
+	if version != nil && version.Mode == filemode.Executable.String() {
+		return 0755
+	}
+	return 0644
+}
+
+func (s *Service) removeConflictedPath(repoPath, filePath string) error {
+	if _, err := s.runGitCommand(repoPath, "rm", "--quiet", "--force", "--ignore-unmatch", "--", filePath); err != nil {
+		return fmt.Errorf("failed to mark %s resolved: %w", filePath, err)
+	}
+	return nil
+}
+
+// ContinueMerge concludes an in-progress merge once all conflicts are resolved.
+func (s *Service) ContinueMerge(repoPath string) (*models.MergeResult, error) {
+	if inProgressOperation(repoPath) != OperationMerge {
+		return nil, fmt.Errorf("%s %w", OperationMerge, ErrNoOperation)
+	}
+
+	conflicts, err := s.unmergedPaths(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return &models.MergeResult{
+			Status:    MergeStatusConflicted,
+			Conflicts: conflicts,
+		}, nil
+	}
+
+	status := MergeStatusMerged
+	if squashMergeInProgress(repoPath) {
+		status = MergeStatusSquashed
+	}
+	if _, err := s.runGitCommand(repoPath, "commit", "--no-edit"); err != nil {
+		return nil, fmt.Errorf("failed to conclude merge: %w", err)
+	}
+
+	hash, err := s.headHash(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MergeResult{
+		Status:    status,
+		Hash:      hash,
+		Conflicts: []string{},
+	}, nil
+}
+
+// AbortMerge abandons an in-progress merge and restores the pre-merge state.
+func (s *Service) AbortMerge(repoPath string) error {
+	if inProgressOperation(repoPath) != OperationMerge {
+		return fmt.Errorf("%s %w", OperationMerge, ErrNoOperation)
+	}
+
+	// git merge --abort needs MERGE_HEAD, which a squash merge does not write.
+	args := []string{"merge", "--abort"}
+	if squashMergeInProgress(repoPath) {
+		args = []string{"reset", "--merge"}
+	}
+	if _, err := s.runGitCommand(repoPath, args...); err != nil {
+		return fmt.Errorf("failed to abort merge: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+// newConflictedRepo leaves dir in the middle of a merge of "feature" into main
+// where README.md is modified on both sides.
+func newConflictedRepo(t *testing.T) (*Service, string) {
+	t.Helper()
+
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "README.md", "# Feature\n", "Edit on feature")
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "README.md", "# Main\n", "Edit on main")
+
+	result, err := service.Merge(dir, "feature", MergeNoFastForward)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.Status != MergeStatusConflicted {
+		t.Fatalf("expected conflicted merge, got %q", result.Status)
+	}
+
+	return service, dir
+}
+
+func TestGetRepositoryStatus_ReportsConflicts(t *testing.T) {
+	service, dir := newConflictedRepo(t)
+
+	status, err := service.GetRepositoryStatus(dir)
+	if err != nil {
+		t.Fatalf("GetRepositoryStatus failed: %v", err)
+	}
+
+	if status.Operation != OperationMerge {
+		t.Fatalf("expected operation %q, got %q", OperationMerge, status.Operation)
+	}
+	if status.IsClean {
+		t.Fatal("expected repository with conflicts not to be clean")
+	}
+	want := []models.ConflictFile{{Path: "README.md", Type: ConflictBothModified}}
+	if !reflect.DeepEqual(status.ConflictedFiles, want) {
+		t.Fatalf("expected conflicted files %v, got %v", want, status.ConflictedFiles)
+	}
+	for _, f := range append(status.Staged, status.Modified...) {
+		if f.Path == "README.md" {
+			t.Fatalf("conflicted path should not be reported as staged or modified: %+v", f)
+		}
+	}
+}
+
+func TestGetConflicts_DeletedByThem(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	runGit(t, dir, "rm", "README.md")
+	runGit(t, dir, "commit", "-m", "Remove README")
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "README.md", "# Main\n", "Edit on main")
+
+	if _, err := service.Merge(dir, "feature", MergeNoFastForward); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	conflicts, err := service.GetConflicts(dir)
+	if err != nil {
+		t.Fatalf("GetConflicts failed: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Type != ConflictDeletedByThem {
+		t.Fatalf("expected deleted-by-them conflict, got %v", conflicts)
+	}
+}
+
+func TestGetConflictDetail(t *testing.T) {
+	service, dir := newConflictedRepo(t)
+
+	detail, err := service.GetConflictDetail(dir, "README.md")
+	if err != nil {
+		t.Fatalf("GetConflictDetail failed: %v", err)
+	}
+
+	if detail.Base == nil || detail.Base.Content != "# Test\n" {
+		t.Fatalf("unexpected base version: %+v", detail.Base)
+	}
+	if detail.Ours == nil || detail.Ours.Content != "# Main\n" {
+		t.Fatalf("unexpected ours version: %+v", detail.Ours)
+	}
+	if detail.Theirs == nil || detail.Theirs.Content != "# Feature\n" {
+		t.Fatalf("unexpected theirs version: %+v", detail.Theirs)
+	}
+	if len(detail.Hunks) != 1 {
+		t.Fatalf("expected one hunk, got %d", len(detail.Hunks))
+	}
+
+	if _, err := service.GetConflictDetail(dir, "missing.txt"); err == nil {
+		t.Fatal("expected error for path that is not conflicted")
+	}
+}
+
+func TestParseConflictHunks(t *testing.T) {
+	content := "top\n" +
+		"<<<<<<< HEAD\nours\n||||||| base\norig\n=======\ntheirs\n>>>>>>> feature\n" +
+		"middle\n" +
+		"<<<<<<< HEAD\na\n=======\nb\nc\n>>>>>>> feature\n"
+
+	hunks := parseConflictHunks(content)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+
+	first := hunks[0]
+	if first.StartLine != 2 || first.EndLine != 8 {
+		t.Fatalf("unexpected first hunk range %d-%d", first.StartLine, first.EndLine)
+	}
+	if first.OursLabel != "HEAD" || first.TheirsLabel != "feature" {
+		t.Fatalf("unexpected labels %q/%q", first.OursLabel, first.TheirsLabel)
+	}
+	if !reflect.DeepEqual(first.Base, []string{"orig"}) {
+		t.Fatalf("expected diff3 base section, got %v", first.Base)
+	}
+
+	second := hunks[1]
+	if second.Base != nil {
+		t.Fatalf("expected no base section, got %v", second.Base)
+	}
+	if !reflect.DeepEqual(second.Theirs, []string{"b", "c"}) {
+		t.Fatalf("unexpected theirs lines %v", second.Theirs)
+	}
+
+	resolved, err := resolveConflictHunks(content, []string{ResolveBase, ResolveBoth})
+	if err != nil {
+		t.Fatalf("resolveConflictHunks failed: %v", err)
+	}
+	if want := "top\norig\nmiddle\na\nb\nc\n"; resolved != want {
+		t.Fatalf("expected %q, got %q", want, resolved)
+	}
+
+	if _, err := resolveConflictHunks(content, []string{ResolveOurs}); err == nil {
+		t.Fatal("expected error when hunk choices do not match hunk count")
+	}
+}
+
+func TestParseConflictHunks_CRLF(t *testing.T) {
+	content := "top\r\n<<<<<<< HEAD\r\nours\r\n=======\r\ntheirs\r\n>>>>>>> feature\r\nend\r\n"
+
+	hunks := parseConflictHunks(content)
+	if len(hunks) != 1 || hunks[0].OursLabel != "HEAD" || hunks[0].TheirsLabel != "feature" {
+		t.Fatalf("expected one hunk with CRLF markers, got %+v", hunks)
+	}
+
+	resolved, err := resolveConflictHunks(content, []string{ResolveTheirs})
+	if err != nil {
+		t.Fatalf("resolveConflictHunks failed: %v", err)
+	}
+	if want := "top\r\ntheirs\r\nend\r\n"; resolved != want {
+		t.Fatalf("expected %q, got %q", want, resolved)
+	}
+}
+
+func TestResolveConflictAndContinueMerge(t *testing.T) {
+	service, dir := newConflictedRepo(t)
+
+	result, err := service.ContinueMerge(dir)
+	if err != nil {
+		t.Fatalf("ContinueMerge failed: %v", err)
+	}
+	if result.Status != MergeStatusConflicted {
+		t.Fatalf("expected continue to report remaining conflicts, got %q", result.Status)
+	}
+
+	if err := service.ResolveConflict(dir, "README.md", models.ResolveConflictRequest{Resolution: ResolveTheirs}); err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# Feature\n" {
+		t.Fatalf("expected theirs content, got %q", content)
+	}
+
+	result, err = service.ContinueMerge(dir)
+	if err != nil {
+		t.Fatalf("ContinueMerge failed: %v", err)
+	}
+	if result.Status != MergeStatusMerged {
+		t.Fatalf("expected merged status, got %q", result.Status)
+	}
+	if op := inProgressOperation(dir); op != "" {
+		t.Fatalf("expected no operation in progress, got %q", op)
+	}
+}
+
+func TestResolveConflictManual(t *testing.T) {
+	service, dir := newConflictedRepo(t)
+
+	req := models.ResolveConflictRequest{Resolution: ResolveManual, Content: "# Combined\n"}
+	if err := service.ResolveConflict(dir, "README.md", req); err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+
+	conflicts, err := service.GetConflicts(dir)
+	if err != nil {
+		t.Fatalf("GetConflicts failed: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts after resolving, got %v", conflicts)
+	}
+}
+
+func TestResolveConflict_KeepsExecutableBit(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "run.sh", "echo base\n", "Add script")
+	runGit(t, dir, "update-index", "--chmod=+x", "run.sh")
+	runGit(t, dir, "commit", "-q", "-m", "Make script executable")
+	runGit(t, dir, "checkout", "-q", "run.sh")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	commitFile(t, dir, "run.sh", "echo feature\n", "Edit on feature")
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "rm", "-q", "run.sh")
+	runGit(t, dir, "commit", "-q", "-m", "Remove script")
+	if _, err := service.Merge(dir, "feature", MergeNoFastForward); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	// A modify/delete conflict leaves the file in the working tree; without it
+	// the mode comes from the kept side.
+	if err := os.Remove(filepath.Join(dir, "run.sh")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.ResolveConflict(dir, "run.sh", models.ResolveConflictRequest{Resolution: ResolveTheirs}); err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+	if mode := runGitOutput(t, dir, "ls-files", "-s", "run.sh"); !strings.HasPrefix(mode, "100755 ") {
+		t.Fatalf("expected the executable mode to be kept, got %q", mode)
+	}
+}
+
+func TestInProgressOperation_LinkedWorktree(t *testing.T) {
+	_, dir := newConflictedRepo(t)
+	runGit(t, dir, "merge", "--abort")
+
+	linked := filepath.Join(t.TempDir(), "linked")
+	runGit(t, dir, "worktree", "add", "-q", "-b", "other", linked, "HEAD~1")
+	runGit(t, linked, "merge", "-q", "--no-edit", "main")
+	if op := inProgressOperation(linked); op != "" {
+		t.Fatalf("expected no operation in progress, got %q", op)
+	}
+
+	runGit(t, linked, "reset", "-q", "--hard", "HEAD~1")
+	commitFile(t, linked, "README.md", "# Other\n", "Edit on other")
+	if err := gitCommand(context.Background(), linked, nil, "merge", "main").Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+	if op := inProgressOperation(linked); op != OperationMerge {
+		t.Fatalf("expected a merge in progress in the linked worktree, got %q", op)
+	}
+	if op := inProgressOperation(dir); op != "" {
+		t.Fatalf("expected no operation in progress in the main worktree, got %q", op)
+	}
+}
+
+func TestAbortMerge(t *testing.T) {
+	service, dir := newConflictedRepo(t)
+
+	if err := service.AbortMerge(dir); err != nil {
+		t.Fatalf("AbortMerge failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# Main\n" {
+		t.Fatalf("expected pre-merge content, got %q", content)
+	}
+
+	if err := service.AbortMerge(dir); err == nil {
+		t.Fatal("expected error when no merge is in progress")
+	}
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// remoteCredentialOption is the option in a remote's config section that
+// names the credential chosen for it. Being part of the section, it follows
+// the remote through git remote rename.
+const remoteCredentialOption = "gittyCredential"
+
+// CredentialProvider supplies authentication for remote operations.
+type CredentialProvider interface {
+	// AuthMethod returns the go-git authentication for url, using the
+	// credential with id or, when id is empty, the one stored for url's
+	// host. It returns nil when no credential applies.
+	AuthMethod(id, url string) (transport.AuthMethod, error)
+	// CommandEnv returns environment variables that authenticate a git
+	// command against url, and a func that removes any files they refer to.
+	CommandEnv(id, url string) ([]string, func(), error)
+}
+
+// SetCredentialProvider makes fetch, pull, push and clone authenticate with
+// credentials from p. Without a provider, go-git and the git CLI fall back to
+// their defaults.
+func (s *Service) SetCredentialProvider(p CredentialProvider) {
+	s.credentials = p
+}
+
+// SetRemoteCredential chooses the credential a remote authenticates with; an
+// empty id goes back to the credential stored for the remote's host.
+func (s *Service) SetRemoteCredential(repoPath, remote, id string) error {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if _, ok := cfg.Remotes[remote]; !ok {
+		return fmt.Errorf("%w: %s", ErrRemoteNotFound, remote)
+	}
+
+	key := "remote." + remote + "." + remoteCredentialOption
+	if id == "" {
+		if remoteCredential(cfg, remote) == "" {
+			return nil
+		}
+		_, err = s.runGitCommand(repoPath, "config", "--unset-all", key)
+		return err
+	}
+	_, err = s.runGitCommand(repoPath, "config", key, id)
+	return err
+}
+
+// remoteCredential returns the credential chosen for a remote, if any.
+func remoteCredential(cfg *config.Config, remote string) string {
+	return cfg.Raw.Section("remote").Subsection(remote).Option(remoteCredentialOption)
+}
+
+// remoteAuth returns the authentication for a remote, or nil to use go-git's
+// defaults.
+func (s *Service) remoteAuth(repo *git.Repository, remote string) (transport.AuthMethod, error) {
+	if s.credentials == nil {
+		return nil, nil
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	remoteConfig, ok := cfg.Remotes[remote]
+	if !ok || len(remoteConfig.URLs) == 0 {
+		// Let the operation report the missing remote
+		return nil, nil
+	}
+
+	return s.credentials.AuthMethod(remoteCredential(cfg, remote), remoteConfig.URLs[0])
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// fakeCredentials records the credential and URL of each request.
+type fakeCredentials struct {
+	requests []string
+	err      error
+}
+
+func (f *fakeCredentials) AuthMethod(id, url string) (transport.AuthMethod, error) {
+	f.requests = append(f.requests, id+" "+url)
+	return nil, f.err
+}
+
+func (f *fakeCredentials) CommandEnv(id, url string) ([]string, func(), error) {
+	f.requests = append(f.requests, id+" "+url)
+	return nil, func() {}, f.err
+}
+
+func TestRemoteCredential_ChosenPerRemote(t *testing.T) {
+	service := NewService()
+	provider := &fakeCredentials{}
+	service.SetCredentialProvider(provider)
+	dir := newCLITestRepo(t)
+	originBare := newBareRemote(t, dir, "origin")
+
+	if err := service.SetRemoteCredential(dir, "origin", "abc"); err != nil {
+		t.Fatalf("SetRemoteCredential failed: %v", err)
+	}
+	remotes, err := service.GetRemotes(dir)
+	if err != nil {
+		t.Fatalf("GetRemotes failed: %v", err)
+	}
+	if len(remotes) != 1 || remotes[0].Credential != "abc" {
+		t.Fatalf("expected origin to use credential abc, got %+v", remotes)
+	}
+
+	if _, err := service.Fetch(dir, "origin", false); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if err := service.Push(dir, "origin", "main"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	want := []string{"abc " + originBare, "abc " + originBare}
+	if strings.Join(provider.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got %q", want, provider.requests)
+	}
+
+	// The choice follows a rename and can be cleared
+	cleared := ""
+	remote, err := service.UpdateRemote(dir, "origin", models.UpdateRemoteRequest{Name: "upstream"})
+	if err != nil {
+		t.Fatalf("UpdateRemote failed: %v", err)
+	}
+	if remote.Credential != "abc" {
+		t.Errorf("expected the credential to follow the rename, got %+v", remote)
+	}
+	remote, err = service.UpdateRemote(dir, "upstream", models.UpdateRemoteRequest{Credential: &cleared})
+	if err != nil {
+		t.Fatalf("UpdateRemote failed: %v", err)
+	}
+	if remote.Credential != "" || strings.Contains(runGitOutput(t, dir, "config", "--list"), "gittycredential") {
+		t.Errorf("expected the credential to be cleared, got %+v", remote)
+	}
+	if err := service.SetRemoteCredential(dir, "upstream", ""); err != nil {
+		t.Errorf("clearing twice should succeed, got %v", err)
+	}
+
+	if err := service.SetRemoteCredential(dir, "missing", "abc"); err == nil || !strings.Contains(err.Error(), "remote not found") {
+		t.Errorf("expected remote not found, got %v", err)
+	}
+}
+
+func TestRemoteCredential_ProviderErrorStopsFetch(t *testing.T) {
+	service := NewService()
+	service.SetCredentialProvider(&fakeCredentials{err: errors.New("credential not found: gone")})
+	dir := newCLITestRepo(t)
+	newBareRemote(t, dir, "origin")
+
+	if _, err := service.Fetch(dir, "origin", false); err == nil || !strings.Contains(err.Error(), "credential not found") {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+}
+
+func TestCloneContext_KeepsCredentialForOrigin(t *testing.T) {
+	service := NewService()
+	provider := &fakeCredentials{}
+	service.SetCredentialProvider(provider)
+	_, url := newCloneSource(t)
+	path := filepath.Join(t.TempDir(), "clone")
+
+	if _, err := service.CloneContext(context.Background(), url, path, models.CloneOptions{Credential: "abc"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.requests) != 1 || provider.requests[0] != "abc "+url {
+		t.Errorf("expected the clone to ask for credential abc, got %q", provider.requests)
+	}
+	if got := strings.TrimSpace(runGitOutput(t, path, "config", "remote.origin.gittyCredential")); got != "abc" {
+		t.Errorf("expected origin to keep credential abc, got %q", got)
+	}
+}
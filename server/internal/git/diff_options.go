@@ -0,0 +1,79 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Diff algorithms accepted by DiffOptions.
+var diffAlgorithms = map[string]bool{
+	"myers":     true,
+	"patience":  true,
+	"histogram": true,
+}
+
+// DiffOptions controls how git computes a diff. The zero value is git's
+// default diff; working-tree, staged and commit diffs apply the options the
+// same way.
+type DiffOptions struct {
+	IgnoreAllSpace    bool   // ignore all whitespace (-w)
+	IgnoreSpaceChange bool   // ignore changes in the amount of whitespace (-b)
+	IgnoreBlankLines  bool   // ignore lines that are all blank
+	Context           *int   // lines of context around changes; 3 when nil
+	Algorithm         string // myers (default), patience or histogram
+	RenameThreshold   int    // similarity percent for renames and copies; 50 when 0
+}
+
+// isDefault reports whether the options leave git's default diff unchanged.
+func (o DiffOptions) isDefault() bool {
+	return !o.IgnoreAllSpace && !o.IgnoreSpaceChange && !o.IgnoreBlankLines &&
+		o.Context == nil && o.Algorithm == "" && o.RenameThreshold == 0
+}
+
+func (o DiffOptions) validate() error {
+	if o.Context != nil && *o.Context < 0 {
+		return fmt.Errorf("%w: context must not be negative", ErrInvalidDiffOptions)
+	}
+	if o.Algorithm != "" && !diffAlgorithms[o.Algorithm] {
+		return fmt.Errorf("%w: algorithm must be myers, patience or histogram", ErrInvalidDiffOptions)
+	}
+	if o.RenameThreshold < 0 || o.RenameThreshold > 100 {
+		return fmt.Errorf("%w: rename threshold must be between 0 and 100", ErrInvalidDiffOptions)
+	}
+	return nil
+}
+
+// args returns the git diff flags for the options, with rename detection.
+func (o DiffOptions) args() []string {
+	var args []string
+	if o.IgnoreAllSpace {
+		args = append(args, "--ignore-all-space")
+	}
+	if o.IgnoreSpaceChange {
+		args = append(args, "--ignore-space-change")
+	}
+	if o.IgnoreBlankLines {
+		args = append(args, "--ignore-blank-lines")
+	}
+	if o.Context != nil {
+		args = append(args, "--unified="+strconv.Itoa(*o.Context))
+	}
+	if o.Algorithm != "" {
+		args = append(args, "--diff-algorithm="+o.Algorithm)
+	}
+	return append(args, o.renameArgs(false)...)
+}
+
+// renameArgs returns the flags detecting renames, and copies too when copies
+// is true, at the rename threshold.
+func (o DiffOptions) renameArgs(copies bool) []string {
+	threshold := ""
+	if o.RenameThreshold > 0 {
+		threshold = strconv.Itoa(o.RenameThreshold) + "%"
+	}
+	args := []string{"-M" + threshold}
+	if copies {
+		args = append(args, "-C"+threshold)
+	}
+	return args
+}
@@ -0,0 +1,163 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffOptions_WorkingTreeAndStaged(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "main.go", "func main() {\n\trun()\n}\n", "Add main")
+	commitFile(t, dir, "lines.txt", numberedLines(10, nil), "Add lines")
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("func main() {\n    run()\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if diff, _ := service.GetUnstagedDiffUsingGitDiff(dir, "main.go", DiffOptions{}); diff == "" {
+		t.Fatal("expected the reindented line in the default diff")
+	}
+	if diff, err := service.GetUnstagedDiffUsingGitDiff(dir, "main.go", DiffOptions{IgnoreAllSpace: true}); err != nil || diff != "" {
+		t.Errorf("expected no diff ignoring whitespace, got %q, %v", diff, err)
+	}
+	if diff, err := service.GetFileDiff(dir, "main.go", DiffOptions{IgnoreAllSpace: true}); err != nil || hasDiffHunks(diff) {
+		t.Errorf("expected no hunks in the working tree diff ignoring whitespace, got %q, %v", diff, err)
+	}
+	runGit(t, dir, "add", "main.go")
+	if diff, err := service.GetStagedDiffUsingGitDiff(dir, "main.go", DiffOptions{IgnoreAllSpace: true}); err != nil || diff != "" {
+		t.Errorf("expected no staged diff ignoring whitespace, got %q, %v", diff, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "lines.txt"), []byte(numberedLines(10, map[int]string{5: "line five"})), 0644); err != nil {
+		t.Fatal(err)
+	}
+	noContext := 0
+	diff, err := service.TokenizeDiffFromPatch(dir, "lines.txt", false, 0, 50, DiffOptions{Context: &noContext, Algorithm: "histogram"})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+	if len(diff.Hunks) != 1 || !strings.HasPrefix(diff.Hunks[0].Header, "@@ -5 +5 @@") {
+		t.Fatalf("expected one hunk without context, got %+v", diff.Hunks)
+	}
+	if diff.Fingerprint != "" {
+		t.Errorf("expected no fingerprint with non-default options, got %q", diff.Fingerprint)
+	}
+	for _, block := range diff.Hunks[0].Blocks {
+		if block.Type == "context" {
+			t.Errorf("expected no context lines, got %+v", block)
+		}
+	}
+}
+
+func TestDiffOptions_CommitDiffs(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "lines.txt", numberedLines(10, nil), "Add lines")
+	commitFile(t, dir, "lines.txt", numberedLines(10, map[int]string{5: "line 5\n\n"}), "Space out line 5")
+	spaced := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	detail, err := service.GetCommitDetails(dir, spaced, DiffOptions{IgnoreBlankLines: true})
+	if err != nil {
+		t.Fatalf("GetCommitDetails failed: %v", err)
+	}
+	if len(detail.Changes) != 1 || hasDiffHunks(detail.Changes[0].Patch) {
+		t.Errorf("expected the blank lines to be ignored, got %+v", detail.Changes)
+	}
+	fileDiff, err := service.GetCommitFileDiff(dir, spaced, "lines.txt", 0, 50, DiffOptions{IgnoreBlankLines: true})
+	if err != nil || len(fileDiff.Hunks) != 0 {
+		t.Errorf("expected no hunks ignoring blank lines, got %+v, %v", fileDiff, err)
+	}
+
+	defaultDetail, err := service.GetCommitDetails(dir, spaced, DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetCommitDetails failed: %v", err)
+	}
+	three := 3
+	explicitDetail, err := service.GetCommitDetails(dir, spaced, DiffOptions{Context: &three})
+	if err != nil {
+		t.Fatalf("GetCommitDetails failed: %v", err)
+	}
+	if len(defaultDetail.Changes) != 1 || !hasDiffHunks(defaultDetail.Changes[0].Patch) ||
+		defaultDetail.Changes[0].Patch != explicitDetail.Changes[0].Patch {
+		t.Errorf("expected the default patch to match context=3, got %+v and %+v", defaultDetail.Changes, explicitDetail.Changes)
+	}
+
+	wide := 10
+	commitDiff, err := service.TokenizeCommitDiff(dir, spaced, DiffOptions{Context: &wide})
+	if err != nil {
+		t.Fatalf("TokenizeCommitDiff failed: %v", err)
+	}
+	if len(commitDiff.Files) != 1 || len(commitDiff.Files[0].Diff.Hunks) != 1 || commitDiff.Files[0].Diff.Additions != 2 ||
+		!strings.HasPrefix(commitDiff.Files[0].Diff.Hunks[0].Header, "@@ -1,10 +1,12 @@") {
+		t.Errorf("expected the whole file as context, got %+v", commitDiff.Files)
+	}
+
+	// A root commit is diffed against the empty tree
+	root := strings.TrimSpace(runGitOutput(t, dir, "rev-list", "--max-parents=0", "HEAD"))
+	detail, err = service.GetCommitDetails(dir, root, DiffOptions{Algorithm: "patience"})
+	if err != nil || len(detail.Changes) != 1 || detail.Changes[0].ChangeType != "added" || detail.Stats.Additions != 1 {
+		t.Errorf("unexpected root commit changes: %+v, %v", detail, err)
+	}
+}
+
+func TestDiffOptions_RenameThreshold(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "old.txt", numberedLines(10, nil), "Add old")
+	runGit(t, dir, "rm", "-q", "old.txt")
+	commitFile(t, dir, "new.txt", numberedLines(10, map[int]string{2: "second", 4: "fourth", 6: "sixth"}), "Rename and edit")
+	head := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	tests := []struct {
+		threshold int
+		renamed   bool
+	}{
+		{50, true},
+		{90, false},
+	}
+	for _, tt := range tests {
+		opts := DiffOptions{RenameThreshold: tt.threshold}
+		detail, err := service.GetCommitDetails(dir, head, opts)
+		if err != nil {
+			t.Fatalf("GetCommitDetails failed: %v", err)
+		}
+		renamed := len(detail.Changes) == 1 && detail.Changes[0].ChangeType == "renamed" && detail.Changes[0].OldPath == "old.txt"
+		if renamed != tt.renamed {
+			t.Errorf("threshold %d: expected renamed %v, got %+v", tt.threshold, tt.renamed, detail.Changes)
+		}
+
+		diff, err := service.GetCommitFileDiff(dir, head, "new.txt", 0, 50, opts)
+		if err != nil {
+			t.Fatalf("GetCommitFileDiff failed: %v", err)
+		}
+		if (diff.OldFilename == "old.txt") != tt.renamed {
+			t.Errorf("threshold %d: expected renamed %v, got old filename %q", tt.threshold, tt.renamed, diff.OldFilename)
+		}
+	}
+}
+
+func TestDiffOptions_Invalid(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	negative := -1
+
+	tests := []struct {
+		opts DiffOptions
+		want string
+	}{
+		{DiffOptions{Context: &negative}, "context must not be negative"},
+		{DiffOptions{Algorithm: "minimal"}, "algorithm must be"},
+		{DiffOptions{RenameThreshold: 101}, "rename threshold"},
+	}
+	for _, tt := range tests {
+		if _, err := service.GetUnstagedDiffUsingGitDiff(dir, "README.md", tt.opts); !errors.Is(err, ErrInvalidDiffOptions) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("GetUnstagedDiffUsingGitDiff(%+v) = %v, want %q", tt.opts, err, tt.want)
+		}
+		if _, err := service.GetCommitDetails(dir, "HEAD", tt.opts); !errors.Is(err, ErrInvalidDiffOptions) {
+			t.Errorf("GetCommitDetails(%+v) = %v, want invalid diff options", tt.opts, err)
+		}
+	}
+}
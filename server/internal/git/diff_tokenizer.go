@@ -1,6 +1,8 @@
 package git
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
@@ -318,6 +320,7 @@ type rawDiffLine struct {
 	content  string // code content (without +/- prefix)
 	oldNum   int    // line number in old file (0 if N/A)
 	newNum   int    // line number in new file (0 if N/A)
+	noEOL    bool   // followed by "\ No newline at end of file"
 }
 
 // parseDiffContent takes a raw unified diff string and returns structured lines.
@@ -386,7 +389,10 @@ func parseDiffContent(diffText string) ([]rawDiffLine, string) {
 			oldLine++
 			newLine++
 		} else if line == "\\ No newline at end of file" {
-			// Skip this marker
+			// Record the marker on the line it belongs to
+			if len(result) > 0 {
+				result[len(result)-1].noEOL = true
+			}
 			continue
 		} else {
 			// Treat as context
@@ -421,8 +427,12 @@ func parseDiffContent(diffText string) ([]rawDiffLine, string) {
 // GetFileDiffUsingGitDiff executes "git diff HEAD <file>" directly using the git CLI.
 // This is faster than using go-git library for large files as it leverages git's
 // optimized diff algorithm and avoids loading full file contents into memory.
-func (s *Service) GetFileDiffUsingGitDiff(repoPath, filePath string) (string, error) {
-	cmd := exec.Command("git", "diff", "HEAD", "--", filePath)
+func (s *Service) GetFileDiffUsingGitDiff(repoPath, filePath string, opts DiffOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+	args := append(append([]string{"diff"}, opts.args()...), "HEAD", "--", filePath)
+	cmd := exec.Command("git", args...)
 	cmd.Dir = repoPath
 	output, err := cmd.Output()
 	if err != nil {
@@ -468,10 +478,46 @@ func (s *Service) isFileTracked(repoPath, filePath string) bool {
 	return err == nil
 }
 
+// GetUnstagedDiffUsingGitDiff executes "git diff <file>", the diff between the
+// index and the working tree. Files that are not in the index yet are shown as
+// entirely added.
+func (s *Service) GetUnstagedDiffUsingGitDiff(repoPath, filePath string, opts DiffOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+	if !s.isFileTracked(repoPath, filePath) {
+		if _, statErr := os.Stat(filepath.Join(repoPath, filePath)); statErr != nil {
+			return "", nil
+		}
+		return s.getUntrackedFileDiff(repoPath, filePath)
+	}
+
+	args := append(append([]string{"diff"}, opts.args()...), "--", filePath)
+	diffText, err := s.runGitCommand(repoPath, args...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diffText) == "" {
+		return "", nil
+	}
+	return diffText, nil
+}
+
+// diffFingerprint identifies the exact diff text a tokenized diff was built from,
+// so that selections made against it can be checked for staleness.
+func diffFingerprint(diffText string) string {
+	sum := sha256.Sum256([]byte(diffText))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetStagedDiffUsingGitDiff executes "git diff --cached <file>" directly using the git CLI.
 // This gets the diff between the staged content (index) and HEAD.
-func (s *Service) GetStagedDiffUsingGitDiff(repoPath, filePath string) (string, error) {
-	cmd := exec.Command("git", "diff", "--cached", "--", filePath)
+func (s *Service) GetStagedDiffUsingGitDiff(repoPath, filePath string, opts DiffOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+	args := append(append([]string{"diff", "--cached"}, opts.args()...), "--", filePath)
+	cmd := exec.Command("git", args...)
 	cmd.Dir = repoPath
 	output, err := cmd.Output()
 	if err != nil {
@@ -660,6 +706,7 @@ func (s *Service) TokenizeDiff(diffText string, filename string, cursor int, lim
 	result.TotalHunks = len(allHunks)
 	result.Additions = totalAdd
 	result.Deletions = totalDel
+	result.EOLOnly = eolOnlyChange(parsed)
 
 	// Apply pagination on hunks
 	startIdx := cursor
@@ -684,26 +731,36 @@ func (s *Service) TokenizeDiff(diffText string, filename string, cursor int, lim
 	}
 
 	result.Hunks = allHunks[startIdx:endIdx]
+	annotateIntralineChanges(result.Hunks)
 
 	return result
 }
 
 // TokenizeDiffFromPatch is a convenience method that gets the diff using optimized
 // git diff commands and returns a fully tokenized diff ready for rendering.
-func (s *Service) TokenizeDiffFromPatch(repoPath, filePath string, staged bool, cursor int, limit int) (*models.TokenizedDiff, error) {
+// Unstaged diffs compare the index with the working tree, staged diffs compare
+// HEAD with the index, so each side only shows what staging would move.
+// Only diffs with the default options carry a fingerprint, as partial staging
+// selects lines from the default diff.
+func (s *Service) TokenizeDiffFromPatch(repoPath, filePath string, staged bool, cursor int, limit int, opts DiffOptions) (*models.TokenizedDiff, error) {
 	var diffText string
 	var err error
 
 	// Use optimized git diff path
 	if staged {
-		diffText, err = s.GetStagedDiffUsingGitDiff(repoPath, filePath)
+		diffText, err = s.GetStagedDiffUsingGitDiff(repoPath, filePath, opts)
 	} else {
-		diffText, err = s.GetFileDiffUsingGitDiff(repoPath, filePath)
+		diffText, err = s.GetUnstagedDiffUsingGitDiff(repoPath, filePath, opts)
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	fingerprint := ""
+	if opts.isDefault() {
+		fingerprint = diffFingerprint(diffText)
+	}
+
 	// If no diff, return empty result
 	if diffText == "" {
 		return &models.TokenizedDiff{
@@ -714,15 +771,18 @@ func (s *Service) TokenizeDiffFromPatch(repoPath, filePath string, staged bool,
 			Deletions: 0,
 			HasMore:   false,
 			NextCursor: 0,
+			Fingerprint: fingerprint,
 		}, nil
 	}
 
-	return s.TokenizeDiff(diffText, filePath, cursor, limit), nil
+	tokenized := s.TokenizeDiff(diffText, filePath, cursor, limit)
+	tokenized.Fingerprint = fingerprint
+	return tokenized, nil
 }
 
 // TokenizeCommitDiff tokenizes all file diffs in a commit detail.
-func (s *Service) TokenizeCommitDiff(repoPath, commitHash string) (*models.TokenizedCommitDiff, error) {
-	detail, err := s.GetCommitDetails(repoPath, commitHash)
+func (s *Service) TokenizeCommitDiff(repoPath, commitHash string, opts DiffOptions) (*models.TokenizedCommitDiff, error) {
+	detail, err := s.GetCommitDetails(repoPath, commitHash, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -738,6 +798,7 @@ func (s *Service) TokenizeCommitDiff(repoPath, commitHash string) (*models.Token
 
 	for _, change := range detail.Changes {
 		tokenized := s.TokenizeDiff(change.Patch, change.Path, 0, 9999) // don't paginate commit diff files for now
+		tokenized.OldFilename = change.OldPath
 		result.Files = append(result.Files, models.TokenizedFileDiff{
 			Path:       change.Path,
 			ChangeType: change.ChangeType,
@@ -370,7 +370,7 @@ func TestGetFileDiffUsingGitDiff_ModifiedFile(t *testing.T) {
 	}
 
 	// Get diff using git diff
-	diff, err := service.GetFileDiffUsingGitDiff(tempDir, filePath)
+	diff, err := service.GetFileDiffUsingGitDiff(tempDir, filePath, DiffOptions{})
 	if err != nil {
 		t.Fatalf("GetFileDiffUsingGitDiff failed: %v", err)
 	}
@@ -423,7 +423,7 @@ func TestGetFileDiffUsingGitDiff_NewFile(t *testing.T) {
 	}
 
 	// Get diff for new file
-	diff, err := service.GetFileDiffUsingGitDiff(tempDir, newFile)
+	diff, err := service.GetFileDiffUsingGitDiff(tempDir, newFile, DiffOptions{})
 	if err != nil {
 		t.Fatalf("GetFileDiffUsingGitDiff failed for new file: %v", err)
 	}
@@ -468,7 +468,7 @@ func TestGetFileDiffUsingGitDiff_NoChanges(t *testing.T) {
 	runGit(t, tempDir, "commit", "-m", "Initial commit")
 
 	// Get diff (should be empty - no changes)
-	diff, err := service.GetFileDiffUsingGitDiff(tempDir, filePath)
+	diff, err := service.GetFileDiffUsingGitDiff(tempDir, filePath, DiffOptions{})
 	if err != nil {
 		t.Fatalf("GetFileDiffUsingGitDiff failed: %v", err)
 	}
@@ -511,7 +511,7 @@ func TestGetStagedDiffUsingGitDiff_StagedChanges(t *testing.T) {
 	runGit(t, tempDir, "add", filePath)
 
 	// Get staged diff
-	diff, err := service.GetStagedDiffUsingGitDiff(tempDir, filePath)
+	diff, err := service.GetStagedDiffUsingGitDiff(tempDir, filePath, DiffOptions{})
 	if err != nil {
 		t.Fatalf("GetStagedDiffUsingGitDiff failed: %v", err)
 	}
@@ -551,7 +551,7 @@ func TestGetStagedDiffUsingGitDiff_NoStagedChanges(t *testing.T) {
 	runGit(t, tempDir, "commit", "-m", "Initial commit")
 
 	// Get staged diff (should be empty - nothing staged)
-	diff, err := service.GetStagedDiffUsingGitDiff(tempDir, filePath)
+	diff, err := service.GetStagedDiffUsingGitDiff(tempDir, filePath, DiffOptions{})
 	if err != nil {
 		t.Fatalf("GetStagedDiffUsingGitDiff failed: %v", err)
 	}
@@ -593,7 +593,7 @@ func TestTokenizeDiffFromPatch_UsesOptimizedGitDiff(t *testing.T) {
 	}
 
 	// Tokenize using optimized path
-	result, err := service.TokenizeDiffFromPatch(tempDir, filePath, false, 0, 50)
+	result, err := service.TokenizeDiffFromPatch(tempDir, filePath, false, 0, 50, DiffOptions{})
 	if err != nil {
 		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
 	}
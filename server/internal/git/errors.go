@@ -0,0 +1,138 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Errors that Service methods wrap, with the details, when a request cannot be
+// carried out as asked. Callers tell them apart with errors.Is.
+var (
+	// ErrMergeSourceNotFound means the branch, tag or commit to merge does not resolve.
+	ErrMergeSourceNotFound = errors.New("merge source not found")
+	// ErrNotConflicted means the path has no unresolved conflict.
+	ErrNotConflicted = errors.New("path is not conflicted")
+	// ErrInvalidHunk means per-hunk resolutions do not fit the file's conflicts.
+	ErrInvalidHunk = errors.New("invalid hunk resolution")
+	// ErrNoOperation means there is no merge, rebase, cherry-pick or revert in
+	// progress to continue, skip or abort. Errors read "<operation> not in progress".
+	ErrNoOperation = errors.New("not in progress")
+	// ErrStashNotFound means there is no stash entry at the index.
+	ErrStashNotFound = errors.New("stash not found")
+	// ErrNothingToStash means the worktree and the index have no changes to save.
+	ErrNothingToStash = errors.New("no local changes to stash")
+	// ErrLocalChanges means git stopped so as not to overwrite uncommitted
+	// changes or untracked files.
+	ErrLocalChanges = errors.New("local changes would be overwritten")
+	// ErrInvalidTagName means git would not accept the name as a tag.
+	ErrInvalidTagName = errors.New("invalid tag name")
+	// ErrTagTargetNotFound means the revision to tag does not resolve.
+	ErrTagTargetNotFound = errors.New("tag target not found")
+	// ErrTagNotFound means there is no tag of that name.
+	ErrTagNotFound = errors.New("tag not found")
+	// ErrTagExists means a tag of that name already exists.
+	ErrTagExists = errors.New("tag already exists")
+	// ErrRemoteNotFound means the repository has no remote of that name.
+	ErrRemoteNotFound = errors.New("remote not found")
+	// ErrRemoteExists means a remote of that name already exists.
+	ErrRemoteExists = errors.New("remote already exists")
+	// ErrInvalidRemoteName means git would not accept the name as a remote.
+	ErrInvalidRemoteName = errors.New("invalid remote name")
+	// ErrRemoteURLRequired means a remote was added without a URL.
+	ErrRemoteURLRequired = errors.New("remote url is required")
+	// ErrInvalidRemoteURL means git would take the URL for an option.
+	ErrInvalidRemoteURL = errors.New("invalid remote url")
+	// ErrInvalidRefSpec means a push or pull refspec does not name usable refs.
+	ErrInvalidRefSpec = errors.New("invalid refspec")
+	// ErrUnsupportedPullStrategy means the pull strategy is not merge, rebase
+	// or fast-forward.
+	ErrUnsupportedPullStrategy = errors.New("unsupported pull strategy")
+	// ErrRemoteBranchNotFound means the branch to pull has no remote-tracking
+	// ref after fetching.
+	ErrRemoteBranchNotFound = errors.New("remote branch not found")
+	// ErrDiverged means a fast-forward-only pull found local commits missing
+	// from the upstream.
+	ErrDiverged = errors.New("branch has diverged")
+	// ErrDetachedHead means HEAD is not on a branch.
+	ErrDetachedHead = errors.New("detached HEAD")
+	// ErrInvalidRevision means a revision looks like an option.
+	ErrInvalidRevision = errors.New("invalid revision")
+	// ErrRevisionNotFound means a revision does not resolve to a commit.
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrFileNotFound means the file to blame does not exist at the revision.
+	ErrFileNotFound = errors.New("file not found")
+	// ErrBinaryFile means the file to blame has binary content.
+	ErrBinaryFile = errors.New("binary file")
+	// ErrInvalidLogOptions means a commit log filter or cursor is malformed.
+	ErrInvalidLogOptions = errors.New("invalid log options")
+	// ErrPathNotFound means a path does not exist in the tree of a revision.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrNotDirectory means a tree listing was asked for a path that is not a
+	// directory.
+	ErrNotDirectory = errors.New("not a directory")
+	// ErrNotFile means a blob was asked for a path that is a directory or a
+	// submodule.
+	ErrNotFile = errors.New("not a file")
+	// ErrInvalidCompareOptions means the compare mode is unknown or the base
+	// is missing.
+	ErrInvalidCompareOptions = errors.New("invalid compare options")
+	// ErrNoCommonAncestor means a three-dot compare found no merge base.
+	ErrNoCommonAncestor = errors.New("no common ancestor")
+	// ErrInvalidDiffOptions means a diff's context, algorithm or rename
+	// threshold is out of range.
+	ErrInvalidDiffOptions = errors.New("invalid diff options")
+	// ErrAuthenticationRequired means the remote asked for credentials that
+	// were not given.
+	ErrAuthenticationRequired = transport.ErrAuthenticationRequired
+	// ErrAuthorizationFailed means the remote rejected the credentials.
+	ErrAuthorizationFailed = transport.ErrAuthorizationFailed
+	// ErrOperationInProgress means a merge, rebase, cherry-pick or revert must
+	// be finished first. Errors read "<operation> already in progress".
+	ErrOperationInProgress = errors.New("already in progress")
+	// ErrInvalidRebasePlan means the rebase base or one of the plan's steps is
+	// unusable.
+	ErrInvalidRebasePlan = errors.New("invalid rebase plan")
+	// ErrNoCommits means a cherry-pick or revert was given no commits.
+	ErrNoCommits = errors.New("at least one commit is required")
+	// ErrCommitNotFound means a commit to cherry-pick or revert does not resolve.
+	ErrCommitNotFound = errors.New("commit not found")
+	// ErrInvalidMainline means the mainline parent is out of range, missing for
+	// a merge commit, or given for a commit that is not a merge.
+	ErrInvalidMainline = errors.New("invalid mainline parent")
+	// ErrNothingApplied means a no-commit cherry-pick or revert stopped on
+	// conflicts and was rolled back.
+	ErrNothingApplied = errors.New("nothing was applied")
+	// ErrNotTracked means the path to discard is not in the index or HEAD.
+	ErrNotTracked = errors.New("path is not tracked")
+	// ErrResetTargetNotFound means the revision to reset to does not resolve.
+	ErrResetTargetNotFound = errors.New("reset target not found")
+	// ErrStaleDiff means the file changed after the diff that a partial stage
+	// or unstage was chosen from.
+	ErrStaleDiff = errors.New("file changed since the diff was generated")
+	// ErrInvalidSelection means the hunks or lines to stage or unstage do not
+	// fit the diff, or select no changes.
+	ErrInvalidSelection = errors.New("invalid selection")
+)
+
+// localChangesMarkers are the parts of git's messages that tell it stopped to
+// keep local changes.
+var localChangesMarkers = []string{
+	"would be overwritten",
+	"already exists, no checkout",
+	"You have unstaged changes",
+	"Your index contains uncommitted changes",
+}
+
+// withLocalChanges wraps a failed git command's error with ErrLocalChanges
+// when git stopped to keep local changes, and returns it unchanged otherwise.
+func withLocalChanges(err error) error {
+	for _, marker := range localChangesMarkers {
+		if strings.Contains(err.Error(), marker) {
+			return fmt.Errorf("%w: %w", ErrLocalChanges, err)
+		}
+	}
+	return err
+}
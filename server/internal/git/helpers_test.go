@@ -0,0 +1,114 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newCLITestRepo creates a repository on branch main with a single commit.
+func newCLITestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test User")
+	commitFile(t, dir, "README.md", "# Test\n", "Initial commit")
+
+	return dir
+}
+
+// commitFile writes content to path and commits it.
+func commitFile(t *testing.T, dir, path, content, message string) {
+	t.Helper()
+
+	fullPath := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", path)
+	runGit(t, dir, "commit", "-m", message)
+}
+
+// runGitOutput runs a git command in dir and returns its stdout.
+func runGitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return string(output)
+}
+
+// logSubjects returns the subjects of the commits in revRange, oldest first.
+func logSubjects(t *testing.T, dir, revRange string) []string {
+	t.Helper()
+	return strings.Split(strings.TrimSpace(runGitOutput(t, dir, "log", "--format=%s", "--reverse", revRange)), "\n")
+}
+
+// writeTestFile writes content to path in dir, creating directories as needed.
+func writeTestFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+
+	fullPath := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestFile returns the content of path in dir.
+func readTestFile(t *testing.T, dir, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// numberedLines returns n lines "line i", with the lines in changed replaced.
+func numberedLines(n int, changed map[int]string) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := changed[i]; ok {
+			sb.WriteString(line + "\n")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	return sb.String()
+}
+
+// newBareRemote creates a bare repository, registers it as remote name in dir
+// and pushes main to it.
+func newBareRemote(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	bare := t.TempDir()
+	runGit(t, bare, "init", "--bare", "-b", "main")
+	runGit(t, dir, "remote", "add", name, bare)
+	runGit(t, dir, "push", name, "main")
+
+	return bare
+}
+
+// refExists reports whether ref resolves in dir.
+func refExists(dir, ref string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref)
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
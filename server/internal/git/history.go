@@ -0,0 +1,140 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitweb/server/internal/models"
+)
+
+// fileChangeTypes maps git's raw diff status letters to change types.
+var fileChangeTypes = map[byte]string{
+	'A': "added",
+	'M': "modified",
+	'D': "deleted",
+	'R': "renamed",
+	'C': "copied",
+	'T': "type-changed",
+}
+
+// FileHistory lists the commits that changed opts.Path, newest first, one
+// page at a time, following renames and copies. Each entry carries the path
+// of the file in that commit and its diff stats; merge commits are compared
+// with their first parent. Paging and filters work as in CommitLog.
+func (s *Service) FileHistory(ctx context.Context, repoPath string, opts LogOptions) ([]models.FileHistoryEntry, string, error) {
+	return s.fileHistory(ctx, repoPath, opts, DiffOptions{})
+}
+
+// fileHistory is FileHistory with renames and copies detected at the rename
+// threshold of diffOpts.
+func (s *Service) fileHistory(ctx context.Context, repoPath string, opts LogOptions, diffOpts DiffOptions) ([]models.FileHistoryEntry, string, error) {
+	opts.Path = strings.Trim(opts.Path, "/")
+	if opts.Path == "" {
+		return nil, "", fmt.Errorf("%w: path is required", ErrInvalidLogOptions)
+	}
+
+	diffArgs := append(diffOpts.renameArgs(true), "--diff-merges=first-parent", "--raw", "--numstat", "-z")
+	records, next, err := s.walkLog(ctx, repoPath, opts, diffArgs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries := make([]models.FileHistoryEntry, 0, len(records))
+	for _, record := range records {
+		entry := models.FileHistoryEntry{Commit: record.commit, Path: opts.Path}
+		// --follow limits the diff to the followed file
+		if files := parseChangedFiles(record.diff); len(files) > 0 {
+			file := files[0]
+			entry.Path = file.Path
+			entry.OldPath = file.OldPath
+			entry.ChangeType = file.ChangeType
+			entry.Similarity = file.Similarity
+			entry.Additions = file.Additions
+			entry.Deletions = file.Deletions
+			entry.Binary = file.Binary
+		}
+		entries = append(entries, entry)
+	}
+	return entries, next, nil
+}
+
+// parseChangedFiles parses diff output with --raw --numstat -z: the raw
+// entries of every file, then the numstat entries in the same order.
+func parseChangedFiles(diff string) []models.ChangedFile {
+	var files []models.ChangedFile
+	stat := 0
+	fields := strings.Split(diff, "\x00")
+
+	for i := 0; i < len(fields); i++ {
+		field := strings.TrimLeft(fields[i], "\n")
+		switch {
+		case strings.HasPrefix(field, ":"):
+			// :<old mode> <new mode> <old hash> <new hash> <status><score>
+			parts := strings.Fields(field)
+			if len(parts) < 5 || i+1 >= len(fields) {
+				continue
+			}
+			status := parts[4]
+			file := models.ChangedFile{ChangeType: fileChangeTypes[status[0]]}
+			if file.ChangeType == "" {
+				file.ChangeType = "modified"
+			}
+			if (status[0] == 'R' || status[0] == 'C') && i+2 < len(fields) {
+				file.Similarity, _ = strconv.Atoi(status[1:])
+				file.OldPath, file.Path = fields[i+1], fields[i+2]
+				i += 2
+			} else {
+				file.Path = fields[i+1]
+				i++
+			}
+			files = append(files, file)
+		case strings.Count(field, "\t") >= 2:
+			// <added>\t<deleted>\t<path>, with an empty path followed by the
+			// old and new paths for renames and copies
+			parts := strings.SplitN(field, "\t", 3)
+			if !isNumstatCount(parts[0]) || !isNumstatCount(parts[1]) {
+				continue
+			}
+			if parts[2] == "" {
+				i += 2
+			}
+			if stat >= len(files) {
+				continue
+			}
+			if parts[0] == "-" && parts[1] == "-" {
+				files[stat].Binary = true
+			} else {
+				files[stat].Additions, _ = strconv.Atoi(parts[0])
+				files[stat].Deletions, _ = strconv.Atoi(parts[1])
+			}
+			stat++
+		}
+	}
+	return files
+}
+
+// isNumstatCount reports whether s is a numstat line count, "-" for binary
+// files.
+func isNumstatCount(s string) bool {
+	if s == "-" {
+		return true
+	}
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// renameSource returns the path filePath had before commitHash renamed or
+// copied it, or "" when the commit did neither.
+func (s *Service) renameSource(repoPath, commitHash, filePath string, opts DiffOptions) string {
+	entries, _, err := s.fileHistory(context.Background(), repoPath, LogOptions{
+		Ref:   commitHash,
+		Path:  filePath,
+		Limit: 1,
+	}, opts)
+	if err != nil || len(entries) == 0 || entries[0].Commit.Hash != commitHash {
+		return ""
+	}
+	return entries[0].OldPath
+}
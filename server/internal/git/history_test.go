@@ -0,0 +1,105 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFileHistory_FollowsRenamesAndCopies(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "a.txt", "one\ntwo\nthree\nfour\nfive\n", "Add a")
+	runGit(t, dir, "mv", "a.txt", "b.txt")
+	commitFile(t, dir, "b.txt", "one\ntwo\nthree\nfour\nfive\nsix\n", "Rename to b")
+	commitFile(t, dir, "c.txt", "one\ntwo\nthree\nfour\nfive\nsix\n", "Copy to c")
+	commitFile(t, dir, "other.txt", "other\n", "Unrelated")
+	commitFile(t, dir, "c.txt", "one\ntwo\nthree\nfour\nfive\nsix\nseven\n", "Edit c")
+
+	entries, next, err := service.FileHistory(context.Background(), dir, LogOptions{Path: "c.txt"})
+	if err != nil {
+		t.Fatalf("FileHistory failed: %v", err)
+	}
+	want := []struct {
+		message, path, oldPath, changeType string
+		additions, deletions               int
+	}{
+		{"Edit c", "c.txt", "", "modified", 1, 0},
+		{"Copy to c", "c.txt", "b.txt", "copied", 0, 0},
+		{"Rename to b", "b.txt", "a.txt", "renamed", 1, 0},
+		{"Add a", "a.txt", "", "added", 5, 0},
+	}
+	if len(entries) != len(want) || next != "" {
+		t.Fatalf("expected %d entries, got %+v (next %q)", len(want), entries, next)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Commit.Message != w.message || e.Path != w.path || e.OldPath != w.oldPath || e.ChangeType != w.changeType || e.Additions != w.additions || e.Deletions != w.deletions {
+			t.Errorf("entry %d: expected %+v, got %+v", i, w, e)
+		}
+	}
+
+	page, next, err := service.FileHistory(context.Background(), dir, LogOptions{Path: "c.txt", Limit: 2})
+	if err != nil || len(page) != 2 || next == "" {
+		t.Fatalf("expected a first page of 2, got %+v, %q, %v", page, next, err)
+	}
+	page, next, err = service.FileHistory(context.Background(), dir, LogOptions{Path: "c.txt", Limit: 2, Cursor: next})
+	if err != nil || len(page) != 2 || page[0].Path != "b.txt" || next != "" {
+		t.Errorf("expected the renamed entries on the last page, got %+v, %q, %v", page, next, err)
+	}
+
+	if _, _, err := service.FileHistory(context.Background(), dir, LogOptions{}); !errors.Is(err, ErrInvalidLogOptions) {
+		t.Errorf("expected a missing path error, got %v", err)
+	}
+}
+
+func TestFileHistory_DeletedAndBinaryFiles(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "logo.png", "\x89PNG\x00\x01", "Add logo")
+	commitFile(t, dir, "logo.png", "\x89PNG\x00\x02", "Update logo")
+	runGit(t, dir, "rm", "-q", "logo.png")
+	runGit(t, dir, "commit", "-m", "Remove logo")
+
+	entries, _, err := service.FileHistory(context.Background(), dir, LogOptions{Path: "logo.png"})
+	if err != nil {
+		t.Fatalf("FileHistory failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].ChangeType != "deleted" || entries[1].ChangeType != "modified" || entries[2].ChangeType != "added" {
+		t.Fatalf("unexpected history: %+v", entries)
+	}
+	for _, e := range entries {
+		if !e.Binary || e.Additions != 0 || e.Deletions != 0 {
+			t.Errorf("expected binary entries without line stats, got %+v", e)
+		}
+	}
+}
+
+func TestGetCommitFileDiff_Renamed(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "old.go", "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n", "Add old")
+	runGit(t, dir, "mv", "old.go", "new.go")
+	commitFile(t, dir, "new.go", "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc d() {}\n", "Rename and edit")
+	renamed := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "mv", "new.go", "moved.go")
+	runGit(t, dir, "commit", "-m", "Pure rename")
+	pure := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	diff, err := service.GetCommitFileDiff(dir, renamed, "new.go", 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetCommitFileDiff failed: %v", err)
+	}
+	if diff.OldFilename != "old.go" || diff.Additions != 1 || diff.Deletions != 1 {
+		t.Errorf("expected a one-line change from old.go, got %s +%d -%d", diff.OldFilename, diff.Additions, diff.Deletions)
+	}
+
+	diff, err = service.GetCommitFileDiff(dir, pure, "moved.go", 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetCommitFileDiff failed: %v", err)
+	}
+	if diff.OldFilename != "new.go" || len(diff.Hunks) != 0 {
+		t.Errorf("expected a rename without hunks, got %+v", diff)
+	}
+}
@@ -0,0 +1,292 @@
+package git
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"gitweb/server/internal/models"
+)
+
+// ─── INTRA-LINE CHANGES ───
+
+const (
+	// minPairSimilarity is how much of two lines' non-whitespace text must be
+	// shared for them to be shown as one line changed rather than a line
+	// removed and another added.
+	minPairSimilarity = 0.5
+
+	// maxPairCandidates bounds the deleted×added line pairs compared when
+	// aligning a change; larger changes are only paired line by line when
+	// both sides have as many lines.
+	maxPairCandidates = 400
+
+	// maxIntralineRunes is the line length above which lines are not paired.
+	maxIntralineRunes = 2000
+
+	// maxWordComparisons bounds the word×word table used to match the
+	// differing middle of two lines.
+	maxWordComparisons = 40000
+)
+
+// annotateIntralineChanges pairs each deleted block of a hunk with the added
+// block after it and marks what changed on the paired lines. Lines too
+// different to pair are left without changes, so clients show them whole.
+func annotateIntralineChanges(hunks []models.DiffHunkTokenized) {
+	for h := range hunks {
+		blocks := hunks[h].Blocks
+		for b := 0; b+1 < len(blocks); b++ {
+			if blocks[b].Type == "deleted" && blocks[b+1].Type == "added" {
+				pairBlockLines(blocks[b].Lines, blocks[b+1].Lines)
+			}
+		}
+	}
+}
+
+// pairBlockLines aligns deleted lines with added lines, keeping their order,
+// so that the total similarity of the pairs is highest, and sets the changes
+// of each pair.
+func pairBlockLines(deleted, added []models.DiffLineTokenized) {
+	oldWords := make([][]string, len(deleted))
+	for i := range deleted {
+		oldWords[i] = lineWords(deleted[i].Tokens)
+	}
+	newWords := make([][]string, len(added))
+	for j := range added {
+		newWords[j] = lineWords(added[j].Tokens)
+	}
+
+	if len(deleted)*len(added) > maxPairCandidates {
+		if len(deleted) != len(added) {
+			return
+		}
+		for i := range deleted {
+			setLineChanges(&deleted[i], &added[i], oldWords[i], newWords[i])
+		}
+		return
+	}
+
+	// score[i][j] is the best total similarity of deleted[i:] with added[j:]
+	n, m := len(deleted), len(added)
+	sim := make([][]float64, n)
+	score := make([][]float64, n+1)
+	for i := range score {
+		score[i] = make([]float64, m+1)
+	}
+	for i := range sim {
+		sim[i] = make([]float64, m)
+		for j := range sim[i] {
+			sim[i][j] = similarity(oldWords[i], newWords[j])
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			best := score[i+1][j]
+			if score[i][j+1] > best {
+				best = score[i][j+1]
+			}
+			if sim[i][j] >= minPairSimilarity && score[i+1][j+1]+sim[i][j] > best {
+				best = score[i+1][j+1] + sim[i][j]
+			}
+			score[i][j] = best
+		}
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case sim[i][j] >= minPairSimilarity && score[i][j] == score[i+1][j+1]+sim[i][j]:
+			setLineChanges(&deleted[i], &added[j], oldWords[i], newWords[j])
+			i++
+			j++
+		case score[i][j] == score[i+1][j]:
+			i++
+		default:
+			j++
+		}
+	}
+}
+
+// setLineChanges marks the changed parts of a deleted line and the added line
+// paired with it, when they are similar enough. Changes are found word by
+// word, then narrowed to the characters that differ within each change.
+func setLineChanges(deleted, added *models.DiffLineTokenized, oldWords, newWords []string) {
+	if similarity(oldWords, newWords) < minPairSimilarity {
+		return
+	}
+	deleted.Changes = []models.DiffSpan{}
+	added.Changes = []models.DiffSpan{}
+
+	// Walk the common words, collecting the differing runs between them
+	common := commonWords(oldWords, newWords)
+	oldPos, newPos := 0, 0 // offsets in UTF-16 code units
+	i, j := 0, 0
+	for _, c := range append(common, [2]int{len(oldWords), len(newWords)}) {
+		oldText := strings.Join(oldWords[i:c[0]], "")
+		newText := strings.Join(newWords[j:c[1]], "")
+		oldSpan, newSpan := narrowChange(oldText, newText)
+		if oldSpan.End > oldSpan.Start {
+			deleted.Changes = appendSpan(deleted.Changes, oldPos+oldSpan.Start, oldPos+oldSpan.End)
+		}
+		if newSpan.End > newSpan.Start {
+			added.Changes = appendSpan(added.Changes, newPos+newSpan.Start, newPos+newSpan.End)
+		}
+		oldPos += utf16Len(oldText)
+		newPos += utf16Len(newText)
+
+		if c[0] < len(oldWords) {
+			oldPos += utf16Len(oldWords[c[0]])
+			newPos += utf16Len(newWords[c[1]])
+		}
+		i, j = c[0]+1, c[1]+1
+	}
+}
+
+// narrowChange trims the characters two differing runs of words share at
+// either end, returning what is left of each in UTF-16 code units.
+func narrowChange(oldText, newText string) (models.DiffSpan, models.DiffSpan) {
+	a, b := []rune(oldText), []rune(newText)
+	if len(a) == 0 || len(b) == 0 {
+		return models.DiffSpan{End: utf16Len(oldText)}, models.DiffSpan{End: utf16Len(newText)}
+	}
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	start := utf16Len(string(a[:prefix]))
+	return models.DiffSpan{Start: start, End: start + utf16Len(string(a[prefix:len(a)-suffix]))},
+		models.DiffSpan{Start: start, End: start + utf16Len(string(b[prefix:len(b)-suffix]))}
+}
+
+// appendSpan adds a span, merging it with the previous one when they touch.
+func appendSpan(spans []models.DiffSpan, start, end int) []models.DiffSpan {
+	if n := len(spans); n > 0 && spans[n-1].End == start {
+		spans[n-1].End = end
+		return spans
+	}
+	return append(spans, models.DiffSpan{Start: start, End: end})
+}
+
+// lineWords splits the text of a tokenized line into words, runs of
+// whitespace and single punctuation characters. Lines too long to compare
+// have no words.
+func lineWords(tokens []models.Token) []string {
+	var text strings.Builder
+	for _, token := range tokens {
+		text.WriteString(token.Text)
+	}
+	runes := []rune(text.String())
+	if len(runes) > maxIntralineRunes {
+		return nil
+	}
+
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 0
+		}
+	}
+	var words []string
+	for start := 0; start < len(runes); {
+		end := start + 1
+		if c := class(runes[start]); c != 0 {
+			for end < len(runes) && class(runes[end]) == c {
+				end++
+			}
+		}
+		words = append(words, string(runes[start:end]))
+		start = end
+	}
+	return words
+}
+
+// commonWords returns the index pairs of a longest common subsequence of two
+// word lists. Words shared at either end are matched directly; when what is
+// left between them is too long to compare, none of it is matched.
+func commonWords(a, b []string) [][2]int {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var pairs [][2]int
+	for i := 0; i < prefix; i++ {
+		pairs = append(pairs, [2]int{i, i})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA)*len(midB) <= maxWordComparisons {
+		lcs := make([][]int, len(midA)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(midB)+1)
+		}
+		for i := len(midA) - 1; i >= 0; i-- {
+			for j := len(midB) - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		for i, j := 0, 0; i < len(midA) && j < len(midB); {
+			switch {
+			case midA[i] == midB[j]:
+				pairs = append(pairs, [2]int{prefix + i, prefix + j})
+				i++
+				j++
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				i++
+			default:
+				j++
+			}
+		}
+	}
+
+	for k := suffix; k > 0; k-- {
+		pairs = append(pairs, [2]int{len(a) - k, len(b) - k})
+	}
+	return pairs
+}
+
+// similarity is the share of two lines' non-whitespace characters that are in
+// their common words, from 0 to 1.
+func similarity(a, b []string) float64 {
+	total := visibleLen(a) + visibleLen(b)
+	if total == 0 || a == nil || b == nil {
+		return 0
+	}
+	shared := 0
+	for _, pair := range commonWords(a, b) {
+		if word := a[pair[0]]; strings.TrimSpace(word) != "" {
+			shared += len([]rune(word))
+		}
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+// visibleLen counts the non-whitespace characters of a word list.
+func visibleLen(words []string) int {
+	n := 0
+	for _, word := range words {
+		if strings.TrimSpace(word) != "" {
+			n += len([]rune(word))
+		}
+	}
+	return n
+}
+
+// utf16Len is the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+// tokenizeChange tokenizes a single-hunk diff replacing the old lines with
+// the new ones and returns its deleted and added lines.
+func tokenizeChange(t *testing.T, filename string, oldLines, newLines []string) ([]models.DiffLineTokenized, []models.DiffLineTokenized) {
+	t.Helper()
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- a/%s\n+++ b/%s\n@@ -1,%d +1,%d @@\n", filename, filename, len(oldLines), len(newLines))
+	for _, line := range oldLines {
+		diff.WriteString("-" + line + "\n")
+	}
+	for _, line := range newLines {
+		diff.WriteString("+" + line + "\n")
+	}
+
+	result := NewService().TokenizeDiff(diff.String(), filename, 0, 50)
+	var deleted, added []models.DiffLineTokenized
+	for _, block := range result.Hunks[0].Blocks {
+		switch block.Type {
+		case "deleted":
+			deleted = append(deleted, block.Lines...)
+		case "added":
+			added = append(added, block.Lines...)
+		}
+	}
+	return deleted, added
+}
+
+func TestIntralineChanges_CharacterEdit(t *testing.T) {
+	deleted, added := tokenizeChange(t, "main.go",
+		[]string{"const requestTimeout = 30 * time.Second // applies to every outbound call"},
+		[]string{"const requestTimeout = 60 * time.Second // applies to every outbound call"})
+
+	want := []models.DiffSpan{{Start: 23, End: 24}}
+	if !reflect.DeepEqual(deleted[0].Changes, want) || !reflect.DeepEqual(added[0].Changes, want) {
+		t.Errorf("expected the changed digit to be marked, got %+v and %+v", deleted[0].Changes, added[0].Changes)
+	}
+}
+
+func TestIntralineChanges_Words(t *testing.T) {
+	deleted, added := tokenizeChange(t, "app.py",
+		[]string{"result = compute(alpha, beta)"},
+		[]string{"result = compute(alpha, gamma, beta)"})
+
+	if len(deleted[0].Changes) != 0 {
+		t.Errorf("expected nothing removed from the old line, got %+v", deleted[0].Changes)
+	}
+	// "gamma, " is inserted after "alpha, "
+	if want := []models.DiffSpan{{Start: 24, End: 31}}; !reflect.DeepEqual(added[0].Changes, want) {
+		t.Errorf("expected %+v, got %+v", want, added[0].Changes)
+	}
+
+	deleted, added = tokenizeChange(t, "app.py",
+		[]string{"user = load_user(request.id)"},
+		[]string{"user = fetch_account(request.id)"})
+	if want := []models.DiffSpan{{Start: 7, End: 16}}; !reflect.DeepEqual(deleted[0].Changes, want) {
+		t.Errorf("expected the replaced call on the old line, got %+v", deleted[0].Changes)
+	}
+	if want := []models.DiffSpan{{Start: 7, End: 20}}; !reflect.DeepEqual(added[0].Changes, want) {
+		t.Errorf("expected the replaced call on the new line, got %+v", added[0].Changes)
+	}
+}
+
+func TestIntralineChanges_Pairing(t *testing.T) {
+	// Only the similar line is paired, even with lines added around it
+	deleted, added := tokenizeChange(t, "main.go",
+		[]string{"\treturn fmt.Errorf(\"failed to open: %w\", err)"},
+		[]string{
+			"\tif errors.Is(err, os.ErrNotExist) {",
+			"\t\treturn nil",
+			"\t}",
+			"\treturn fmt.Errorf(\"failed to open %s: %w\", path, err)",
+		})
+	if deleted[0].Changes == nil || added[3].Changes == nil {
+		t.Fatalf("expected the return statements to be paired, got %+v and %+v", deleted[0].Changes, added[3].Changes)
+	}
+	for i := 0; i < 3; i++ {
+		if added[i].Changes != nil {
+			t.Errorf("expected added line %d to stay unpaired, got %+v", i, added[i].Changes)
+		}
+	}
+
+	// Lines with little in common are shown whole
+	deleted, added = tokenizeChange(t, "main.go",
+		[]string{"x := compute()"},
+		[]string{"log.Println(\"starting server on port\", port)"})
+	if deleted[0].Changes != nil || added[0].Changes != nil {
+		t.Errorf("expected unrelated lines to stay unpaired, got %+v and %+v", deleted[0].Changes, added[0].Changes)
+	}
+}
+
+func TestIntralineChanges_UTF16Offsets(t *testing.T) {
+	deleted, added := tokenizeChange(t, "notes.txt",
+		[]string{"😀 status: draft"},
+		[]string{"😀 status: final"})
+
+	// The emoji is two UTF-16 code units
+	want := []models.DiffSpan{{Start: 11, End: 16}}
+	if !reflect.DeepEqual(deleted[0].Changes, want) || !reflect.DeepEqual(added[0].Changes, want) {
+		t.Errorf("expected %+v, got %+v and %+v", want, deleted[0].Changes, added[0].Changes)
+	}
+}
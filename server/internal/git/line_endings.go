@@ -0,0 +1,147 @@
+package git
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Line ending modes, matching RepoCommitSettings.LineEndings. Auto keeps each
+// file's predominant line ending and only repairs files that mix both.
+const (
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+	LineEndingsAuto = "auto"
+)
+
+// NormalizeLineEndings rewrites every line ending in content as eol ("lf" or
+// "crlf"). Lone carriage returns are left alone.
+func NormalizeLineEndings(content []byte, eol string) []byte {
+	lf := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	if eol == LineEndingsCRLF {
+		return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+	}
+	return lf
+}
+
+// dominantLineEnding returns the line ending most lines in content use,
+// preferring LF on a tie, or "" when content has no line endings.
+func dominantLineEnding(content []byte) string {
+	crlf := bytes.Count(content, []byte("\r\n"))
+	lf := bytes.Count(content, []byte("\n")) - crlf
+	switch {
+	case crlf == 0 && lf == 0:
+		return ""
+	case crlf > lf:
+		return LineEndingsCRLF
+	default:
+		return LineEndingsLF
+	}
+}
+
+// NormalizeFileContent applies the line ending rules for filePath to content.
+// A .gitattributes eol attribute wins over setting, -text (or binary) turns
+// normalization off, and binary content is never touched.
+func (s *Service) NormalizeFileContent(repoPath, filePath, setting string, content []byte) []byte {
+	eol := s.lineEndingRules(repoPath, setting, []string{filePath})[filePath]
+	return applyLineEnding(content, eol)
+}
+
+// stagingConfig are the -c overrides every staging path runs git with. Text
+// files are stored in the index with LF line endings, as the text and eol
+// attributes in .gitattributes also require; the LineEndings setting only
+// decides the line endings files are saved with. Files already committed
+// with CRLF keep them, and -text or binary files are stored as they are.
+var stagingConfig = []string{"-c", "core.autocrlf=input", "-c", "core.safecrlf=false"}
+
+// stagePaths stages the changes to paths, the whole worktree when empty,
+// including deletions. The worktree files are left untouched.
+func (s *Service) stagePaths(repoPath string, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	args := append(append(append([]string{}, stagingConfig...), "add", "-A", "--"), paths...)
+	_, err := s.runGitCommand(repoPath, args...)
+	return err
+}
+
+// applyLineEnding normalizes content according to a resolved rule. Binary
+// content and files without any line ending are returned unchanged.
+func applyLineEnding(content []byte, eol string) []byte {
+	if eol == "" || isBinaryContent(content) {
+		return content
+	}
+	if eol == LineEndingsAuto {
+		if eol = dominantLineEnding(content); eol == "" {
+			return content
+		}
+	}
+	return NormalizeLineEndings(content, eol)
+}
+
+// lineEndingRules resolves the line ending for each path: "lf" or "crlf",
+// "auto", or "" to leave the file alone. The text and eol attributes from
+// .gitattributes take precedence over setting.
+func (s *Service) lineEndingRules(repoPath, setting string, paths []string) map[string]string {
+	if setting != LineEndingsLF && setting != LineEndingsCRLF && setting != LineEndingsAuto {
+		setting = ""
+	}
+
+	rules := make(map[string]string, len(paths))
+	for _, path := range paths {
+		rules[path] = setting
+	}
+
+	input := strings.Join(paths, "\x00") + "\x00"
+	output, err := s.runGitCommandWithInput(repoPath, input, "check-attr", "-z", "--stdin", "text", "eol")
+	if err != nil {
+		return rules
+	}
+
+	// -z output is a sequence of path, attribute, value triples; text is
+	// reported before eol, and an unset text attribute disables eol too.
+	fields := strings.Split(output, "\x00")
+	noText := map[string]bool{}
+	for i := 0; i+2 < len(fields); i += 3 {
+		path, attr, value := fields[i], fields[i+1], fields[i+2]
+		switch {
+		case attr == "text" && value == "unset":
+			noText[path] = true
+			rules[path] = ""
+		case attr == "eol" && !noText[path] && (value == LineEndingsLF || value == LineEndingsCRLF):
+			rules[path] = value
+		}
+	}
+
+	return rules
+}
+
+// IsEOLOnlyDiff reports whether a unified diff changes nothing but line
+// endings.
+func IsEOLOnlyDiff(diffText string) bool {
+	parsed, _ := parseDiffContent(diffText)
+	return eolOnlyChange(parsed)
+}
+
+// eolOnlyChange reports whether every removed line comes back, in order,
+// differing at most by a trailing carriage return or final newline.
+func eolOnlyChange(parsed []rawDiffLine) bool {
+	var deleted, added []string
+	for _, dl := range parsed {
+		switch dl.lineType {
+		case "deleted":
+			deleted = append(deleted, strings.TrimSuffix(dl.content, "\r"))
+		case "added":
+			added = append(added, strings.TrimSuffix(dl.content, "\r"))
+		}
+	}
+
+	if len(deleted) == 0 || len(deleted) != len(added) {
+		return false
+	}
+	for i := range deleted {
+		if deleted[i] != added[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package git
+
+import (
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestNormalizeLineEndings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		eol     string
+		want    string
+	}{
+		{"mixed to lf", "a\r\nb\nc\r\n", LineEndingsLF, "a\nb\nc\n"},
+		{"mixed to crlf", "a\r\nb\nc\n", LineEndingsCRLF, "a\r\nb\r\nc\r\n"},
+		{"lone carriage return kept", "a\rb\r\n", LineEndingsLF, "a\rb\n"},
+		{"no final newline", "a\nb", LineEndingsCRLF, "a\r\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(NormalizeLineEndings([]byte(tt.content), tt.eol)); got != tt.want {
+				t.Errorf("NormalizeLineEndings(%q, %s) = %q, want %q", tt.content, tt.eol, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFileContent_RespectsGitAttributes(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	writeTestFile(t, dir, ".gitattributes", "*.bat eol=crlf\n*.dat -text\n")
+
+	tests := []struct {
+		name    string
+		path    string
+		setting string
+		content string
+		want    string
+	}{
+		{"setting lf", "notes.txt", LineEndingsLF, "a\r\nb\n", "a\nb\n"},
+		{"setting crlf", "notes.txt", LineEndingsCRLF, "a\r\nb\n", "a\r\nb\r\n"},
+		{"auto keeps dominant ending", "notes.txt", LineEndingsAuto, "a\r\nb\r\nc\n", "a\r\nb\r\nc\r\n"},
+		{"eol attribute wins", "run.bat", LineEndingsLF, "a\nb\n", "a\r\nb\r\n"},
+		{"unset text is left alone", "blob.dat", LineEndingsLF, "a\r\nb\n", "a\r\nb\n"},
+		{"binary is left alone", "image.txt", LineEndingsLF, "a\x00\r\nb\n", "a\x00\r\nb\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(service.NormalizeFileContent(dir, tt.path, tt.setting, []byte(tt.content)))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaging_StoresLFInIndex(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "tracked.txt", "one\ntwo\n", "Add tracked")
+	commitFile(t, dir, ".gitattributes", "*.bat -text\n", "Keep batch files as they are")
+
+	writeTestFile(t, dir, "tracked.txt", "one\r\ntwo\nthree\r\n")
+	writeTestFile(t, dir, "sub/new.txt", "x\r\ny\r\n")
+	writeTestFile(t, dir, "run.bat", "echo\r\n")
+
+	if err := service.StageFile(dir, "sub"); err != nil {
+		t.Fatalf("StageFile failed: %v", err)
+	}
+	if got := runGitOutput(t, dir, "show", ":sub/new.txt"); got != "x\ny\n" {
+		t.Errorf("staged sub/new.txt = %q", got)
+	}
+	if got := runGitOutput(t, dir, "show", ":tracked.txt"); got != "one\ntwo\n" {
+		t.Errorf("files outside the pathspec must not be staged, got %q", got)
+	}
+
+	if err := service.StageAll(dir); err != nil {
+		t.Fatalf("StageAll failed: %v", err)
+	}
+	if got := runGitOutput(t, dir, "show", ":tracked.txt"); got != "one\ntwo\nthree\n" {
+		t.Errorf("staged tracked.txt = %q", got)
+	}
+	if got := runGitOutput(t, dir, "show", ":run.bat"); got != "echo\r\n" {
+		t.Errorf("-text files must be staged as they are, got %q", got)
+	}
+	if got := readTestFile(t, dir, "tracked.txt"); got != "one\r\ntwo\nthree\r\n" {
+		t.Errorf("staging must not rewrite the working tree, got %q", got)
+	}
+}
+
+func TestStagePartial_StoresLFInIndex(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "file.txt", "one\ntwo\n", "Add file")
+	writeTestFile(t, dir, "file.txt", "one\ntwo\nthree\r\n")
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.StagePartial(dir, "file.txt", models.PartialStageRequest{Fingerprint: diff.Fingerprint, Hunks: []int{0}}); err != nil {
+		t.Fatalf("StagePartial failed: %v", err)
+	}
+	if got := runGitOutput(t, dir, "show", ":file.txt"); got != "one\ntwo\nthree\n" {
+		t.Errorf("staged file.txt = %q", got)
+	}
+}
+
+func TestDiffs_FlagLineEndingOnlyChanges(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "file.txt", "one\ntwo\n", "Add file")
+
+	writeTestFile(t, dir, "file.txt", "one\r\ntwo\r\n")
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.EOLOnly {
+		t.Errorf("expected CRLF conversion to be flagged as EOL-only")
+	}
+
+	runGit(t, dir, "add", "file.txt")
+	runGit(t, dir, "commit", "-m", "Convert to CRLF")
+	detail, err := service.GetCommitDetails(dir, runGitOutput(t, dir, "rev-parse", "HEAD")[:40], DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detail.Changes) != 1 || !detail.Changes[0].EOLOnly {
+		t.Errorf("expected commit change to be flagged as EOL-only, got %+v", detail.Changes)
+	}
+
+	writeTestFile(t, dir, "file.txt", "one\r\nthree\r\n")
+	diff, err = service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.EOLOnly {
+		t.Errorf("content changes must not be flagged as EOL-only")
+	}
+}
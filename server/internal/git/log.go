@@ -0,0 +1,344 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitweb/server/internal/models"
+)
+
+// LogOptions selects and pages the commits CommitLog returns.
+type LogOptions struct {
+	Ref          string // ref or range such as main..feature or main...feature; HEAD when empty
+	Path         string // only commits touching this path, following renames
+	Author       string // case-insensitive substring of "Name <email>"
+	Since        string // commits authored on or after this date (RFC 3339 or YYYY-MM-DD)
+	Until        string // commits authored on or before this date (RFC 3339 or YYYY-MM-DD)
+	Message      string // case-insensitive substring of the message, or a regexp with MessageRegex
+	MessageRegex bool
+	Cursor       string // from the previous page; the other options must not change
+	Limit        int    // commits per page; 50 when not positive
+}
+
+// logFilter is the parsed form of the LogOptions filters.
+type logFilter struct {
+	author  string
+	since   time.Time
+	until   time.Time
+	message func(string) bool
+}
+
+func (f *logFilter) match(commit models.Commit) bool {
+	if f.author != "" && !strings.Contains(strings.ToLower(commit.Author.Name+" <"+commit.Author.Email+">"), f.author) {
+		return false
+	}
+	if !f.since.IsZero() && commit.Date.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && commit.Date.After(f.until) {
+		return false
+	}
+	return f.message == nil || f.message(commit.Message)
+}
+
+// CommitLog lists the commits of a ref or range, newest first, one page at a
+// time. It returns the cursor of the next page, or "" on the last one. The
+// range is resolved to commit hashes on the first page and kept in the
+// cursor, so commits added in the meantime do not shift later pages.
+//
+// Filters are applied here rather than by git log, whose --follow loses the
+// file's earlier names when commits are skipped or filtered out.
+func (s *Service) CommitLog(ctx context.Context, repoPath string, opts LogOptions) ([]models.Commit, string, error) {
+	records, next, err := s.walkLog(ctx, repoPath, opts, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	commits := make([]models.Commit, 0, len(records))
+	for _, record := range records {
+		commits = append(commits, record.commit)
+	}
+	return commits, next, nil
+}
+
+// logRecord is one commit of the log, followed by the diff output asked for
+// by the extra git log arguments.
+type logRecord struct {
+	commit models.Commit
+	diff   string
+}
+
+// walkLog reads one page of the log for CommitLog and FileHistory, passing
+// diffArgs to git log.
+func (s *Service) walkLog(ctx context.Context, repoPath string, opts LogOptions, diffArgs []string) ([]logRecord, string, error) {
+	filter, err := parseLogFilter(opts)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var revs []string
+	offset := 0
+	if opts.Cursor != "" {
+		revs, offset, err = decodeLogCursor(opts.Cursor)
+	} else {
+		if strings.TrimSpace(opts.Ref) == "" && !s.hasCommits(repoPath) {
+			return nil, "", nil
+		}
+		revs, err = s.resolveRevisionRange(repoPath, opts.Ref)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The cursor's offset counts the commits git log listed before, matching
+	// or not, so git can skip them without formatting them. --follow only
+	// tracks renames through the commits it shows, though, so a path's log is
+	// read from the start and the offset skipped here.
+	args := []string{"log", "--format=%x1e%H%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%B%x1f"}
+	args = append(args, diffArgs...)
+	skip := 0
+	path := strings.Trim(opts.Path, "/")
+	if path == "" && offset > 0 {
+		args = append(args, "--skip="+strconv.Itoa(offset))
+	} else {
+		skip = offset
+	}
+	args = append(args, revs...)
+	if path != "" {
+		args = append(args, "--follow", "--", path)
+	} else {
+		args = append(args, "--")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := gitCommand(ctx, repoPath, nil, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get commit log: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, "", fmt.Errorf("failed to get commit log: %w", err)
+	}
+
+	var records []logRecord
+	listed := offset
+	more := false
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	scanner.Split(splitRecords)
+	for scanner.Scan() {
+		if skip > 0 {
+			skip--
+			continue
+		}
+		record, ok := parseLogRecord(scanner.Text())
+		if ok && filter.match(record.commit) {
+			if len(records) == limit {
+				// The next page starts at this commit
+				more = true
+				break
+			}
+			records = append(records, record)
+		}
+		listed++
+	}
+	scanErr := scanner.Err()
+
+	if more {
+		// The rest of the log is not needed
+		cancel()
+		cmd.Wait()
+	} else if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		return nil, "", fmt.Errorf("git log failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	if scanErr != nil && !more {
+		return nil, "", fmt.Errorf("failed to read commit log: %w", scanErr)
+	}
+
+	next := ""
+	if more {
+		next = encodeLogCursor(revs, listed)
+	}
+	return records, next, nil
+}
+
+// parseLogFilter validates the filters of opts.
+func parseLogFilter(opts LogOptions) (*logFilter, error) {
+	filter := &logFilter{author: strings.ToLower(strings.TrimSpace(opts.Author))}
+
+	var err error
+	if filter.since, err = parseLogDate(opts.Since, false); err != nil {
+		return nil, fmt.Errorf("%w: invalid since date %q", ErrInvalidLogOptions, opts.Since)
+	}
+	if filter.until, err = parseLogDate(opts.Until, true); err != nil {
+		return nil, fmt.Errorf("%w: invalid until date %q", ErrInvalidLogOptions, opts.Until)
+	}
+	if !filter.since.IsZero() && !filter.until.IsZero() && filter.until.Before(filter.since) {
+		return nil, fmt.Errorf("%w: until is before since", ErrInvalidLogOptions)
+	}
+
+	switch message := strings.TrimSpace(opts.Message); {
+	case message == "":
+	case opts.MessageRegex:
+		re, err := regexp.Compile("(?i)" + message)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid message regexp: %v", ErrInvalidLogOptions, err)
+		}
+		filter.message = re.MatchString
+	default:
+		message = strings.ToLower(message)
+		filter.message = func(s string) bool { return strings.Contains(strings.ToLower(s), message) }
+	}
+
+	return filter, nil
+}
+
+// parseLogDate parses an RFC 3339 time or a date. A date bounding the end of
+// a range covers the whole day.
+func parseLogDate(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// resolveRevisionRange resolves a ref or range to the commit hashes git log
+// walks, with excluded commits prefixed by ^.
+func (s *Service) resolveRevisionRange(repoPath, ref string) ([]string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("%w: invalid ref %q", ErrInvalidLogOptions, ref)
+	}
+
+	// The trailing -- makes rev-parse fail on anything that is not a revision
+	output, err := s.runGitCommand(repoPath, "rev-parse", "--end-of-options", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRevisionNotFound, ref)
+	}
+
+	var revs []string
+	for _, line := range strings.Split(output, "\n") {
+		if isHexHash(strings.TrimPrefix(line, "^")) {
+			revs = append(revs, line)
+		}
+	}
+	if len(revs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRevisionNotFound, ref)
+	}
+	return revs, nil
+}
+
+// hasCommits reports whether HEAD points to a commit, which it does not in a
+// repository without commits yet.
+func (s *Service) hasCommits(repoPath string) bool {
+	_, err := s.runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	return err == nil
+}
+
+func encodeLogCursor(revs []string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset) + " " + strings.Join(revs, " ")))
+}
+
+func decodeLogCursor(cursor string) ([]string, int, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", ErrInvalidLogOptions)
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, invalid
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return nil, 0, invalid
+	}
+	offset, err := strconv.Atoi(fields[0])
+	if err != nil || offset < 0 {
+		return nil, 0, invalid
+	}
+	for _, rev := range fields[1:] {
+		if !isHexHash(strings.TrimPrefix(rev, "^")) {
+			return nil, 0, invalid
+		}
+	}
+	return fields[1:], offset, nil
+}
+
+// splitRecords splits git log output on the record separator that starts
+// each commit.
+func splitRecords(data []byte, atEOF bool) (int, []byte, error) {
+	start := 0
+	if len(data) > 0 && data[0] == '\x1e' {
+		start = 1
+	}
+	for i := start; i < len(data); i++ {
+		if data[i] == '\x1e' {
+			return i, data[start:i], nil
+		}
+	}
+	if atEOF && len(data) > start {
+		return len(data), data[start:], nil
+	}
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return 0, nil, nil
+}
+
+// parseLogRecord parses one commit of the walkLog format.
+func parseLogRecord(record string) (logRecord, bool) {
+	fields := strings.SplitN(record, "\x1f", 7)
+	if len(fields) != 7 {
+		return logRecord{}, false
+	}
+
+	date, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return logRecord{}, false
+	}
+	parentHash := ""
+	if parents := strings.Fields(fields[1]); len(parents) > 0 {
+		parentHash = parents[0]
+	}
+
+	return logRecord{
+		commit: models.Commit{
+			Hash:    fields[0],
+			Message: strings.TrimSpace(fields[5]),
+			Author: models.Author{
+				Name:  fields[2],
+				Email: fields[3],
+			},
+			Date:       date,
+			ParentHash: parentHash,
+		},
+		diff: fields[6],
+	}, true
+}
@@ -0,0 +1,175 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// logMessages returns the first lines of the commits CommitLog returns, and
+// the next cursor.
+func logMessages(t *testing.T, service *Service, dir string, opts LogOptions) ([]string, string) {
+	t.Helper()
+
+	commits, next, err := service.CommitLog(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatalf("CommitLog(%+v) failed: %v", opts, err)
+	}
+	messages := make([]string, 0, len(commits))
+	for _, commit := range commits {
+		messages = append(messages, strings.SplitN(commit.Message, "\n", 2)[0])
+	}
+	return messages, next
+}
+
+func TestCommitLog_CursorPagination(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		commitFile(t, dir, name+".txt", name+"\n", "Add "+name)
+	}
+
+	page, next := logMessages(t, service, dir, LogOptions{Limit: 2})
+	if strings.Join(page, ",") != "Add d,Add c" || next == "" {
+		t.Fatalf("unexpected first page %q, next %q", page, next)
+	}
+
+	// Commits made meanwhile do not shift the next page
+	commitFile(t, dir, "e.txt", "e\n", "Add e")
+
+	page, next = logMessages(t, service, dir, LogOptions{Limit: 2, Cursor: next})
+	if strings.Join(page, ",") != "Add b,Add a" || next == "" {
+		t.Fatalf("unexpected second page %q, next %q", page, next)
+	}
+	page, next = logMessages(t, service, dir, LogOptions{Limit: 2, Cursor: next})
+	if strings.Join(page, ",") != "Initial commit" || next != "" {
+		t.Fatalf("unexpected last page %q, next %q", page, next)
+	}
+
+	if _, _, err := service.CommitLog(context.Background(), dir, LogOptions{Cursor: "not a cursor"}); !errors.Is(err, ErrInvalidLogOptions) {
+		t.Errorf("expected an invalid cursor error, got %v", err)
+	}
+}
+
+func TestCommitLog_PagesFilteredLog(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	for _, message := range []string{"Fix a", "Add b", "Add c", "Fix d", "Add e", "Fix f"} {
+		runGit(t, dir, "commit", "--allow-empty", "-m", message)
+	}
+
+	var pages []string
+	next := ""
+	for {
+		page, cursor := logMessages(t, service, dir, LogOptions{Message: "fix", Limit: 1, Cursor: next})
+		pages = append(pages, strings.Join(page, ","))
+		if cursor == "" {
+			break
+		}
+		next = cursor
+	}
+	if strings.Join(pages, "|") != "Fix f|Fix d|Fix a" {
+		t.Fatalf("unexpected pages %q", pages)
+	}
+}
+
+func TestCommitLog_PathFollowsRenames(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "old.txt", "one\ntwo\nthree\nfour\n", "Add old")
+	commitFile(t, dir, "other.txt", "other\n", "Touch other")
+	runGit(t, dir, "mv", "old.txt", "new.txt")
+	runGit(t, dir, "commit", "-m", "Rename to new")
+	commitFile(t, dir, "new.txt", "one\ntwo\nthree\nfour\nfive\n", "Edit new")
+
+	page, _ := logMessages(t, service, dir, LogOptions{Path: "new.txt"})
+	if strings.Join(page, ",") != "Edit new,Rename to new,Add old" {
+		t.Fatalf("expected the history across the rename, got %q", page)
+	}
+
+	// Paging and filtering keep following the rename
+	page, next := logMessages(t, service, dir, LogOptions{Path: "new.txt", Limit: 1, Cursor: ""})
+	page2, _ := logMessages(t, service, dir, LogOptions{Path: "new.txt", Limit: 2, Cursor: next})
+	if strings.Join(append(page, page2...), ",") != "Edit new,Rename to new,Add old" {
+		t.Errorf("expected paging to follow the rename, got %q then %q", page, page2)
+	}
+	page, _ = logMessages(t, service, dir, LogOptions{Path: "new.txt", Message: "ADD"})
+	if strings.Join(page, ",") != "Add old" {
+		t.Errorf("expected the filter to keep following the rename, got %q", page)
+	}
+}
+
+func TestCommitLog_Filters(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com", "commit", "--allow-empty", "-m", "Fix login bug", "--date=2024-01-10T12:00:00Z")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "Add feature #42", "--date=2024-02-10T12:00:00Z")
+	runGit(t, dir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com", "commit", "--allow-empty", "-m", "Fix crash", "--date=2024-03-10T12:00:00Z")
+
+	tests := []struct {
+		opts LogOptions
+		want string
+	}{
+		{LogOptions{Author: "alice"}, "Fix crash,Fix login bug"},
+		{LogOptions{Author: "ALICE@example"}, "Fix crash,Fix login bug"},
+		{LogOptions{Message: "fix"}, "Fix crash,Fix login bug"},
+		{LogOptions{Message: `#\d+`, MessageRegex: true}, "Add feature #42"},
+		{LogOptions{Since: "2024-02-01", Until: "2024-02-10"}, "Add feature #42"},
+		{LogOptions{Since: "2024-02-11T00:00:00Z", Author: "alice"}, "Fix crash"},
+	}
+	for _, tt := range tests {
+		if page, _ := logMessages(t, service, dir, tt.opts); strings.Join(page, ",") != tt.want {
+			t.Errorf("CommitLog(%+v) = %q, want %q", tt.opts, page, tt.want)
+		}
+	}
+
+	invalid := []LogOptions{
+		{Since: "last tuesday"},
+		{Since: "2024-03-01", Until: "2024-02-01"},
+		{Message: "(", MessageRegex: true},
+		{Ref: "--all"},
+	}
+	for _, opts := range invalid {
+		if _, _, err := service.CommitLog(context.Background(), dir, opts); !errors.Is(err, ErrInvalidLogOptions) {
+			t.Errorf("CommitLog(%+v) = %v, want an invalid log options error", opts, err)
+		}
+	}
+}
+
+func TestCommitLog_RefsAndRanges(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "base.txt", "base\n", "Base")
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "feature.txt", "feature\n", "Feature work")
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "main.txt", "main\n", "Main work")
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"feature", "Base,Feature work,Initial commit"},
+		{"main..feature", "Feature work"},
+		{"main...feature", "Feature work,Main work"},
+	}
+	for _, tt := range tests {
+		// Commits made within the same second have no defined order
+		page, _ := logMessages(t, service, dir, LogOptions{Ref: tt.ref})
+		if sort.Strings(page); strings.Join(page, ",") != tt.want {
+			t.Errorf("CommitLog(%s) = %q, want %q", tt.ref, page, tt.want)
+		}
+	}
+
+	if _, _, err := service.CommitLog(context.Background(), dir, LogOptions{Ref: "main..missing"}); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("expected revision not found, got %v", err)
+	}
+
+	empty := t.TempDir()
+	runGit(t, empty, "init", "-b", "main")
+	if page, next := logMessages(t, service, empty, LogOptions{}); len(page) != 0 || next != "" {
+		t.Errorf("expected no commits in an empty repository, got %q", page)
+	}
+}
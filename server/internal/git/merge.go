@@ -0,0 +1,156 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Merge strategies accepted by Service.Merge.
+const (
+	MergeFastForwardOnly = "ff-only"
+	MergeNoFastForward   = "no-ff"
+	MergeSquash          = "squash"
+)
+
+// Merge result statuses.
+const (
+	MergeStatusUpToDate    = "up-to-date"
+	MergeStatusFastForward = "fast-forward"
+	MergeStatusMerged      = "merged"
+	MergeStatusSquashed    = "squashed"
+	MergeStatusConflicted  = "conflicted"
+)
+
+// IsValidMergeStrategy reports whether strategy is one of the supported merge strategies.
+func IsValidMergeStrategy(strategy string) bool {
+	switch strategy {
+	case MergeFastForwardOnly, MergeNoFastForward, MergeSquash:
+		return true
+	default:
+		return false
+	}
+}
+
+// Merge merges source (a branch, tag or commit) into the current branch.
+// When the merge stops on conflicts the repository is left in the merging state
+// and the result lists the conflicted paths instead of returning an error.
+func (s *Service) Merge(repoPath, source, strategy string) (*models.MergeResult, error) {
+	if !IsValidMergeStrategy(strategy) {
+		return nil, fmt.Errorf("unsupported merge strategy: %s", strategy)
+	}
+
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	sourceHash, err := repo.ResolveRevision(plumbing.Revision(source))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMergeSourceNotFound, source)
+	}
+
+	sourceCommit, err := repo.CommitObject(*sourceHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source commit: %w", err)
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	// Nothing to do when the source is already reachable from HEAD.
+	if isAncestor, err := sourceCommit.IsAncestor(headCommit); err == nil && isAncestor {
+		return &models.MergeResult{
+			Status:    MergeStatusUpToDate,
+			Hash:      head.Hash().String(),
+			Conflicts: []string{},
+		}, nil
+	}
+
+	args := []string{"merge"}
+	switch strategy {
+	case MergeFastForwardOnly:
+		args = append(args, "--ff-only")
+	case MergeNoFastForward:
+		args = append(args, "--no-ff", "--no-edit")
+	case MergeSquash:
+		args = append(args, "--squash")
+	}
+	args = append(args, source)
+
+	if _, err := s.runGitCommand(repoPath, args...); err != nil {
+		conflicts, conflictErr := s.unmergedPaths(repoPath)
+		if conflictErr == nil && len(conflicts) > 0 {
+			return &models.MergeResult{
+				Status:    MergeStatusConflicted,
+				Conflicts: conflicts,
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to merge %s: %w", source, err)
+	}
+
+	status := MergeStatusMerged
+	switch strategy {
+	case MergeFastForwardOnly:
+		status = MergeStatusFastForward
+	case MergeSquash:
+		// git merge --squash only stages the result; record it as a single commit.
+		// The source's changes may already be on HEAD, e.g. cherry-picked, and
+		// then there is nothing to commit.
+		if _, err := s.runGitCommand(repoPath, "diff", "--cached", "--quiet"); err == nil {
+			os.Remove(filepath.Join(gitDir(repoPath), "SQUASH_MSG"))
+			return &models.MergeResult{
+				Status:    MergeStatusUpToDate,
+				Hash:      head.Hash().String(),
+				Conflicts: []string{},
+			}, nil
+		}
+		if _, err := s.runGitCommand(repoPath, "commit", "--no-edit"); err != nil {
+			return nil, fmt.Errorf("failed to commit squashed merge: %w", err)
+		}
+		status = MergeStatusSquashed
+	}
+
+	newHead, err := s.headHash(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MergeResult{
+		Status:    status,
+		Hash:      newHead,
+		Conflicts: []string{},
+	}, nil
+}
+
+// squashMergeInProgress reports whether the merge in progress is a squash
+// merge that stopped on conflicts: git leaves SQUASH_MSG but no MERGE_HEAD.
+func squashMergeInProgress(repoPath string) bool {
+	dir := gitDir(repoPath)
+	if _, err := os.Stat(filepath.Join(dir, "MERGE_HEAD")); err == nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, "SQUASH_MSG"))
+	return err == nil
+}
+
+// headHash returns the commit hash HEAD currently points to.
+func (s *Service) headHash(repoPath string) (string, error) {
+	output, err := s.runGitCommand(repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return strings.TrimSpace(output), nil
+}
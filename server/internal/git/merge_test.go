@@ -0,0 +1,224 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestMerge_FastForwardOnly(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "feature.txt", "feature\n", "Add feature")
+	runGit(t, dir, "checkout", "main")
+
+	result, err := service.Merge(dir, "feature", MergeFastForwardOnly)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.Status != MergeStatusFastForward {
+		t.Fatalf("expected status %q, got %q", MergeStatusFastForward, result.Status)
+	}
+
+	featureHead, err := service.runGitCommand(dir, "rev-parse", "feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Hash != strings.TrimSpace(featureHead) {
+		t.Fatalf("expected HEAD %s after fast-forward, got %s", strings.TrimSpace(featureHead), result.Hash)
+	}
+}
+
+func TestMerge_FastForwardOnlyRejectsDivergedBranches(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "feature.txt", "feature\n", "Add feature")
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "main.txt", "main\n", "Add main")
+
+	if _, err := service.Merge(dir, "feature", MergeFastForwardOnly); err == nil {
+		t.Fatal("expected ff-only merge of diverged branches to fail")
+	}
+}
+
+func TestMerge_NoFastForwardCreatesMergeCommit(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "feature.txt", "feature\n", "Add feature")
+	runGit(t, dir, "checkout", "main")
+
+	result, err := service.Merge(dir, "feature", MergeNoFastForward)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.Status != MergeStatusMerged {
+		t.Fatalf("expected status %q, got %q", MergeStatusMerged, result.Status)
+	}
+
+	parents, err := service.runGitCommand(dir, "rev-list", "--parents", "-n", "1", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := strings.Fields(parents); len(fields) != 3 {
+		t.Fatalf("expected merge commit with two parents, got %q", parents)
+	}
+}
+
+func TestMerge_SquashCreatesSingleParentCommit(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "a.txt", "a\n", "Add a")
+	commitFile(t, dir, "b.txt", "b\n", "Add b")
+	runGit(t, dir, "checkout", "main")
+
+	result, err := service.Merge(dir, "feature", MergeSquash)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.Status != MergeStatusSquashed {
+		t.Fatalf("expected status %q, got %q", MergeStatusSquashed, result.Status)
+	}
+
+	parents, err := service.runGitCommand(dir, "rev-list", "--parents", "-n", "1", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := strings.Fields(parents); len(fields) != 2 {
+		t.Fatalf("expected squash commit with one parent, got %q", parents)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s to exist after squash merge: %v", name, err)
+		}
+	}
+}
+
+func TestMerge_ReportsConflictedPaths(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "README.md", "# Feature\n", "Edit on feature")
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "README.md", "# Main\n", "Edit on main")
+
+	result, err := service.Merge(dir, "feature", MergeNoFastForward)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.Status != MergeStatusConflicted {
+		t.Fatalf("expected status %q, got %q", MergeStatusConflicted, result.Status)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != "README.md" {
+		t.Fatalf("expected README.md conflict, got %v", result.Conflicts)
+	}
+}
+
+func TestMerge_UpToDate(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "branch", "feature")
+
+	result, err := service.Merge(dir, "feature", MergeNoFastForward)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.Status != MergeStatusUpToDate {
+		t.Fatalf("expected status %q, got %q", MergeStatusUpToDate, result.Status)
+	}
+}
+
+func TestMerge_SquashWithNothingToCommit(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "a.txt", "a\n", "Add a")
+	runGit(t, dir, "checkout", "main")
+	runGit(t, dir, "cherry-pick", "feature")
+	head := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	result, err := service.Merge(dir, "feature", MergeSquash)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.Status != MergeStatusUpToDate || result.Hash != head {
+		t.Fatalf("expected status %q at %s, got %+v", MergeStatusUpToDate, head, result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "SQUASH_MSG")); !os.IsNotExist(err) {
+		t.Fatalf("expected no squash message left behind, got %v", err)
+	}
+}
+
+func TestMerge_InvalidStrategyAndSource(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	if _, err := service.Merge(dir, "main", "octopus"); err == nil {
+		t.Fatal("expected error for unsupported strategy")
+	}
+	if _, err := service.Merge(dir, "does-not-exist", MergeNoFastForward); err == nil || !strings.Contains(err.Error(), "merge source not found") {
+		t.Fatalf("expected merge source not found error, got %v", err)
+	}
+}
+
+func TestMerge_SquashConflictContinueAndAbort(t *testing.T) {
+	service := NewService()
+	squashConflict := func() string {
+		dir := newCLITestRepo(t)
+		runGit(t, dir, "checkout", "-b", "feature")
+		commitFile(t, dir, "README.md", "# Feature\n", "Feature readme")
+		runGit(t, dir, "checkout", "main")
+		commitFile(t, dir, "README.md", "# Main\n", "Main readme")
+
+		result, err := service.Merge(dir, "feature", MergeSquash)
+		if err != nil || result.Status != MergeStatusConflicted {
+			t.Fatalf("expected a conflicted squash merge, got %+v, %v", result, err)
+		}
+		if op := inProgressOperation(dir); op != OperationMerge {
+			t.Fatalf("expected a merge in progress, got %q", op)
+		}
+		return dir
+	}
+
+	dir := squashConflict()
+	head := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+	if err := service.AbortMerge(dir); err != nil {
+		t.Fatalf("AbortMerge failed: %v", err)
+	}
+	if op := inProgressOperation(dir); op != "" {
+		t.Errorf("expected no operation after aborting, got %q", op)
+	}
+	if status := runGitOutput(t, dir, "status", "--porcelain"); status != "" || strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD")) != head {
+		t.Errorf("expected the pre-merge state back, got status %q", status)
+	}
+
+	dir = squashConflict()
+	if err := service.ResolveConflict(dir, "README.md", models.ResolveConflictRequest{Resolution: ResolveTheirs}); err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+	result, err := service.ContinueMerge(dir)
+	if err != nil {
+		t.Fatalf("ContinueMerge failed: %v", err)
+	}
+	if result.Status != MergeStatusSquashed {
+		t.Errorf("expected status %q, got %+v", MergeStatusSquashed, result)
+	}
+	if parents := strings.Fields(runGitOutput(t, dir, "rev-list", "--parents", "-n", "1", "HEAD")); len(parents) != 2 {
+		t.Errorf("expected a single-parent commit, got %v", parents)
+	}
+	if op := inProgressOperation(dir); op != "" {
+		t.Errorf("expected no operation after continuing, got %q", op)
+	}
+}
@@ -0,0 +1,282 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitweb/server/internal/models"
+)
+
+// Sides a line range can select from
+const (
+	DiffSideOld = "old"
+	DiffSideNew = "new"
+)
+
+// StagePartial stages the selected hunks and lines of the unstaged diff of a file.
+// The selection refers to the tokenized diff returned for staged=false with the
+// default diff options, and is rejected when that diff's fingerprint no longer
+// matches.
+func (s *Service) StagePartial(repoPath, filePath string, req models.PartialStageRequest) error {
+	diffText, err := s.GetUnstagedDiffUsingGitDiff(repoPath, filePath, DiffOptions{})
+	if err != nil {
+		return err
+	}
+	if diffFingerprint(diffText) != req.Fingerprint {
+		return ErrStaleDiff
+	}
+
+	parsed, _ := parseDiffContent(diffText)
+	selected, allSelected, err := selectDiffLines(parsed, req)
+	if err != nil {
+		return err
+	}
+
+	base, inIndex, err := s.blobContent(repoPath, ":"+filePath)
+	if err != nil {
+		return err
+	}
+
+	fullPath := filepath.Join(repoPath, filePath)
+	info, statErr := os.Stat(fullPath)
+	if os.IsNotExist(statErr) && allSelected {
+		_, err := s.runGitCommand(repoPath, "rm", "--cached", "--quiet", "--", filePath)
+		return err
+	}
+
+	mode := "100644"
+	if inIndex {
+		if mode, err = s.indexEntryMode(repoPath, filePath); err != nil {
+			return err
+		}
+	} else if statErr == nil && info.Mode()&0111 != 0 {
+		mode = "100755"
+	}
+
+	content := applyDiffSelection(base, parsed, func(i int) bool { return selected[i] })
+	return s.writeIndexEntry(repoPath, filePath, mode, content)
+}
+
+// UnstagePartial removes the selected hunks and lines of the staged diff of a
+// file from the index, leaving the working tree untouched. The selection refers
+// to the tokenized diff returned for staged=true with the default diff options.
+func (s *Service) UnstagePartial(repoPath, filePath string, req models.PartialStageRequest) error {
+	diffText, err := s.GetStagedDiffUsingGitDiff(repoPath, filePath, DiffOptions{})
+	if err != nil {
+		return err
+	}
+	if diffFingerprint(diffText) != req.Fingerprint {
+		return ErrStaleDiff
+	}
+
+	parsed, _ := parseDiffContent(diffText)
+	selected, allSelected, err := selectDiffLines(parsed, req)
+	if err != nil {
+		return err
+	}
+
+	base, inHead, err := s.blobContent(repoPath, "HEAD:"+filePath)
+	if err != nil {
+		return err
+	}
+	if !inHead && allSelected {
+		_, err := s.runGitCommand(repoPath, "rm", "--cached", "--quiet", "--", filePath)
+		return err
+	}
+
+	var mode string
+	if s.isFileTracked(repoPath, filePath) {
+		mode, err = s.indexEntryMode(repoPath, filePath)
+	} else {
+		mode, err = s.headEntryMode(repoPath, filePath)
+	}
+	if err != nil {
+		return err
+	}
+
+	content := applyDiffSelection(base, parsed, func(i int) bool { return !selected[i] })
+	return s.writeIndexEntry(repoPath, filePath, mode, content)
+}
+
+// selectDiffLines resolves hunk indexes and line ranges to the indexes of the
+// added and deleted lines they cover. It also reports whether every change in
+// the diff was selected.
+func selectDiffLines(parsed []rawDiffLine, req models.PartialStageRequest) (map[int]bool, bool, error) {
+	totalHunks := 0
+	for _, dl := range parsed {
+		if dl.lineType == "header" {
+			totalHunks++
+		}
+	}
+
+	hunks := make(map[int]bool)
+	for _, h := range req.Hunks {
+		if h < 0 || h >= totalHunks {
+			return nil, false, fmt.Errorf("%w: hunk index %d out of range", ErrInvalidSelection, h)
+		}
+		hunks[h] = true
+	}
+
+	ranges := make([]models.DiffLineRange, 0, len(req.Lines))
+	for _, lr := range req.Lines {
+		if lr.End == 0 {
+			lr.End = lr.Start
+		}
+		if lr.Side != DiffSideOld && lr.Side != DiffSideNew {
+			return nil, false, fmt.Errorf("%w: invalid line range side %q", ErrInvalidSelection, lr.Side)
+		}
+		if lr.Start <= 0 || lr.End < lr.Start {
+			return nil, false, fmt.Errorf("%w: invalid line range %d-%d", ErrInvalidSelection, lr.Start, lr.End)
+		}
+		ranges = append(ranges, lr)
+	}
+
+	selected := make(map[int]bool)
+	changes := 0
+	hunk := -1
+	for i, dl := range parsed {
+		var side string
+		var num int
+		switch dl.lineType {
+		case "header":
+			hunk++
+			continue
+		case "deleted":
+			side, num = DiffSideOld, dl.oldNum
+		case "added":
+			side, num = DiffSideNew, dl.newNum
+		default:
+			continue
+		}
+		changes++
+
+		if hunks[hunk] {
+			selected[i] = true
+			continue
+		}
+		for _, lr := range ranges {
+			if lr.Side == side && num >= lr.Start && num <= lr.End {
+				selected[i] = true
+				break
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, false, fmt.Errorf("%w: no changes selected", ErrInvalidSelection)
+	}
+	return selected, len(selected) == changes, nil
+}
+
+// applyDiffSelection rebuilds the new side of a diff from the old content,
+// applying only the added and deleted lines for which apply returns true.
+// Skipped deletions keep the old line and skipped additions are dropped.
+func applyDiffSelection(base string, parsed []rawDiffLine, apply func(int) bool) string {
+	oldLines := strings.SplitAfter(base, "\n")
+	if len(oldLines) > 0 && oldLines[len(oldLines)-1] == "" {
+		oldLines = oldLines[:len(oldLines)-1]
+	}
+
+	var out strings.Builder
+	writeLine := func(line string) {
+		if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+			out.WriteString("\n")
+		}
+		out.WriteString(line)
+	}
+
+	next := 0 // number of old lines already consumed
+	copyThrough := func(lineNum int) {
+		for ; next < lineNum && next < len(oldLines); next++ {
+			writeLine(oldLines[next])
+		}
+	}
+
+	for i, dl := range parsed {
+		switch dl.lineType {
+		case "header":
+			var oldStart, oldCount int
+			if n, _ := fmt.Sscanf(dl.content, "@@ -%d,%d", &oldStart, &oldCount); n == 2 && oldCount == 0 {
+				// Pure insertion: the hunk goes after line oldStart
+				copyThrough(oldStart)
+			} else {
+				copyThrough(oldStart - 1)
+			}
+		case "context":
+			copyThrough(dl.oldNum)
+		case "deleted":
+			copyThrough(dl.oldNum - 1)
+			if apply(i) {
+				next = dl.oldNum
+			} else {
+				copyThrough(dl.oldNum)
+			}
+		case "added":
+			if apply(i) {
+				line := dl.content
+				if !dl.noEOL {
+					line += "\n"
+				}
+				writeLine(line)
+			}
+		}
+	}
+	copyThrough(len(oldLines))
+
+	return out.String()
+}
+
+// blobContent returns the content of the blob named by spec (e.g. "HEAD:path"
+// or ":path" for the index), and whether it exists.
+func (s *Service) blobContent(repoPath, spec string) (string, bool, error) {
+	if _, err := s.runGitCommand(repoPath, "cat-file", "-e", spec); err != nil {
+		return "", false, nil
+	}
+	content, err := s.runGitCommand(repoPath, "cat-file", "blob", spec)
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+// indexEntryMode returns the file mode of the stage-0 index entry for a path.
+func (s *Service) indexEntryMode(repoPath, filePath string) (string, error) {
+	output, err := s.runGitCommand(repoPath, "ls-files", "--stage", "--", filePath)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("path is not in the index: %s", filePath)
+	}
+	return fields[0], nil
+}
+
+// headEntryMode returns the file mode of a path in the HEAD tree.
+func (s *Service) headEntryMode(repoPath, filePath string) (string, error) {
+	output, err := s.runGitCommand(repoPath, "ls-tree", "HEAD", "--", filePath)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("path is not in HEAD: %s", filePath)
+	}
+	return fields[0], nil
+}
+
+// writeIndexEntry stores content as a blob and points the index entry for
+// filePath at it. The content is converted as staging the whole file would.
+func (s *Service) writeIndexEntry(repoPath, filePath, mode, content string) error {
+	args := append(append([]string{}, stagingConfig...), "hash-object", "-w", "--stdin", "--path="+filePath)
+	output, err := s.runGitCommandWithInput(repoPath, content, args...)
+	if err != nil {
+		return err
+	}
+	hash := strings.TrimSpace(output)
+
+	_, err = s.runGitCommand(repoPath, "update-index", "--add", "--cacheinfo", fmt.Sprintf("%s,%s,%s", mode, hash, filePath))
+	return err
+}
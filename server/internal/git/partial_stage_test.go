@@ -0,0 +1,242 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+// newPartialStageRepo commits a 20-line file and changes lines 2 and 18 in the
+// working tree, producing two separate hunks.
+func newPartialStageRepo(t *testing.T) (*Service, string) {
+	t.Helper()
+
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "file.txt", numberedLines(20, nil), "Add file")
+	writeTestFile(t, dir, "file.txt", numberedLines(20, map[int]string{2: "changed 2", 18: "changed 18"}))
+
+	return NewService(), dir
+}
+
+func TestStagePartial_Hunk(t *testing.T) {
+	service, dir := newPartialStageRepo(t)
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+	if diff.TotalHunks != 2 {
+		t.Fatalf("expected 2 hunks, got %d", diff.TotalHunks)
+	}
+
+	err = service.StagePartial(dir, "file.txt", models.PartialStageRequest{
+		Fingerprint: diff.Fingerprint,
+		Hunks:       []int{1},
+	})
+	if err != nil {
+		t.Fatalf("StagePartial failed: %v", err)
+	}
+
+	staged := runGitOutput(t, dir, "show", ":file.txt")
+	if staged != numberedLines(20, map[int]string{18: "changed 18"}) {
+		t.Errorf("unexpected staged content:\n%s", staged)
+	}
+	unstaged := runGitOutput(t, dir, "diff", "--", "file.txt")
+	if !strings.Contains(unstaged, "+changed 2") || strings.Contains(unstaged, "changed 18") {
+		t.Errorf("expected only the first hunk to remain unstaged, got:\n%s", unstaged)
+	}
+	if readTestFile(t, dir, "file.txt") != numberedLines(20, map[int]string{2: "changed 2", 18: "changed 18"}) {
+		t.Error("working tree should be untouched")
+	}
+}
+
+func TestStagePartial_Lines(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "file.txt", "a\nb\nc\n", "Add file")
+	writeTestFile(t, dir, "file.txt", "a\nB\nnew 1\nnew 2\nc\n")
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+
+	// Stage the replacement of "b" and the first inserted line only.
+	err = service.StagePartial(dir, "file.txt", models.PartialStageRequest{
+		Fingerprint: diff.Fingerprint,
+		Lines: []models.DiffLineRange{
+			{Side: DiffSideOld, Start: 2},
+			{Side: DiffSideNew, Start: 2, End: 3},
+		},
+	})
+	if err != nil {
+		t.Fatalf("StagePartial failed: %v", err)
+	}
+
+	if staged := runGitOutput(t, dir, "show", ":file.txt"); staged != "a\nB\nnew 1\nc\n" {
+		t.Errorf("unexpected staged content: %q", staged)
+	}
+}
+
+func TestStagePartial_KeepsUnselectedDeletion(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "file.txt", "a\nb\nc\n", "Add file")
+	writeTestFile(t, dir, "file.txt", "a\nc\nd\n")
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+
+	err = service.StagePartial(dir, "file.txt", models.PartialStageRequest{
+		Fingerprint: diff.Fingerprint,
+		Lines:       []models.DiffLineRange{{Side: DiffSideNew, Start: 3}},
+	})
+	if err != nil {
+		t.Fatalf("StagePartial failed: %v", err)
+	}
+
+	if staged := runGitOutput(t, dir, "show", ":file.txt"); staged != "a\nb\nc\nd\n" {
+		t.Errorf("unexpected staged content: %q", staged)
+	}
+}
+
+func TestStagePartial_UntrackedFile(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	writeTestFile(t, dir, "new.txt", "one\ntwo\nthree")
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "new.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+
+	err = service.StagePartial(dir, "new.txt", models.PartialStageRequest{
+		Fingerprint: diff.Fingerprint,
+		Lines:       []models.DiffLineRange{{Side: DiffSideNew, Start: 1, End: 2}},
+	})
+	if err != nil {
+		t.Fatalf("StagePartial failed: %v", err)
+	}
+
+	if staged := runGitOutput(t, dir, "show", ":new.txt"); staged != "one\ntwo\n" {
+		t.Errorf("unexpected staged content: %q", staged)
+	}
+}
+
+func TestStagePartial_RejectsStaleFingerprint(t *testing.T) {
+	service, dir := newPartialStageRepo(t)
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+
+	writeTestFile(t, dir, "file.txt", numberedLines(20, map[int]string{2: "changed again"}))
+
+	err = service.StagePartial(dir, "file.txt", models.PartialStageRequest{
+		Fingerprint: diff.Fingerprint,
+		Hunks:       []int{0},
+	})
+	if !errors.Is(err, ErrStaleDiff) {
+		t.Fatalf("expected stale diff error, got %v", err)
+	}
+	if staged := runGitOutput(t, dir, "diff", "--cached"); staged != "" {
+		t.Errorf("index should be untouched, got:\n%s", staged)
+	}
+}
+
+func TestStagePartial_InvalidSelection(t *testing.T) {
+	service, dir := newPartialStageRepo(t)
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", false, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  models.PartialStageRequest
+		want string
+	}{
+		{"hunk out of range", models.PartialStageRequest{Hunks: []int{5}}, "out of range"},
+		{"bad side", models.PartialStageRequest{Lines: []models.DiffLineRange{{Side: "both", Start: 1}}}, "invalid line range"},
+		{"context only", models.PartialStageRequest{Lines: []models.DiffLineRange{{Side: DiffSideNew, Start: 5, End: 10}}}, "no changes selected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Fingerprint = diff.Fingerprint
+			err := service.StagePartial(dir, "file.txt", tt.req)
+			if !errors.Is(err, ErrInvalidSelection) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestUnstagePartial_Hunk(t *testing.T) {
+	service, dir := newPartialStageRepo(t)
+	runGit(t, dir, "add", "file.txt")
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "file.txt", true, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+
+	err = service.UnstagePartial(dir, "file.txt", models.PartialStageRequest{
+		Fingerprint: diff.Fingerprint,
+		Hunks:       []int{0},
+	})
+	if err != nil {
+		t.Fatalf("UnstagePartial failed: %v", err)
+	}
+
+	staged := runGitOutput(t, dir, "show", ":file.txt")
+	if staged != numberedLines(20, map[int]string{18: "changed 18"}) {
+		t.Errorf("unexpected staged content:\n%s", staged)
+	}
+	if readTestFile(t, dir, "file.txt") != numberedLines(20, map[int]string{2: "changed 2", 18: "changed 18"}) {
+		t.Error("working tree should be untouched")
+	}
+}
+
+func TestUnstagePartial_NewFileRemovesEntry(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	writeTestFile(t, dir, "new.txt", "one\n")
+	runGit(t, dir, "add", "new.txt")
+
+	diff, err := service.TokenizeDiffFromPatch(dir, "new.txt", true, 0, 50, DiffOptions{})
+	if err != nil {
+		t.Fatalf("TokenizeDiffFromPatch failed: %v", err)
+	}
+
+	err = service.UnstagePartial(dir, "new.txt", models.PartialStageRequest{
+		Fingerprint: diff.Fingerprint,
+		Hunks:       []int{0},
+	})
+	if err != nil {
+		t.Fatalf("UnstagePartial failed: %v", err)
+	}
+
+	if tracked := runGitOutput(t, dir, "ls-files", "--", "new.txt"); tracked != "" {
+		t.Errorf("expected new.txt to leave the index, got %q", tracked)
+	}
+}
+
+func TestApplyDiffSelection_MissingNewlineAtEOF(t *testing.T) {
+	diffText := "--- a/f\n+++ b/f\n@@ -1 +1,2 @@\n-a\n\\ No newline at end of file\n+a\n+b\n"
+	parsed, _ := parseDiffContent(diffText)
+
+	// Keep the old last line and add only "b".
+	got := applyDiffSelection("a", parsed, func(i int) bool {
+		return parsed[i].lineType == "added" && parsed[i].content == "b"
+	})
+	if got != "a\nb\n" {
+		t.Errorf("expected %q, got %q", "a\nb\n", got)
+	}
+}
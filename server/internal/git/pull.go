@@ -0,0 +1,189 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Pull strategies, matching RepoSyncSettings.PullStrategy.
+const (
+	PullMerge           = "merge"
+	PullRebase          = "rebase"
+	PullFastForwardOnly = "fast-forward"
+)
+
+// PullStatusRebased reports a pull that replayed local commits onto the upstream.
+const PullStatusRebased = "rebased"
+
+// IsValidPullStrategy reports whether strategy is one of the supported pull strategies.
+func IsValidPullStrategy(strategy string) bool {
+	switch strategy {
+	case PullMerge, PullRebase, PullFastForwardOnly:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pull fetches the upstream of the current branch and integrates it using
+// strategy. The upstream is the given remote branch (refSpec), or the branch's
+// configured upstream, or the same-named branch on the remote. Merge creates a
+// merge commit when the branches have diverged, rebase replays local commits
+// onto the upstream, and fast-forward refuses to pull a diverged branch.
+func (s *Service) Pull(repoPath, remote, refSpec, strategy string) (*models.PullResult, error) {
+	return s.PullContext(context.Background(), repoPath, remote, refSpec, strategy, nil)
+}
+
+// PullContext is Pull with the fetch bounded by ctx and its progress output
+// written to progress when it is not nil. Cancelling ctx stops the pull
+// before it starts integrating; a merge or rebase that has begun runs to
+// completion, so the branch is never left half integrated.
+func (s *Service) PullContext(ctx context.Context, repoPath, remote, refSpec, strategy string, progress io.Writer) (*models.PullResult, error) {
+	if strategy == "" {
+		strategy = PullMerge
+	}
+	if !IsValidPullStrategy(strategy) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPullStrategy, strategy)
+	}
+	if op := inProgressOperation(repoPath); op != "" {
+		return nil, fmt.Errorf("%s %w", op, ErrOperationInProgress)
+	}
+
+	remote, branch, err := s.pullUpstream(repoPath, remote, refSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.fetch(ctx, repoPath, remote, false, progress); err != nil {
+		return nil, fmt.Errorf("failed to pull: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	upstream := plumbing.NewRemoteReferenceName(remote, branch).String()
+	if _, err := s.runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", upstream); err != nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrRemoteBranchNotFound, remote, branch)
+	}
+
+	ahead, behind, err := s.aheadBehind(repoPath, "HEAD", upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PullResult{
+		Strategy:  strategy,
+		Upstream:  remote + "/" + branch,
+		Ahead:     ahead,
+		Behind:    behind,
+		Conflicts: []string{},
+	}
+
+	if behind == 0 {
+		result.Status = MergeStatusUpToDate
+		result.Hash, err = s.headHash(repoPath)
+		return result, err
+	}
+
+	var args []string
+	switch {
+	case ahead == 0 || strategy == PullFastForwardOnly:
+		if ahead > 0 {
+			return nil, fmt.Errorf("%w from %s (%d ahead, %d behind); pull with merge or rebase instead",
+				ErrDiverged, result.Upstream, ahead, behind)
+		}
+		args = []string{"merge", "--ff-only", upstream}
+		result.Status = MergeStatusFastForward
+	case strategy == PullRebase:
+		args = []string{"rebase", upstream}
+		result.Status = PullStatusRebased
+	default:
+		args = []string{"merge", "--no-ff", "--no-edit", upstream}
+		result.Status = MergeStatusMerged
+	}
+
+	if _, err := s.runGitCommand(repoPath, args...); err != nil {
+		conflicts, conflictErr := s.unmergedPaths(repoPath)
+		if conflictErr == nil && len(conflicts) > 0 {
+			result.Status = MergeStatusConflicted
+			result.Conflicts = conflicts
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to pull: %w", withLocalChanges(err))
+	}
+
+	result.Hash, err = s.headHash(repoPath)
+	return result, err
+}
+
+// pullUpstream resolves the remote and remote branch a pull integrates.
+func (s *Service) pullUpstream(repoPath, remote, refSpec string) (string, string, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return "", "", fmt.Errorf("cannot pull with a %w", ErrDetachedHead)
+	}
+
+	branch := head.Name().Short()
+	if cfg, err := repo.Config(); err == nil {
+		if tracking, ok := cfg.Branches[branch]; ok {
+			if remote == "" {
+				remote = tracking.Remote
+			}
+			if refSpec == "" && tracking.Merge.IsBranch() && tracking.Remote == remote {
+				branch = tracking.Merge.Short()
+			}
+		}
+	}
+	if remote == "" {
+		remote = defaultRemote
+	}
+
+	if refSpec != "" {
+		src, _, _ := strings.Cut(strings.TrimPrefix(refSpec, "+"), ":")
+		name := plumbing.ReferenceName(fullRefName(src))
+		if !name.IsBranch() || name.Validate() != nil {
+			return "", "", fmt.Errorf("%w: %q", ErrInvalidRefSpec, refSpec)
+		}
+		branch = name.Short()
+	}
+
+	return remote, branch, nil
+}
+
+// aheadBehind counts the commits only reachable from local and only reachable
+// from upstream.
+func (s *Service) aheadBehind(repoPath, local, upstream string) (int, int, error) {
+	output, err := s.runGitCommand(repoPath, "rev-list", "--left-right", "--count", local+"..."+upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", output)
+	}
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
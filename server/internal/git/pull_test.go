@@ -0,0 +1,147 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newDivergedPullRepo returns a repository whose main branch tracks
+// origin/main, with one local commit and one new commit on the remote.
+func newDivergedPullRepo(t *testing.T, localPath, localContent, remotePath, remoteContent string) string {
+	t.Helper()
+
+	dir := newCLITestRepo(t)
+	bare := newBareRemote(t, dir, "origin")
+	runGit(t, dir, "branch", "--set-upstream-to", "origin/main")
+
+	other := t.TempDir()
+	runGit(t, other, "clone", "-b", "main", bare, ".")
+	runGit(t, other, "config", "user.email", "test@example.com")
+	runGit(t, other, "config", "user.name", "Test User")
+	commitFile(t, other, remotePath, remoteContent, "Remote change")
+	runGit(t, other, "push", "origin", "main")
+
+	commitFile(t, dir, localPath, localContent, "Local change")
+	return dir
+}
+
+func TestPull_MergeCreatesMergeCommit(t *testing.T) {
+	service := NewService()
+	dir := newDivergedPullRepo(t, "local.txt", "local\n", "remote.txt", "remote\n")
+
+	result, err := service.Pull(dir, "", "", PullMerge)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if result.Status != MergeStatusMerged || result.Ahead != 1 || result.Behind != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Upstream != "origin/main" {
+		t.Errorf("expected upstream origin/main, got %q", result.Upstream)
+	}
+
+	parents := runGitOutput(t, dir, "rev-list", "--parents", "-n", "1", "HEAD")
+	if fields := strings.Fields(parents); len(fields) != 3 {
+		t.Fatalf("expected merge commit with two parents, got %q", parents)
+	}
+}
+
+func TestPull_RebaseReplaysLocalCommits(t *testing.T) {
+	service := NewService()
+	dir := newDivergedPullRepo(t, "local.txt", "local\n", "remote.txt", "remote\n")
+
+	result, err := service.Pull(dir, "", "", PullRebase)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if result.Status != PullStatusRebased {
+		t.Fatalf("expected status %q, got %+v", PullStatusRebased, result)
+	}
+
+	if parent := runGitOutput(t, dir, "rev-parse", "HEAD~1"); parent != runGitOutput(t, dir, "rev-parse", "origin/main") {
+		t.Error("expected local commit to be replayed on top of origin/main")
+	}
+	if merges := runGitOutput(t, dir, "rev-list", "--merges", "HEAD"); merges != "" {
+		t.Errorf("expected linear history, found merge commits %q", merges)
+	}
+	if result.Hash != strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD")) {
+		t.Error("expected result hash to match the rebased HEAD")
+	}
+}
+
+func TestPull_FastForwardOnlyRejectsDivergedBranch(t *testing.T) {
+	service := NewService()
+	dir := newDivergedPullRepo(t, "local.txt", "local\n", "remote.txt", "remote\n")
+	before := runGitOutput(t, dir, "rev-parse", "HEAD")
+
+	_, err := service.Pull(dir, "", "", PullFastForwardOnly)
+	if !errors.Is(err, ErrDiverged) || !strings.Contains(err.Error(), "1 ahead, 1 behind") {
+		t.Fatalf("expected diverged error with ahead/behind counts, got %v", err)
+	}
+	if runGitOutput(t, dir, "rev-parse", "HEAD") != before {
+		t.Error("rejected pull must not move HEAD")
+	}
+}
+
+func TestPull_FastForwardsWhenOnlyBehind(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	bare := newBareRemote(t, dir, "origin")
+
+	other := t.TempDir()
+	runGit(t, other, "clone", "-b", "main", bare, ".")
+	runGit(t, other, "config", "user.email", "test@example.com")
+	runGit(t, other, "config", "user.name", "Test User")
+	commitFile(t, other, "remote.txt", "remote\n", "Remote change")
+	runGit(t, other, "push", "origin", "main")
+
+	for _, strategy := range []string{PullFastForwardOnly, PullMerge} {
+		result, err := service.Pull(dir, "origin", "main", strategy)
+		if err != nil {
+			t.Fatalf("Pull(%s) failed: %v", strategy, err)
+		}
+		if strategy == PullFastForwardOnly && result.Status != MergeStatusFastForward {
+			t.Fatalf("expected fast-forward, got %+v", result)
+		}
+		if strategy == PullMerge && result.Status != MergeStatusUpToDate {
+			t.Fatalf("expected up-to-date on second pull, got %+v", result)
+		}
+	}
+	if runGitOutput(t, dir, "rev-parse", "HEAD") != runGitOutput(t, bare, "rev-parse", "main") {
+		t.Error("expected HEAD to match the remote after pulling")
+	}
+}
+
+func TestPull_ReportsConflicts(t *testing.T) {
+	service := NewService()
+
+	for _, strategy := range []string{PullMerge, PullRebase} {
+		t.Run(strategy, func(t *testing.T) {
+			dir := newDivergedPullRepo(t, "README.md", "local\n", "README.md", "remote\n")
+			result, err := service.Pull(dir, "", "", strategy)
+			if err != nil {
+				t.Fatalf("Pull failed: %v", err)
+			}
+			if result.Status != MergeStatusConflicted || len(result.Conflicts) != 1 || result.Conflicts[0] != "README.md" {
+				t.Fatalf("expected README.md conflict, got %+v", result)
+			}
+			if _, err := service.Pull(dir, "", "", strategy); !errors.Is(err, ErrOperationInProgress) {
+				t.Fatalf("expected pull to refuse while %s is in progress, got %v", strategy, err)
+			}
+		})
+	}
+}
+
+func TestPull_InvalidStrategyAndMissingBranch(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	newBareRemote(t, dir, "origin")
+
+	if _, err := service.Pull(dir, "", "", "octopus"); !errors.Is(err, ErrUnsupportedPullStrategy) {
+		t.Fatalf("expected unsupported strategy error, got %v", err)
+	}
+	if _, err := service.Pull(dir, "origin", "missing", PullMerge); !errors.Is(err, ErrRemoteBranchNotFound) {
+		t.Fatalf("expected remote branch not found, got %v", err)
+	}
+}
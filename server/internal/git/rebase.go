@@ -0,0 +1,285 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Rebase plan actions.
+const (
+	RebasePick   = "pick"
+	RebaseReword = "reword"
+	RebaseSquash = "squash"
+	RebaseFixup  = "fixup"
+	RebaseDrop   = "drop"
+)
+
+// Rebase result statuses.
+const (
+	RebaseStatusCompleted  = "completed"
+	RebaseStatusConflicted = "conflicted"
+	RebaseStatusStopped    = "stopped"
+	RebaseStatusAborted    = "aborted"
+)
+
+// rebaseStateDir holds files referenced by the generated todo list (commit
+// messages for reworded and squashed commits). It lives in the git directory
+// so it survives server restarts for as long as the rebase is in progress.
+const rebaseStateDir = "gitty-rebase"
+
+// IsValidRebaseAction reports whether action is a supported rebase plan action.
+func IsValidRebaseAction(action string) bool {
+	switch action {
+	case RebasePick, RebaseReword, RebaseSquash, RebaseFixup, RebaseDrop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Rebase runs an interactive rebase that replays the plan's steps, in order,
+// onto the given base. Commits left out of the plan are dropped. The rebase
+// pauses on conflicts; use ContinueRebase, SkipRebase or AbortRebase afterwards.
+func (s *Service) Rebase(repoPath string, req models.RebaseRequest) (*models.RebaseResult, error) {
+	if op := inProgressOperation(repoPath); op != "" {
+		return nil, fmt.Errorf("%s %w", op, ErrOperationInProgress)
+	}
+	if len(req.Steps) == 0 {
+		return nil, fmt.Errorf("%w: no steps", ErrInvalidRebasePlan)
+	}
+
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	onto, err := repo.ResolveRevision(plumbing.Revision(req.Onto))
+	if err != nil {
+		return nil, fmt.Errorf("%w: base not found: %s", ErrInvalidRebasePlan, req.Onto)
+	}
+
+	stateDir := filepath.Join(gitDir(repoPath), rebaseStateDir)
+	if err := os.RemoveAll(stateDir); err != nil {
+		return nil, fmt.Errorf("failed to reset rebase state: %w", err)
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create rebase state: %w", err)
+	}
+
+	var todo strings.Builder
+	picked := false
+	for i, step := range req.Steps {
+		if !IsValidRebaseAction(step.Action) {
+			return nil, fmt.Errorf("%w: unknown action %s", ErrInvalidRebasePlan, step.Action)
+		}
+
+		hash, err := repo.ResolveRevision(plumbing.Revision(step.Hash))
+		if err != nil {
+			return nil, fmt.Errorf("%w: commit not found: %s", ErrInvalidRebasePlan, step.Hash)
+		}
+		if _, err := repo.CommitObject(*hash); err != nil {
+			return nil, fmt.Errorf("%w: commit not found: %s", ErrInvalidRebasePlan, step.Hash)
+		}
+
+		switch step.Action {
+		case RebaseSquash, RebaseFixup:
+			if !picked {
+				return nil, fmt.Errorf("%w: cannot %s without a previous commit", ErrInvalidRebasePlan, step.Action)
+			}
+		case RebaseReword:
+			if strings.TrimSpace(step.Message) == "" {
+				return nil, fmt.Errorf("%w: reword of %s needs a message", ErrInvalidRebasePlan, step.Hash)
+			}
+			picked = true
+		case RebasePick:
+			picked = true
+		}
+
+		// Reword is executed as a pick followed by an amend so the new message
+		// can be supplied without an interactive editor.
+		action := step.Action
+		if action == RebaseReword {
+			action = RebasePick
+		}
+
+		if step.Action == RebaseDrop || strings.TrimSpace(step.Message) == "" {
+			fmt.Fprintf(&todo, "%s %s\n", action, hash)
+			continue
+		}
+		msgPath := filepath.Join(stateDir, fmt.Sprintf("%d.msg", i))
+		if err := os.WriteFile(msgPath, []byte(step.Message), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write commit message: %w", err)
+		}
+		// The amend only runs when the step moved HEAD. A step skipped after a
+		// conflict leaves HEAD on the previous commit, whose message must stay.
+		headPath := filepath.Join(stateDir, fmt.Sprintf("%d.head", i))
+		fmt.Fprintf(&todo, "exec git rev-parse HEAD >%s\n", ShellQuote(headPath))
+		fmt.Fprintf(&todo, "%s %s\n", action, hash)
+		fmt.Fprintf(&todo, "exec test \"$(git rev-parse HEAD)\" = \"$(cat %s)\" || git commit --amend --only --allow-empty --quiet -F %s\n",
+			ShellQuote(headPath), ShellQuote(msgPath))
+	}
+
+	if !picked {
+		return nil, fmt.Errorf("%w: it must keep at least one commit", ErrInvalidRebasePlan)
+	}
+
+	todoPath := filepath.Join(stateDir, "todo")
+	if err := os.WriteFile(todoPath, []byte(todo.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write rebase plan: %w", err)
+	}
+
+	// git passes the path of its generated todo list to the sequence editor;
+	// replacing that file with ours executes the plan as written.
+	env := []string{"GIT_SEQUENCE_EDITOR=cp " + ShellQuote(todoPath)}
+	_, err = s.runGitCommandWithEnv(repoPath, env, "rebase", "--interactive", "--no-autosquash", onto.String())
+	return s.rebaseResult(repoPath, err)
+}
+
+// ContinueRebase resumes a paused rebase after conflicts have been resolved.
+func (s *Service) ContinueRebase(repoPath string) (*models.RebaseResult, error) {
+	if inProgressOperation(repoPath) != OperationRebase {
+		return nil, fmt.Errorf("%s %w", OperationRebase, ErrNoOperation)
+	}
+
+	conflicts, err := s.GetConflicts(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return &models.RebaseResult{
+			Status:    RebaseStatusConflicted,
+			Conflicts: conflicts,
+			Progress:  readRebaseProgress(repoPath),
+		}, nil
+	}
+
+	_, err = s.runGitCommand(repoPath, "rebase", "--continue")
+	return s.rebaseResult(repoPath, err)
+}
+
+// SkipRebase drops the commit the rebase stopped at and resumes with the next step.
+func (s *Service) SkipRebase(repoPath string) (*models.RebaseResult, error) {
+	if inProgressOperation(repoPath) != OperationRebase {
+		return nil, fmt.Errorf("%s %w", OperationRebase, ErrNoOperation)
+	}
+
+	_, err := s.runGitCommand(repoPath, "rebase", "--skip")
+	return s.rebaseResult(repoPath, err)
+}
+
+// AbortRebase abandons a rebase and restores the branch to its original state.
+func (s *Service) AbortRebase(repoPath string) (*models.RebaseResult, error) {
+	if inProgressOperation(repoPath) != OperationRebase {
+		return nil, fmt.Errorf("%s %w", OperationRebase, ErrNoOperation)
+	}
+
+	if _, err := s.runGitCommand(repoPath, "rebase", "--abort"); err != nil {
+		return nil, fmt.Errorf("failed to abort rebase: %w", err)
+	}
+	os.RemoveAll(filepath.Join(gitDir(repoPath), rebaseStateDir))
+
+	hash, err := s.headHash(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RebaseResult{
+		Status:    RebaseStatusAborted,
+		Hash:      hash,
+		Conflicts: []models.ConflictFile{},
+	}, nil
+}
+
+// GetRebaseProgress reports the position of an in-progress rebase, or nil when
+// no rebase is running.
+func (s *Service) GetRebaseProgress(repoPath string) *models.RebaseProgress {
+	if inProgressOperation(repoPath) != OperationRebase {
+		return nil
+	}
+	return readRebaseProgress(repoPath)
+}
+
+// rebaseResult turns the outcome of a git rebase invocation into a result. A
+// failed invocation that leaves the rebase in progress means it paused.
+func (s *Service) rebaseResult(repoPath string, runErr error) (*models.RebaseResult, error) {
+	if inProgressOperation(repoPath) == OperationRebase {
+		conflicts, err := s.GetConflicts(repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		result := &models.RebaseResult{
+			Status:    RebaseStatusConflicted,
+			Conflicts: conflicts,
+			Progress:  readRebaseProgress(repoPath),
+		}
+		if len(conflicts) == 0 {
+			result.Status = RebaseStatusStopped
+			if runErr != nil {
+				result.Message = runErr.Error()
+			}
+		}
+		return result, nil
+	}
+
+	os.RemoveAll(filepath.Join(gitDir(repoPath), rebaseStateDir))
+
+	if runErr != nil {
+		return nil, fmt.Errorf("failed to rebase: %w", withLocalChanges(runErr))
+	}
+
+	hash, err := s.headHash(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RebaseResult{
+		Status:    RebaseStatusCompleted,
+		Hash:      hash,
+		Conflicts: []models.ConflictFile{},
+	}, nil
+}
+
+// readRebaseProgress reads the state git keeps in rebase-merge in the git
+// directory.
+func readRebaseProgress(repoPath string) *models.RebaseProgress {
+	stateDir := filepath.Join(gitDir(repoPath), "rebase-merge")
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(stateDir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(content))
+	}
+
+	progress := &models.RebaseProgress{
+		Branch:  strings.TrimPrefix(read("head-name"), "refs/heads/"),
+		Onto:    read("onto"),
+		Current: read("stopped-sha"),
+	}
+	progress.Done = countRebaseSteps(read("done"))
+	progress.Total = progress.Done + countRebaseSteps(read("git-rebase-todo"))
+
+	return progress
+}
+
+// countRebaseSteps counts the plan steps in a todo list. The exec lines that
+// apply edited messages are not steps of the plan.
+func countRebaseSteps(todo string) int {
+	count := 0
+	for _, line := range strings.Split(todo, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "exec ") {
+			continue
+		}
+		count++
+	}
+	return count
+}
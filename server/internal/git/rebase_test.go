@@ -0,0 +1,226 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+// newRebaseRepo creates main with one commit and a feature branch with three
+// commits on top of it. It returns the feature commit hashes in order.
+func newRebaseRepo(t *testing.T) (*Service, string, []string) {
+	t.Helper()
+
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-b", "feature")
+
+	var hashes []string
+	for _, name := range []string{"a", "b", "c"} {
+		commitFile(t, dir, name+".txt", name+"\n", "Add "+name)
+		hash, err := service.headHash(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return service, dir, hashes
+}
+
+func TestRebase_ExecutesPlan(t *testing.T) {
+	service, dir, hashes := newRebaseRepo(t)
+
+	result, err := service.Rebase(dir, models.RebaseRequest{
+		Onto: "main",
+		Steps: []models.RebaseStep{
+			{Action: RebaseReword, Hash: hashes[2], Message: "Add c first"},
+			{Action: RebasePick, Hash: hashes[0]},
+			{Action: RebaseFixup, Hash: hashes[1]},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Rebase failed: %v", err)
+	}
+	if result.Status != RebaseStatusCompleted {
+		t.Fatalf("expected completed rebase, got %+v", result)
+	}
+
+	subjects := logSubjects(t, dir, "main..HEAD")
+	if want := []string{"Add c first", "Add a"}; strings.Join(subjects, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected commits %v, got %v", want, subjects)
+	}
+	files := runGitOutput(t, dir, "show", "--name-only", "--format=", "HEAD")
+	if !strings.Contains(files, "a.txt") || !strings.Contains(files, "b.txt") {
+		t.Fatalf("expected fixup to fold b.txt into the last commit, got %q", files)
+	}
+}
+
+func TestRebase_DropAndSquashMessage(t *testing.T) {
+	service, dir, hashes := newRebaseRepo(t)
+
+	result, err := service.Rebase(dir, models.RebaseRequest{
+		Onto: "main",
+		Steps: []models.RebaseStep{
+			{Action: RebasePick, Hash: hashes[0]},
+			{Action: RebaseSquash, Hash: hashes[1], Message: "Add a and b"},
+			{Action: RebaseDrop, Hash: hashes[2]},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Rebase failed: %v", err)
+	}
+	if result.Status != RebaseStatusCompleted {
+		t.Fatalf("expected completed rebase, got %+v", result)
+	}
+	if subjects := logSubjects(t, dir, "main..HEAD"); len(subjects) != 1 || subjects[0] != "Add a and b" {
+		t.Fatalf("unexpected commits after squash: %v", subjects)
+	}
+}
+
+func TestRebase_PausesOnConflictsAndContinues(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "README.md", "# Feature\n", "Edit on feature")
+	featureHash, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "README.md", "# Main\n", "Edit on main")
+	runGit(t, dir, "checkout", "feature")
+
+	result, err := service.Rebase(dir, models.RebaseRequest{
+		Onto:  "main",
+		Steps: []models.RebaseStep{{Action: RebaseReword, Hash: featureHash, Message: "Reworded"}},
+	})
+	if err != nil {
+		t.Fatalf("Rebase failed: %v", err)
+	}
+	if result.Status != RebaseStatusConflicted || len(result.Conflicts) != 1 {
+		t.Fatalf("expected conflicted rebase, got %+v", result)
+	}
+	if result.Progress == nil || result.Progress.Branch != "feature" || result.Progress.Total != 1 {
+		t.Fatalf("unexpected progress: %+v", result.Progress)
+	}
+
+	if _, err := service.Rebase(dir, models.RebaseRequest{Onto: "main", Steps: []models.RebaseStep{{Action: RebasePick, Hash: featureHash}}}); err == nil {
+		t.Fatal("expected error when a rebase is already in progress")
+	}
+
+	if err := service.ResolveConflict(dir, "README.md", models.ResolveConflictRequest{Resolution: ResolveTheirs}); err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+
+	result, err = service.ContinueRebase(dir)
+	if err != nil {
+		t.Fatalf("ContinueRebase failed: %v", err)
+	}
+	if result.Status != RebaseStatusCompleted {
+		t.Fatalf("expected completed rebase, got %+v", result)
+	}
+	if subjects := logSubjects(t, dir, "main..HEAD"); len(subjects) != 1 || subjects[0] != "Reworded" {
+		t.Fatalf("expected reword to apply after continue, got %v", subjects)
+	}
+}
+
+func TestRebase_Abort(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "README.md", "# Feature\n", "Edit on feature")
+	featureHash, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "README.md", "# Main\n", "Edit on main")
+	runGit(t, dir, "checkout", "feature")
+
+	if _, err := service.Rebase(dir, models.RebaseRequest{Onto: "main", Steps: []models.RebaseStep{{Action: RebasePick, Hash: featureHash}}}); err != nil {
+		t.Fatalf("Rebase failed: %v", err)
+	}
+
+	result, err := service.AbortRebase(dir)
+	if err != nil {
+		t.Fatalf("AbortRebase failed: %v", err)
+	}
+	if result.Status != RebaseStatusAborted || result.Hash != featureHash {
+		t.Fatalf("expected abort to restore %s, got %+v", featureHash, result)
+	}
+	if service.GetRebaseProgress(dir) != nil {
+		t.Fatal("expected no rebase in progress after abort")
+	}
+}
+
+func TestRebase_InvalidPlans(t *testing.T) {
+	service, dir, hashes := newRebaseRepo(t)
+
+	tests := []struct {
+		name  string
+		req   models.RebaseRequest
+		error string
+	}{
+		{name: "empty plan", req: models.RebaseRequest{Onto: "main"}, error: "no steps"},
+		{name: "unknown base", req: models.RebaseRequest{Onto: "nope", Steps: []models.RebaseStep{{Action: RebasePick, Hash: hashes[0]}}}, error: "base not found"},
+		{name: "unknown action", req: models.RebaseRequest{Onto: "main", Steps: []models.RebaseStep{{Action: "edit", Hash: hashes[0]}}}, error: "unknown action"},
+		{name: "unknown commit", req: models.RebaseRequest{Onto: "main", Steps: []models.RebaseStep{{Action: RebasePick, Hash: "deadbeef"}}}, error: "commit not found"},
+		{name: "reword without message", req: models.RebaseRequest{Onto: "main", Steps: []models.RebaseStep{{Action: RebaseReword, Hash: hashes[0], Message: " "}}}, error: "needs a message"},
+		{name: "leading squash", req: models.RebaseRequest{Onto: "main", Steps: []models.RebaseStep{{Action: RebaseSquash, Hash: hashes[0]}}}, error: "without a previous commit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Rebase(dir, tt.req); !errors.Is(err, ErrInvalidRebasePlan) || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestRebase_RefusesLocalChanges(t *testing.T) {
+	service, dir, hashes := newRebaseRepo(t)
+	writeTestFile(t, dir, "a.txt", "local\n")
+
+	_, err := service.Rebase(dir, models.RebaseRequest{Onto: "main", Steps: []models.RebaseStep{{Action: RebasePick, Hash: hashes[0]}}})
+	if !errors.Is(err, ErrLocalChanges) {
+		t.Fatalf("expected local changes error, got %v", err)
+	}
+	if op := inProgressOperation(dir); op != "" {
+		t.Fatalf("expected no rebase in progress, got %q", op)
+	}
+}
+
+func TestRebase_SkipConflictedRewordKeepsPreviousMessage(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-b", "feature")
+	commitFile(t, dir, "a.txt", "a\n", "Add a")
+	commitFile(t, dir, "README.md", "# Feature\n", "Change readme")
+	hashes := strings.Fields(runGitOutput(t, dir, "rev-list", "--reverse", "main..feature"))
+	runGit(t, dir, "checkout", "main")
+	commitFile(t, dir, "README.md", "# Main\n", "Main readme")
+	runGit(t, dir, "checkout", "feature")
+
+	result, err := service.Rebase(dir, models.RebaseRequest{
+		Onto: "main",
+		Steps: []models.RebaseStep{
+			{Action: RebasePick, Hash: hashes[0]},
+			{Action: RebaseReword, Hash: hashes[1], Message: "new B msg"},
+		},
+	})
+	if err != nil || result.Status != RebaseStatusConflicted {
+		t.Fatalf("expected the reworded commit to conflict, got %+v, %v", result, err)
+	}
+
+	result, err = service.SkipRebase(dir)
+	if err != nil || result.Status != RebaseStatusCompleted {
+		t.Fatalf("expected the rebase to complete after skipping, got %+v, %v", result, err)
+	}
+	if subjects := logSubjects(t, dir, "main..HEAD"); len(subjects) != 1 || subjects[0] != "Add a" {
+		t.Errorf("expected only the untouched first commit, got %v", subjects)
+	}
+}
@@ -0,0 +1,259 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// defaultRemote is used by push, pull and fetch when no remote is given.
+const defaultRemote = "origin"
+
+// AddRemote registers a new remote with the default fetch refspec.
+func (s *Service) AddRemote(repoPath, name, url string) (*models.RepoRemote, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if err := validateRemoteName(name); err != nil {
+		return nil, err
+	}
+	if err := validateRemoteURL(url); err != nil {
+		return nil, err
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+	if err != nil {
+		if errors.Is(err, git.ErrRemoteExists) {
+			return nil, fmt.Errorf("%w: %s", ErrRemoteExists, name)
+		}
+		return nil, fmt.Errorf("failed to add remote: %w", err)
+	}
+
+	return &models.RepoRemote{Name: name, URL: url}, nil
+}
+
+// UpdateRemote renames a remote, changes its URL and/or chooses its
+// credential. Renaming goes through the git CLI so remote-tracking branches
+// and branch upstreams follow along.
+func (s *Service) UpdateRemote(repoPath, name string, req models.UpdateRemoteRequest) (*models.RepoRemote, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRemoteNotFound, name)
+	}
+	if req.URL != "" {
+		if err := validateRemoteURL(req.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Name != "" && req.Name != name {
+		if err := validateRemoteName(req.Name); err != nil {
+			return nil, err
+		}
+		if _, err := repo.Remote(req.Name); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRemoteExists, req.Name)
+		}
+		if _, err := s.runGitCommand(repoPath, "remote", "rename", name, req.Name); err != nil {
+			return nil, err
+		}
+		name = req.Name
+	}
+
+	if req.URL != "" {
+		if _, err := s.runGitCommand(repoPath, "remote", "set-url", "--", name, req.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Credential != nil {
+		if err := s.SetRemoteCredential(repoPath, name, *req.Credential); err != nil {
+			return nil, err
+		}
+	}
+
+	url := req.URL
+	if url == "" && len(remote.Config().URLs) > 0 {
+		url = remote.Config().URLs[0]
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return &models.RepoRemote{Name: name, URL: url, Credential: remoteCredential(cfg, name)}, nil
+}
+
+// RemoveRemote deletes a remote together with its remote-tracking branches.
+func (s *Service) RemoveRemote(repoPath, name string) error {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if _, err := repo.Remote(name); err != nil {
+		return fmt.Errorf("%w: %s", ErrRemoteNotFound, name)
+	}
+
+	_, err = s.runGitCommand(repoPath, "remote", "remove", name)
+	return err
+}
+
+// Fetch fetches one remote, or every configured remote when remote is empty.
+// With prune, remote-tracking refs whose branch is gone on the remote are deleted.
+// When fetching every remote, one failing does not stop the others: its error
+// is reported in its result, and Fetch only fails when no remote could be
+// fetched. FetchError tells whether any remote failed.
+func (s *Service) Fetch(repoPath, remote string, prune bool) (*models.FetchResult, error) {
+	return s.FetchContext(context.Background(), repoPath, remote, prune)
+}
+
+// FetchContext is Fetch bounded by ctx; cancelling it aborts the transfer.
+func (s *Service) FetchContext(ctx context.Context, repoPath, remote string, prune bool) (*models.FetchResult, error) {
+	return s.fetch(ctx, repoPath, remote, prune, nil)
+}
+
+func (s *Service) fetch(ctx context.Context, repoPath, remote string, prune bool, progress io.Writer) (*models.FetchResult, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	names := []string{remote}
+	if remote == "" {
+		remotes, err := repo.Remotes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get remotes: %w", err)
+		}
+		names = names[:0]
+		for _, r := range remotes {
+			names = append(names, r.Config().Name)
+		}
+	}
+
+	result := &models.FetchResult{Remotes: []models.RemoteFetchResult{}}
+	var failures []string
+	for _, name := range names {
+		upToDate, err := s.fetchRemote(ctx, repo, name, prune, progress)
+		if err != nil {
+			if remote != "" || ctx.Err() != nil {
+				return nil, err
+			}
+			failures = append(failures, err.Error())
+			result.Remotes = append(result.Remotes, models.RemoteFetchResult{Remote: name, Error: err.Error()})
+			continue
+		}
+		result.Remotes = append(result.Remotes, models.RemoteFetchResult{Remote: name, UpToDate: upToDate})
+	}
+	if len(failures) > 0 && len(failures) == len(names) {
+		return nil, errors.New(strings.Join(failures, "; "))
+	}
+
+	return result, nil
+}
+
+// fetchRemote fetches the remote name, reporting whether it was up to date.
+func (s *Service) fetchRemote(ctx context.Context, repo *git.Repository, name string, prune bool, progress io.Writer) (bool, error) {
+	auth, err := s.remoteAuth(repo, name)
+	if err != nil {
+		return false, err
+	}
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: name,
+		Prune:      prune,
+		Progress:   progress,
+		Auth:       auth,
+	})
+	if err == git.NoErrAlreadyUpToDate {
+		return true, nil
+	}
+	if err != nil {
+		if errors.Is(err, git.ErrRemoteNotFound) {
+			return false, fmt.Errorf("%w: %s", ErrRemoteNotFound, name)
+		}
+		return false, fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+	return false, nil
+}
+
+// FetchError returns the errors of the remotes a fetch could not fetch,
+// combined, or nil when every remote was fetched.
+func FetchError(result *models.FetchResult) error {
+	var failures []string
+	for _, remote := range result.Remotes {
+		if remote.Error != "" {
+			failures = append(failures, remote.Error)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(failures, "; "))
+}
+
+// validateRemoteName rejects names git would not accept as a remote.
+func validateRemoteName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t:/\\") ||
+		plumbing.NewRemoteReferenceName(name, "HEAD").Validate() != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidRemoteName, name)
+	}
+	return nil
+}
+
+// validateRemoteURL rejects an empty URL and one git would take for an option.
+func validateRemoteURL(url string) error {
+	if url == "" {
+		return ErrRemoteURLRequired
+	}
+	if strings.HasPrefix(url, "-") {
+		return fmt.Errorf("%w: %q", ErrInvalidRemoteURL, url)
+	}
+	return nil
+}
+
+// expandPushRefSpec turns the short forms accepted by git push ("main",
+// "main:release", "+main") into a full refspec go-git can use.
+func expandPushRefSpec(refSpec string) (config.RefSpec, error) {
+	force := strings.HasPrefix(refSpec, "+")
+	refSpec = strings.TrimPrefix(refSpec, "+")
+
+	src, dst, found := strings.Cut(refSpec, ":")
+	if !found {
+		dst = src
+	}
+	if src == "" || dst == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRefSpec, refSpec)
+	}
+
+	spec := fullRefName(src) + ":" + fullRefName(dst)
+	if force {
+		spec = "+" + spec
+	}
+	result := config.RefSpec(spec)
+	if err := result.Validate(); err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRefSpec, refSpec)
+	}
+	return result, nil
+}
+
+// fullRefName qualifies a short branch name with refs/heads/.
+func fullRefName(name string) string {
+	if strings.HasPrefix(name, "refs/") {
+		return name
+	}
+	return plumbing.NewBranchReferenceName(name).String()
+}
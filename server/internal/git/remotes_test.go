@@ -0,0 +1,183 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestRemotes_AddUpdateRemove(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	bare := newBareRemote(t, dir, "origin")
+	runGit(t, dir, "fetch", "origin")
+
+	if _, err := service.AddRemote(dir, "upstream", "https://example.com/upstream.git"); err != nil {
+		t.Fatalf("AddRemote failed: %v", err)
+	}
+	if _, err := service.AddRemote(dir, "upstream", "https://example.com/other.git"); !errors.Is(err, ErrRemoteExists) {
+		t.Fatalf("expected duplicate remote error, got %v", err)
+	}
+	if _, err := service.AddRemote(dir, "bad name", "https://example.com/x.git"); !errors.Is(err, ErrInvalidRemoteName) {
+		t.Fatalf("expected invalid name error, got %v", err)
+	}
+	if _, err := service.AddRemote(dir, "flag", "--upload-pack=touch /tmp/pwned"); !errors.Is(err, ErrInvalidRemoteURL) {
+		t.Fatalf("expected invalid url error, got %v", err)
+	}
+
+	remote, err := service.UpdateRemote(dir, "origin", models.UpdateRemoteRequest{Name: "fork"})
+	if err != nil {
+		t.Fatalf("UpdateRemote failed: %v", err)
+	}
+	if remote.Name != "fork" || remote.URL != bare {
+		t.Errorf("unexpected remote after rename: %+v", remote)
+	}
+	if !refExists(dir, "refs/remotes/fork/main") || refExists(dir, "refs/remotes/origin/main") {
+		t.Error("expected remote-tracking branches to follow the rename")
+	}
+
+	if _, err := service.UpdateRemote(dir, "upstream", models.UpdateRemoteRequest{URL: "https://example.com/moved.git"}); err != nil {
+		t.Fatalf("UpdateRemote failed: %v", err)
+	}
+	if url := strings.TrimSpace(runGitOutput(t, dir, "remote", "get-url", "upstream")); url != "https://example.com/moved.git" {
+		t.Errorf("expected url to change, got %q", url)
+	}
+	if _, err := service.UpdateRemote(dir, "fork", models.UpdateRemoteRequest{Name: "upstream"}); !errors.Is(err, ErrRemoteExists) {
+		t.Fatalf("expected rename collision error, got %v", err)
+	}
+	if _, err := service.UpdateRemote(dir, "upstream", models.UpdateRemoteRequest{URL: "--push"}); !errors.Is(err, ErrInvalidRemoteURL) {
+		t.Fatalf("expected invalid url error, got %v", err)
+	}
+
+	if err := service.RemoveRemote(dir, "fork"); err != nil {
+		t.Fatalf("RemoveRemote failed: %v", err)
+	}
+	if refExists(dir, "refs/remotes/fork/main") {
+		t.Error("expected remote-tracking branches to be removed")
+	}
+	if err := service.RemoveRemote(dir, "fork"); !errors.Is(err, ErrRemoteNotFound) {
+		t.Fatalf("expected remote not found, got %v", err)
+	}
+
+	remotes, err := service.GetRemotes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 1 || remotes[0].Name != "upstream" {
+		t.Errorf("expected only upstream to remain, got %+v", remotes)
+	}
+}
+
+func TestFetch_AllRemotesWithPrune(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	originBare := newBareRemote(t, dir, "origin")
+	newBareRemote(t, dir, "upstream")
+
+	runGit(t, dir, "push", "origin", "main:feature")
+	result, err := service.Fetch(dir, "", false)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(result.Remotes) != 2 {
+		t.Fatalf("expected both remotes to be fetched, got %+v", result.Remotes)
+	}
+	if !refExists(dir, "refs/remotes/origin/feature") {
+		t.Fatal("expected origin/feature after fetch")
+	}
+
+	runGit(t, originBare, "branch", "-D", "feature")
+
+	if _, err := service.Fetch(dir, "origin", false); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if !refExists(dir, "refs/remotes/origin/feature") {
+		t.Fatal("fetch without prune should keep origin/feature")
+	}
+
+	if _, err := service.Fetch(dir, "origin", true); err != nil {
+		t.Fatalf("Fetch with prune failed: %v", err)
+	}
+	if refExists(dir, "refs/remotes/origin/feature") {
+		t.Error("expected prune to delete origin/feature")
+	}
+
+	if _, err := service.Fetch(dir, "missing", false); !errors.Is(err, ErrRemoteNotFound) {
+		t.Fatalf("expected remote not found, got %v", err)
+	}
+}
+
+func TestFetch_AllRemotesKeepsGoingOnFailure(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "remote", "add", "broken", t.TempDir()+"/missing.git")
+	newBareRemote(t, dir, "origin")
+	runGit(t, dir, "push", "origin", "main:feature")
+
+	result, err := service.Fetch(dir, "", false)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if !refExists(dir, "refs/remotes/origin/feature") {
+		t.Fatal("expected origin to be fetched despite broken failing")
+	}
+	failed := map[string]bool{}
+	for _, remote := range result.Remotes {
+		failed[remote.Remote] = remote.Error != ""
+	}
+	if len(failed) != 2 || !failed["broken"] || failed["origin"] {
+		t.Fatalf("expected only broken to fail, got %+v", result.Remotes)
+	}
+	if err := FetchError(result); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected FetchError to report broken, got %v", err)
+	}
+
+	runGit(t, dir, "remote", "remove", "origin")
+	if _, err := service.Fetch(dir, "", false); err == nil || !strings.Contains(err.Error(), "failed to fetch broken") {
+		t.Fatalf("expected an error when no remote could be fetched, got %v", err)
+	}
+}
+
+func TestPushAndPull_NamedRemoteAndRefSpec(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	newBareRemote(t, dir, "origin")
+	forkBare := newBareRemote(t, dir, "fork")
+
+	pushedBefore := runGitOutput(t, forkBare, "rev-parse", "main")
+	commitFile(t, dir, "feature.txt", "feature\n", "Add feature")
+	if err := service.Push(dir, "fork", "main:release"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if runGitOutput(t, forkBare, "rev-parse", "release") != runGitOutput(t, dir, "rev-parse", "HEAD") {
+		t.Error("expected fork/release to match local HEAD")
+	}
+	if runGitOutput(t, forkBare, "rev-parse", "main") != pushedBefore {
+		t.Error("refspec push should only update release")
+	}
+
+	if err := service.Push(dir, "fork", "::"); !errors.Is(err, ErrInvalidRefSpec) {
+		t.Fatalf("expected invalid refspec error, got %v", err)
+	}
+	if err := service.Push(dir, "missing", "main"); !errors.Is(err, ErrRemoteNotFound) {
+		t.Fatalf("expected remote not found, got %v", err)
+	}
+
+	// Advance fork/main from another clone, then pull it by name.
+	other := t.TempDir()
+	runGit(t, other, "clone", "-b", "main", forkBare, ".")
+	runGit(t, other, "config", "user.email", "test@example.com")
+	runGit(t, other, "config", "user.name", "Test User")
+	runGit(t, other, "reset", "--hard", "origin/release")
+	commitFile(t, other, "upstream.txt", "upstream\n", "Upstream change")
+	runGit(t, other, "push", "origin", "HEAD:main")
+
+	if _, err := service.Pull(dir, "fork", "main", PullMerge); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if runGitOutput(t, dir, "rev-parse", "HEAD") != runGitOutput(t, forkBare, "rev-parse", "main") {
+		t.Error("expected HEAD to match fork/main after pull")
+	}
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// RepoState is a snapshot of HEAD, every ref and the index file. Comparing two
+// snapshots tells which of them a file system change touched.
+type RepoState struct {
+	Head         string            // full name of the branch HEAD points at, "" when detached
+	HeadHash     string            // "" on an unborn branch
+	Refs         map[string]string // full ref name -> hash; symbolic refs are skipped
+	IndexModTime time.Time
+	IndexSize    int64
+}
+
+// ReadRepoState takes a snapshot of the repository's HEAD, refs and index.
+func (s *Service) ReadRepoState(repoPath string) (*RepoState, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	state := &RepoState{Refs: make(map[string]string)}
+	if head.Type() == plumbing.SymbolicReference {
+		state.Head = head.Target().String()
+		if resolved, err := repo.Reference(head.Target(), true); err == nil {
+			state.HeadHash = resolved.Hash().String()
+		}
+	} else {
+		state.HeadHash = head.Hash().String()
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name() != plumbing.HEAD && ref.Type() == plumbing.HashReference {
+			state.Refs[ref.Name().String()] = ref.Hash().String()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read references: %w", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(gitDir(repoPath), "index")); err == nil {
+		state.IndexModTime = info.ModTime()
+		state.IndexSize = info.Size()
+	}
+
+	return state, nil
+}
@@ -0,0 +1,32 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadRepoState_SeparateGitDir(t *testing.T) {
+	dir := t.TempDir()
+	separate := filepath.Join(t.TempDir(), "repo.git")
+	runGit(t, dir, "init", "-b", "main", "--separate-git-dir", separate)
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test User")
+	commitFile(t, dir, "README.md", "# Test\n", "Initial commit")
+
+	state, err := NewService().ReadRepoState(dir)
+	if err != nil {
+		t.Fatalf("ReadRepoState failed: %v", err)
+	}
+	if state.Head != "refs/heads/main" || state.HeadHash == "" {
+		t.Fatalf("unexpected HEAD in %+v", state)
+	}
+
+	info, err := os.Stat(filepath.Join(separate, "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.IndexSize != info.Size() || !state.IndexModTime.Equal(info.ModTime()) {
+		t.Fatalf("expected the index in the git directory, got size %d modified %v", state.IndexSize, state.IndexModTime)
+	}
+}
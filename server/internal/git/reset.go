@@ -0,0 +1,146 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Discard sources accepted by DiscardFile.
+const (
+	DiscardFromIndex = "index"
+	DiscardFromHead  = "head"
+)
+
+// Reset modes accepted by Reset.
+const (
+	ResetSoft  = "soft"
+	ResetMixed = "mixed"
+	ResetHard  = "hard"
+)
+
+// IsValidResetMode reports whether mode is a supported reset mode.
+func IsValidResetMode(mode string) bool {
+	switch mode {
+	case ResetSoft, ResetMixed, ResetHard:
+		return true
+	default:
+		return false
+	}
+}
+
+// DiscardFile throws away working tree changes to a tracked path. With
+// DiscardFromIndex the file is restored to its staged content; with
+// DiscardFromHead both the index and the working tree are restored to HEAD.
+func (s *Service) DiscardFile(repoPath, filePath, source string) error {
+	if !s.isFileTracked(repoPath, filePath) {
+		return fmt.Errorf("%w: %s", ErrNotTracked, filePath)
+	}
+
+	args := []string{"restore", "--worktree"}
+	switch source {
+	case DiscardFromIndex:
+	case DiscardFromHead:
+		args = append(args, "--source=HEAD", "--staged")
+	default:
+		return fmt.Errorf("unsupported discard source: %s", source)
+	}
+	args = append(args, "--", filePath)
+
+	if _, err := s.runGitCommand(repoPath, args...); err != nil {
+		return fmt.Errorf("failed to discard changes: %w", err)
+	}
+
+	return nil
+}
+
+// CleanUntracked deletes untracked files, optionally limited to paths. Files
+// ignored by git or by the repository's GitIgnore rules are never touched.
+// Directories left empty by the clean are removed as well.
+func (s *Service) CleanUntracked(repoPath string, paths []string, dryRun bool) (*models.CleanResult, error) {
+	args := []string{"ls-files", "--others", "--exclude-standard", "-z", "--"}
+	args = append(args, paths...)
+
+	output, err := s.runGitCommand(repoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	gitignore := NewGitIgnore(repoPath)
+	result := &models.CleanResult{
+		Removed: []string{},
+		DryRun:  dryRun,
+	}
+
+	for _, path := range strings.Split(output, "\x00") {
+		if path == "" || gitignore.IsIgnored(path, false) {
+			continue
+		}
+		result.Removed = append(result.Removed, path)
+	}
+	sort.Strings(result.Removed)
+
+	if dryRun {
+		return result, nil
+	}
+
+	root, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+
+	for _, path := range result.Removed {
+		fullPath := filepath.Join(root, path)
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+
+		// Prune parent directories that are now empty, stopping at the repository root.
+		for dir := filepath.Dir(fullPath); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Reset moves the current branch to target. Soft keeps the index and working
+// tree, mixed resets the index, and hard also overwrites the working tree.
+func (s *Service) Reset(repoPath, target, mode string) (*models.ResetResult, error) {
+	if !IsValidResetMode(mode) {
+		return nil, fmt.Errorf("unsupported reset mode: %s", mode)
+	}
+
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if target == "" {
+		target = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(target))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrResetTargetNotFound, target)
+	}
+	if _, err := repo.CommitObject(*hash); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrResetTargetNotFound, target)
+	}
+
+	if _, err := s.runGitCommand(repoPath, "reset", "--quiet", "--"+mode, hash.String()); err != nil {
+		return nil, fmt.Errorf("failed to reset: %w", err)
+	}
+
+	return &models.ResetResult{
+		Mode: mode,
+		Hash: hash.String(),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiscardFile(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	writeTestFile(t, dir, "README.md", "# Staged\n")
+	runGit(t, dir, "add", "README.md")
+	writeTestFile(t, dir, "README.md", "# Unstaged\n")
+
+	if err := service.DiscardFile(dir, "README.md", DiscardFromIndex); err != nil {
+		t.Fatalf("DiscardFile from index failed: %v", err)
+	}
+	if got := readTestFile(t, dir, "README.md"); got != "# Staged\n" {
+		t.Fatalf("expected staged content after discarding from index, got %q", got)
+	}
+
+	if err := service.DiscardFile(dir, "README.md", DiscardFromHead); err != nil {
+		t.Fatalf("DiscardFile from HEAD failed: %v", err)
+	}
+	if got := readTestFile(t, dir, "README.md"); got != "# Test\n" {
+		t.Fatalf("expected HEAD content after discarding from HEAD, got %q", got)
+	}
+	if staged := runGitOutput(t, dir, "diff", "--cached", "--name-only"); staged != "" {
+		t.Fatalf("expected nothing staged, got %q", staged)
+	}
+
+	writeTestFile(t, dir, "new.txt", "new\n")
+	if err := service.DiscardFile(dir, "new.txt", DiscardFromIndex); !errors.Is(err, ErrNotTracked) {
+		t.Fatalf("expected path is not tracked error, got %v", err)
+	}
+}
+
+func TestCleanUntracked_RespectsGitIgnore(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, ".gitignore", "*.log\nbuild/\n", "Add gitignore")
+
+	writeTestFile(t, dir, "scratch.txt", "scratch\n")
+	writeTestFile(t, dir, "tmp/nested/a.txt", "a\n")
+	writeTestFile(t, dir, "debug.log", "log\n")
+	writeTestFile(t, dir, "build/out.bin", "bin\n")
+
+	result, err := service.CleanUntracked(dir, nil, true)
+	if err != nil {
+		t.Fatalf("CleanUntracked dry run failed: %v", err)
+	}
+	if want := []string{"scratch.txt", "tmp/nested/a.txt"}; !reflect.DeepEqual(result.Removed, want) {
+		t.Fatalf("expected %v, got %v", want, result.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scratch.txt")); err != nil {
+		t.Fatal("dry run must not delete files")
+	}
+
+	if _, err := service.CleanUntracked(dir, []string{"tmp"}, false); err != nil {
+		t.Fatalf("CleanUntracked failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tmp")); !os.IsNotExist(err) {
+		t.Fatal("expected emptied tmp directory to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scratch.txt")); err != nil {
+		t.Fatal("expected files outside the requested paths to be kept")
+	}
+
+	if _, err := service.CleanUntracked(dir, nil, false); err != nil {
+		t.Fatalf("CleanUntracked failed: %v", err)
+	}
+	for _, kept := range []string{"debug.log", "build/out.bin", "README.md"} {
+		if _, err := os.Stat(filepath.Join(dir, kept)); err != nil {
+			t.Fatalf("expected %s to be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestReset_Modes(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	initial, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, "README.md", "# Second\n", "Second commit")
+
+	result, err := service.Reset(dir, "HEAD~1", ResetSoft)
+	if err != nil {
+		t.Fatalf("soft Reset failed: %v", err)
+	}
+	if result.Hash != initial {
+		t.Fatalf("expected HEAD at %s, got %s", initial, result.Hash)
+	}
+	if staged := strings.TrimSpace(runGitOutput(t, dir, "diff", "--cached", "--name-only")); staged != "README.md" {
+		t.Fatalf("expected soft reset to keep changes staged, got %q", staged)
+	}
+
+	if _, err := service.Reset(dir, "", ResetMixed); err != nil {
+		t.Fatalf("mixed Reset failed: %v", err)
+	}
+	if staged := runGitOutput(t, dir, "diff", "--cached", "--name-only"); staged != "" {
+		t.Fatalf("expected mixed reset to unstage changes, got %q", staged)
+	}
+	if got := readTestFile(t, dir, "README.md"); got != "# Second\n" {
+		t.Fatalf("expected mixed reset to keep working tree, got %q", got)
+	}
+
+	if _, err := service.Reset(dir, initial, ResetHard); err != nil {
+		t.Fatalf("hard Reset failed: %v", err)
+	}
+	if got := readTestFile(t, dir, "README.md"); got != "# Test\n" {
+		t.Fatalf("expected hard reset to restore working tree, got %q", got)
+	}
+
+	if _, err := service.Reset(dir, "nope", ResetMixed); !errors.Is(err, ErrResetTargetNotFound) {
+		t.Fatalf("expected reset target not found error, got %v", err)
+	}
+	if _, err := service.Reset(dir, "", "keep"); err == nil {
+		t.Fatal("expected error for unsupported mode")
+	}
+}
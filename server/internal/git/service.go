@@ -1,6 +1,8 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -9,18 +11,18 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
 
 	"gitweb/server/internal/models"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
-	diff "github.com/go-git/go-git/v5/plumbing/format/diff"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 type Service struct {
-	repoPath string
+	repoPath    string
+	credentials CredentialProvider
 }
 
 func NewService() *Service {
@@ -40,10 +42,19 @@ func (s *Service) OpenRepository(path string) (*git.Repository, error) {
 	return git.PlainOpen(path)
 }
 
+// IsBare reports whether the repository at path has no worktree, as with a
+// bare or mirror clone.
+func (s *Service) IsBare(path string) bool {
+	repo, err := s.OpenRepository(path)
+	if err != nil {
+		return false
+	}
+	_, err = repo.Worktree()
+	return errors.Is(err, git.ErrIsBareRepository)
+}
+
 func (s *Service) CloneRepository(url, path string) (*git.Repository, error) {
-	return git.PlainClone(path, false, &git.CloneOptions{
-		URL: url,
-	})
+	return s.CloneContext(context.Background(), url, path, models.CloneOptions{}, nil)
 }
 
 func (s *Service) InitRepository(path string) (*git.Repository, error) {
@@ -80,10 +91,26 @@ func (s *Service) GetRepositoryStatus(repoPath string) (*models.RepositoryStatus
 	var modified []models.FileChange
 	var untracked []string
 
+	// go-git's status does not understand unmerged index entries, so conflicted
+	// paths are read from the index stages and kept out of staged/modified.
+	stages, err := readConflictStages(repo)
+	if err != nil {
+		return nil, err
+	}
+	conflicted := conflictFiles(stages)
+	conflicts := make([]string, 0, len(conflicted))
+	for _, conflict := range conflicted {
+		conflicts = append(conflicts, conflict.Path)
+	}
+
 	// Initialize gitignore parser to filter out ignored files
 	gitignore := NewGitIgnore(repoPath)
 
 	for file, fileStatus := range status {
+		if _, isConflicted := stages[file]; isConflicted {
+			continue
+		}
+
 		change := models.FileChange{
 			Path:   file,
 			Status: string(fileStatus.Staging),
@@ -170,14 +197,16 @@ func (s *Service) GetRepositoryStatus(repoPath string) (*models.RepositoryStatus
 	sort.Strings(untracked)
 
 	return &models.RepositoryStatus{
-		Branch:    currentBranch,
-		IsClean:   status.IsClean(),
-		Staged:    staged,
-		Modified:  modified,
-		Untracked: untracked,
-		Conflicts: []string{},
-		Ahead:     ahead,
-		Behind:    behind,
+		Branch:          currentBranch,
+		IsClean:         status.IsClean() && len(conflicts) == 0,
+		Staged:          staged,
+		Modified:        modified,
+		Untracked:       untracked,
+		Conflicts:       conflicts,
+		ConflictedFiles: conflicted,
+		Operation:       inProgressOperation(repoPath),
+		Ahead:           ahead,
+		Behind:          behind,
 	}, nil
 }
 
@@ -289,40 +318,42 @@ func (s *Service) GetCommitHistory(repoPath string, limit int) ([]models.Commit,
 }
 
 func (s *Service) CreateCommit(repoPath string, req models.CommitRequest) error {
-	repo, err := s.OpenRepository(repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
-	}
-
-	worktree, err := repo.Worktree()
-	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
-	}
+	return s.commit(repoPath, req, nil)
+}
 
-	for _, file := range req.Files {
-		_, err = worktree.Add(file)
-		if err != nil {
-			return fmt.Errorf("failed to add file %s: %w", file, err)
+// commit stages req.Files and commits the index, signed with signing when it
+// is not nil. The message is kept verbatim and the author, when given, is also
+// the committer, so a signed commit differs from an unsigned one only in its
+// signature.
+func (s *Service) commit(repoPath string, req models.CommitRequest, signing *SigningConfig) error {
+	if len(req.Files) > 0 {
+		if err := s.stagePaths(repoPath, req.Files); err != nil {
+			return fmt.Errorf("failed to add files: %w", err)
 		}
 	}
 
-	commitOptions := &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  req.Author.Name,
-			Email: req.Author.Email,
-			When:  time.Now(),
-		},
+	var args []string
+	if signing != nil {
+		args = append(s.signingArgs(repoPath, *signing), "commit", "-S")
+	} else {
+		args = []string{"commit", "--no-gpg-sign"}
 	}
+	args = append(args, "--cleanup=verbatim", "-m", req.Message)
 
-	if req.Author.Name == "" {
-		commitOptions.Author = nil
+	var env []string
+	if req.Author.Name != "" {
+		env = []string{
+			"GIT_AUTHOR_NAME=" + req.Author.Name, "GIT_AUTHOR_EMAIL=" + req.Author.Email,
+			"GIT_COMMITTER_NAME=" + req.Author.Name, "GIT_COMMITTER_EMAIL=" + req.Author.Email,
+		}
 	}
 
-	_, err = worktree.Commit(req.Message, commitOptions)
-	if err != nil {
+	if _, err := s.runGitCommandWithEnv(repoPath, env, args...); err != nil {
+		if signing != nil {
+			return fmt.Errorf("failed to create signed commit: %w", err)
+		}
 		return fmt.Errorf("failed to create commit: %w", err)
 	}
-
 	return nil
 }
 
@@ -535,92 +566,84 @@ func (s *Service) BrowseDirectory(repoPath, subPath string, offset, limit int) (
 	}, nil
 }
 
-func (s *Service) Push(repoPath string) error {
-	repo, err := s.OpenRepository(repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
-	}
+// Push pushes to a remote (origin when empty). An empty refSpec keeps go-git's
+// default of pushing every local branch.
+func (s *Service) Push(repoPath, remote, refSpec string) error {
+	return s.PushContext(context.Background(), repoPath, remote, refSpec, nil)
+}
 
-	err = repo.Push(&git.PushOptions{})
-	if err != nil {
+// PushContext is Push bounded by ctx, writing git's progress output to
+// progress when it is not nil.
+func (s *Service) PushContext(ctx context.Context, repoPath, remote, refSpec string, progress io.Writer) error {
+	if err := s.push(ctx, repoPath, remote, refSpec, false, progress); err != nil {
 		return fmt.Errorf("failed to push: %w", err)
 	}
-
 	return nil
 }
 
-func (s *Service) ForcePush(repoPath string) error {
-	repo, err := s.OpenRepository(repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
-	}
+// ForcePush is Push with non-fast-forward updates allowed.
+func (s *Service) ForcePush(repoPath, remote, refSpec string) error {
+	return s.ForcePushContext(context.Background(), repoPath, remote, refSpec, nil)
+}
 
-	err = repo.Push(&git.PushOptions{
-		Force: true,
-	})
-	if err != nil {
+// ForcePushContext is ForcePush bounded by ctx, writing git's progress output
+// to progress when it is not nil.
+func (s *Service) ForcePushContext(ctx context.Context, repoPath, remote, refSpec string, progress io.Writer) error {
+	if err := s.push(ctx, repoPath, remote, refSpec, true, progress); err != nil {
 		return fmt.Errorf("failed to force push: %w", err)
 	}
-
 	return nil
 }
 
-func (s *Service) Pull(repoPath string) error {
+func (s *Service) push(ctx context.Context, repoPath, remote, refSpec string, force bool, progress io.Writer) error {
 	repo, err := s.OpenRepository(repoPath)
 	if err != nil {
 		return fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	worktree, err := repo.Worktree()
+	if remote == "" {
+		remote = defaultRemote
+	}
+	auth, err := s.remoteAuth(repo, remote)
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return err
+	}
+	options := &git.PushOptions{
+		RemoteName: remote,
+		Force:      force,
+		Progress:   progress,
+		Auth:       auth,
+	}
+	if refSpec != "" {
+		spec, err := expandPushRefSpec(refSpec)
+		if err != nil {
+			return err
+		}
+		options.RefSpecs = []config.RefSpec{spec}
 	}
 
-	err = worktree.Pull(&git.PullOptions{
-		RemoteName: "origin",
-	})
+	err = repo.PushContext(ctx, options)
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("failed to pull: %w", err)
+		if errors.Is(err, git.ErrRemoteNotFound) {
+			return fmt.Errorf("%w: %s", ErrRemoteNotFound, remote)
+		}
+		return err
 	}
 
 	return nil
 }
 
 func (s *Service) StageFile(repoPath, filePath string) error {
-	repo, err := s.OpenRepository(repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
-	}
-
-	worktree, err := repo.Worktree()
-	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
-	}
-
-	_, err = worktree.Add(filePath)
-	if err != nil {
+	if err := s.stagePaths(repoPath, []string{filePath}); err != nil {
 		return fmt.Errorf("failed to stage file: %w", err)
 	}
-
 	return nil
 }
 
 func (s *Service) StageAll(repoPath string) error {
-	repo, err := s.OpenRepository(repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
-	}
-
-	worktree, err := repo.Worktree()
-	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
-	}
-
-	_, err = worktree.Add(".")
-	if err != nil {
+	if err := s.stagePaths(repoPath, nil); err != nil {
 		return fmt.Errorf("failed to stage all files: %w", err)
 	}
-
 	return nil
 }
 
@@ -646,7 +669,14 @@ func (s *Service) UnstageFile(repoPath, filePath string) error {
 	return nil
 }
 
-func (s *Service) GetCommitDetails(repoPath, commitHash string) (*models.CommitDetail, error) {
+// GetCommitDetails returns a commit with the changes it made to each file,
+// compared with its first parent. The changes are computed by git, with full
+// unified diffs as patches.
+func (s *Service) GetCommitDetails(repoPath, commitHash string, opts DiffOptions) (*models.CommitDetail, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	repo, err := s.OpenRepository(repoPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
@@ -665,116 +695,9 @@ func (s *Service) GetCommitDetails(repoPath, commitHash string) (*models.CommitD
 	}
 
 	// Get the file changes (diff)
-	var changes []models.FileDiff
-	var stats models.DiffStats
-
-	if parentCommit != nil {
-		// Compare with parent
-		parentTree, err := parentCommit.Tree()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get parent tree: %w", err)
-		}
-
-		commitTree, err := commit.Tree()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get commit tree: %w", err)
-		}
-
-		patch, err := parentTree.Patch(commitTree)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get patch: %w", err)
-		}
-
-		for _, filePatch := range patch.FilePatches() {
-			from, to := filePatch.Files()
-
-			var filePath string
-			var changeType string
-
-			if from == nil && to != nil {
-				// New file
-				filePath = to.Path()
-				changeType = "added"
-			} else if from != nil && to == nil {
-				// Deleted file
-				filePath = from.Path()
-				changeType = "deleted"
-			} else if from != nil && to != nil {
-				// Modified file
-				filePath = to.Path()
-				changeType = "modified"
-			}
-
-			// Build patch string containing only changed lines and
-			// count additions/deletions
-			var patchBuilder strings.Builder
-			additions := 0
-			deletions := 0
-			for _, chunk := range filePatch.Chunks() {
-				content := chunk.Content()
-				if content == "" {
-					continue
-				}
-				lines := strings.Split(content, "\n")
-				switch chunk.Type() {
-				case diff.Add:
-					for _, line := range lines {
-						if line == "" {
-							continue
-						}
-						if !strings.HasPrefix(line, "+") {
-							line = "+" + line
-						}
-						patchBuilder.WriteString(line + "\n")
-						additions++
-					}
-				case diff.Delete:
-					for _, line := range lines {
-						if line == "" {
-							continue
-						}
-						if !strings.HasPrefix(line, "-") {
-							line = "-" + line
-						}
-						patchBuilder.WriteString(line + "\n")
-						deletions++
-					}
-				}
-			}
-
-			patchContent := strings.TrimSuffix(patchBuilder.String(), "\n")
-			stats.Additions += additions
-			stats.Deletions += deletions
-
-			changes = append(changes, models.FileDiff{
-				Path:       filePath,
-				ChangeType: changeType,
-				Additions:  additions,
-				Deletions:  deletions,
-				Patch:      patchContent,
-			})
-		}
-	} else {
-		// Initial commit - all files are additions
-		tree, err := commit.Tree()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get tree: %w", err)
-		}
-
-		err = tree.Files().ForEach(func(file *object.File) error {
-			changes = append(changes, models.FileDiff{
-				Path:       file.Name,
-				ChangeType: "added",
-				Additions:  0,
-				Deletions:  0,
-				Patch:      "",
-			})
-			stats.Additions++
-			return nil
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to iterate files: %w", err)
-		}
+	changes, stats, err := s.gitCommitChanges(repoPath, commit, parentCommit, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	parentHash := ""
@@ -791,11 +714,60 @@ func (s *Service) GetCommitDetails(repoPath, commitHash string) (*models.CommitD
 		},
 		Date:       commit.Author.When,
 		ParentHash: parentHash,
+		Signature:  s.commitSignature(repoPath, commit),
 		Changes:    changes,
 		Stats:      stats,
 	}, nil
 }
 
+// gitCommitChanges diffs a commit with its parent, or with the empty tree for
+// a root commit, using git so that the diff options apply.
+func (s *Service) gitCommitChanges(repoPath string, commit, parent *object.Commit, opts DiffOptions) ([]models.FileDiff, models.DiffStats, error) {
+	var stats models.DiffStats
+
+	args := append([]string{"show", "--format="}, opts.args()...)
+	revs := []string{commit.Hash.String()}
+	if parent != nil {
+		args = append([]string{"diff"}, opts.args()...)
+		revs = []string{parent.Hash.String(), commit.Hash.String()}
+	}
+
+	output, err := s.runGitCommand(repoPath, append(append(args, "--raw", "--numstat", "-z"), revs...)...)
+	if err != nil {
+		return nil, stats, fmt.Errorf("failed to diff commit: %w", err)
+	}
+	files := parseChangedFiles(output)
+	if len(files) == 0 {
+		return nil, stats, nil
+	}
+
+	patch, err := s.runGitCommand(repoPath, append(append([]string{"-c", "core.quotePath=false"}, args...), revs...)...)
+	if err != nil {
+		return nil, stats, fmt.Errorf("failed to diff commit: %w", err)
+	}
+	patches := make(map[string]string)
+	for _, file := range splitPatchByFile(patch) {
+		patches[file.path] = file.patch
+	}
+
+	changes := make([]models.FileDiff, 0, len(files))
+	for _, file := range files {
+		patchContent := patches[file.Path]
+		stats.Additions += file.Additions
+		stats.Deletions += file.Deletions
+		changes = append(changes, models.FileDiff{
+			Path:       file.Path,
+			OldPath:    file.OldPath,
+			ChangeType: file.ChangeType,
+			Additions:  file.Additions,
+			Deletions:  file.Deletions,
+			Patch:      patchContent,
+			EOLOnly:    IsEOLOnlyDiff(patchContent),
+		})
+	}
+	return changes, stats, nil
+}
+
 func (s *Service) DeleteBranch(repoPath, branchName string) error {
 	repo, err := s.OpenRepository(repoPath)
 	if err != nil {
@@ -827,128 +799,10 @@ func (s *Service) DeleteBranch(repoPath, branchName string) error {
 	return nil
 }
 
-func (s *Service) GetFileDiff(repoPath, filePath string) (string, error) {
-	repo, err := s.OpenRepository(repoPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open repository: %w", err)
-	}
-
-	worktree, err := repo.Worktree()
-	if err != nil {
-		return "", fmt.Errorf("failed to get worktree: %w", err)
-	}
-
-	// Get the current status
-	status, err := worktree.Status()
-	if err != nil {
-		return "", fmt.Errorf("failed to get status: %w", err)
-	}
-
-	fileStatus, exists := status[filePath]
-	if !exists {
-		return "", fmt.Errorf("file not found in status: %s", filePath)
-	}
-
-	// Handle different file statuses
-	switch fileStatus.Worktree {
-	case git.Untracked:
-		// For untracked files, show the entire content as additions
-		content, err := os.ReadFile(filepath.Join(repoPath, filePath))
-		if err != nil {
-			return "", fmt.Errorf("failed to read file: %w", err)
-		}
-
-		var diff strings.Builder
-		diff.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", filePath, filePath))
-		diff.WriteString("new file mode 100644\n")
-		diff.WriteString("index 0000000..0000000\n")
-		diff.WriteString("--- /dev/null\n")
-		diff.WriteString(fmt.Sprintf("+++ b/%s\n", filePath))
-
-		lines := strings.Split(string(content), "\n")
-		if len(lines) > 0 {
-			diff.WriteString(fmt.Sprintf("@@ -0,0 +1,%d @@\n", len(lines)))
-			for _, line := range lines {
-				diff.WriteString("+" + line + "\n")
-			}
-		}
-
-		return diff.String(), nil
-
-	case git.Modified, git.Added:
-		// Get HEAD commit
-		head, err := repo.Head()
-		if err != nil {
-			return "", fmt.Errorf("failed to get HEAD: %w", err)
-		}
-
-		commit, err := repo.CommitObject(head.Hash())
-		if err != nil {
-			return "", fmt.Errorf("failed to get commit: %w", err)
-		}
-
-		// Get the file from HEAD
-		tree, err := commit.Tree()
-		if err != nil {
-			return "", fmt.Errorf("failed to get tree: %w", err)
-		}
-
-		var oldContent string
-		file, err := tree.File(filePath)
-		if err != nil {
-			// File doesn't exist in HEAD (new file)
-			oldContent = ""
-		} else {
-			oldContent, err = file.Contents()
-			if err != nil {
-				return "", fmt.Errorf("failed to get file contents: %w", err)
-			}
-		}
-
-		// Get current file content
-		newContent, err := os.ReadFile(filepath.Join(repoPath, filePath))
-		if err != nil {
-			return "", fmt.Errorf("failed to read current file: %w", err)
-		}
-
-		// Generate diff
-		return s.generateTextDiff(filePath, oldContent, string(newContent)), nil
-
-	case git.Deleted:
-		// Get the file content from HEAD
-		head, err := repo.Head()
-		if err != nil {
-			return "", fmt.Errorf("failed to get HEAD: %w", err)
-		}
-
-		commit, err := repo.CommitObject(head.Hash())
-		if err != nil {
-			return "", fmt.Errorf("failed to get commit: %w", err)
-		}
-
-		tree, err := commit.Tree()
-		if err != nil {
-			return "", fmt.Errorf("failed to get tree: %w", err)
-		}
-
-		file, err := tree.File(filePath)
-		if err != nil {
-			return "", fmt.Errorf("failed to get deleted file: %w", err)
-		}
-
-		oldContent, err := file.Contents()
-		if err != nil {
-			return "", fmt.Errorf("failed to get file contents: %w", err)
-		}
-
-		return s.generateTextDiff(filePath, oldContent, ""), nil
-
-	case git.Unmodified:
-		return "", nil
-
-	default:
-		return "", fmt.Errorf("unsupported file status: %v", fileStatus.Worktree)
-	}
+// GetFileDiff returns the diff of a working tree file against HEAD, computed
+// by git with the options applied.
+func (s *Service) GetFileDiff(repoPath, filePath string, opts DiffOptions) (string, error) {
+	return s.GetFileDiffUsingGitDiff(repoPath, filePath, opts)
 }
 
 // GetStagedDiff gets the diff of a staged file (index vs HEAD)
@@ -1234,6 +1088,11 @@ func (s *Service) GetRemotes(repoPath string) ([]models.RepoRemote, error) {
 		return nil, fmt.Errorf("failed to get remotes: %w", err)
 	}
 
+	repoConfig, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
 	result := make([]models.RepoRemote, 0, len(remotes))
 	for _, remote := range remotes {
 		cfg := remote.Config()
@@ -1242,8 +1101,9 @@ func (s *Service) GetRemotes(repoPath string) ([]models.RepoRemote, error) {
 			url = cfg.URLs[0]
 		}
 		result = append(result, models.RepoRemote{
-			Name: cfg.Name,
-			URL:  url,
+			Name:       cfg.Name,
+			URL:        url,
+			Credential: remoteCredential(repoConfig, cfg.Name),
 		})
 	}
 
@@ -1252,7 +1112,11 @@ func (s *Service) GetRemotes(repoPath string) ([]models.RepoRemote, error) {
 
 // GetCommitFileDiff returns the diff for a specific file at a specific commit.
 // It compares the file at the commit with its parent (or empty for initial commits).
-func (s *Service) GetCommitFileDiff(repoPath, commitHash, filePath string, cursor, limit int) (*models.TokenizedDiff, error) {
+func (s *Service) GetCommitFileDiff(repoPath, commitHash, filePath string, cursor, limit int, opts DiffOptions) (*models.TokenizedDiff, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	repo, err := s.OpenRepository(repoPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
@@ -1299,13 +1163,23 @@ func (s *Service) GetCommitFileDiff(repoPath, commitHash, filePath string, curso
 		return nil, fmt.Errorf("file not found in commit or parent: %s", filePath)
 	}
 
+	// A file new to this commit may have been renamed or copied from another
+	// path, as the file history reports it.
+	oldPath := ""
+	if parentCommit != nil && fileExists && !oldFileExists {
+		oldPath = s.renameSource(repoPath, commit.Hash.String(), filePath, opts)
+	}
+
 	// Generate unified diff using git's native algorithm to avoid synthetic
 	// delete/add pairs from naive line-by-line comparison.
-	var cmd *exec.Cmd
-	if parentCommit != nil {
-		cmd = exec.Command(
-			"git",
-			"diff",
+	var args []string
+	if oldPath != "" {
+		args = append(append([]string{"diff"}, opts.args()...),
+			parentCommit.Hash.String()+":"+oldPath,
+			commit.Hash.String()+":"+filePath,
+		)
+	} else if parentCommit != nil {
+		args = append(append([]string{"diff"}, opts.args()...),
 			parentCommit.Hash.String(),
 			commit.Hash.String(),
 			"--",
@@ -1313,16 +1187,13 @@ func (s *Service) GetCommitFileDiff(repoPath, commitHash, filePath string, curso
 		)
 	} else {
 		// Initial commit has no parent, so show this commit's patch for the file.
-		cmd = exec.Command(
-			"git",
-			"show",
-			"--format=",
-			"--patch",
+		args = append(append([]string{"show", "--format=", "--patch"}, opts.args()...),
 			commit.Hash.String(),
 			"--",
 			filePath,
 		)
 	}
+	cmd := exec.Command("git", args...)
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
@@ -1337,20 +1208,29 @@ func (s *Service) GetCommitFileDiff(repoPath, commitHash, filePath string, curso
 	}
 
 	diffText := string(output)
-	if strings.TrimSpace(diffText) == "" {
+	if !hasDiffHunks(diffText) {
+		// Unchanged, binary, or a rename without content changes
 		return &models.TokenizedDiff{
-			Filename:   filePath,
-			Hunks:      []models.DiffHunkTokenized{},
-			TotalHunks: 0,
-			Additions:  0,
-			Deletions:  0,
-			HasMore:    false,
-			NextCursor: 0,
+			Filename:    filePath,
+			OldFilename: oldPath,
+			Hunks:       []models.DiffHunkTokenized{},
+			TotalHunks:  0,
+			Additions:   0,
+			Deletions:   0,
+			HasMore:     false,
+			NextCursor:  0,
 		}, nil
 	}
 
 	// Tokenize and return
-	return s.TokenizeDiff(diffText, filePath, cursor, limit), nil
+	tokenized := s.TokenizeDiff(diffText, filePath, cursor, limit)
+	tokenized.OldFilename = oldPath
+	return tokenized, nil
+}
+
+// hasDiffHunks reports whether a unified diff has any hunks.
+func hasDiffHunks(diffText string) bool {
+	return strings.HasPrefix(diffText, "@@") || strings.Contains(diffText, "\n@@")
 }
 
 // generateCommitFileDiff creates a unified diff between two file versions
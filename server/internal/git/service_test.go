@@ -1021,7 +1021,7 @@ func TestGetFileDiff(t *testing.T) {
 	}
 
 	// Get file diff
-	diff, err := service.GetFileDiff(tempDir, filePath)
+	diff, err := service.GetFileDiff(tempDir, filePath, DiffOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1263,7 +1263,7 @@ func TestGetCommitFileDiff(t *testing.T) {
 	s := NewService()
 
 	// This test will fail until we implement the method
-	_, err := s.GetCommitFileDiff("/tmp/test-repo", "abc123", "test.go", 0, 50)
+	_, err := s.GetCommitFileDiff("/tmp/test-repo", "abc123", "test.go", 0, 50, DiffOptions{})
 	if err == nil {
 		t.Error("Expected error for non-existent repo, got nil")
 	}
@@ -1329,7 +1329,7 @@ func TestGetCommitFileDiff_InsertLineDoesNotCreateSyntheticDeleteAddPairs(t *tes
 	}
 
 	latestCommitHash := history[0].Hash
-	tokenized, err := service.GetCommitFileDiff(tempDir, latestCommitHash, filePath, 0, 50)
+	tokenized, err := service.GetCommitFileDiff(tempDir, latestCommitHash, filePath, 0, 50, DiffOptions{})
 	if err != nil {
 		t.Fatalf("GetCommitFileDiff failed: %v", err)
 	}
@@ -0,0 +1,170 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Signing formats, matching git's gpg.format.
+const (
+	SigningFormatOpenPGP = "openpgp"
+	SigningFormatSSH     = "ssh"
+)
+
+// Signature statuses reported by GetCommitDetails.
+const (
+	SignatureGood       = "good"
+	SignatureBad        = "bad"
+	SignatureUnknownKey = "unknown-key"
+	SignatureExpired    = "expired"
+	SignatureUnsigned   = "unsigned"
+)
+
+// SigningConfig is the repository's signing key. Format and Key only apply
+// where git config leaves gpg.format or user.signingkey unset, so a key
+// configured in git always wins.
+type SigningConfig struct {
+	Format string
+	Key    string
+}
+
+// IsValidSigningFormat reports whether format is a supported signing format.
+// An empty format defers to gpg.format (OpenPGP when unset).
+func IsValidSigningFormat(format string) bool {
+	switch format {
+	case "", SigningFormatOpenPGP, SigningFormatSSH:
+		return true
+	default:
+		return false
+	}
+}
+
+// CreateSignedCommit is CreateCommit with the commit signed by the configured
+// OpenPGP or SSH key. Signing is delegated to git, which runs gpg or
+// ssh-keygen according to gpg.format.
+func (s *Service) CreateSignedCommit(repoPath string, req models.CommitRequest, signing SigningConfig) error {
+	return s.commit(repoPath, req, &signing)
+}
+
+// CreateSignedTag is CreateTag with annotated tags signed by the configured
+// key. Lightweight tags have no object to sign and are created unsigned.
+func (s *Service) CreateSignedTag(repoPath string, req models.CreateTagRequest, signing SigningConfig) (*models.Tag, error) {
+	if strings.TrimSpace(req.Message) == "" {
+		return s.CreateTag(repoPath, req)
+	}
+
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	name, hash, err := newTagTarget(repo, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repo.Reference(plumbing.NewTagReferenceName(name), false); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTagExists, name)
+	}
+
+	args := s.signingArgs(repoPath, signing)
+	args = append(args, "tag", "-s", "--cleanup=whitespace", "-m", req.Message, name, hash.String())
+
+	var env []string
+	if req.Tagger != nil && req.Tagger.Name != "" {
+		env = []string{"GIT_COMMITTER_NAME=" + req.Tagger.Name, "GIT_COMMITTER_EMAIL=" + req.Tagger.Email}
+	}
+
+	if _, err := s.runGitCommandWithEnv(repoPath, env, args...); err != nil {
+		return nil, fmt.Errorf("failed to create signed tag: %w", err)
+	}
+
+	ref, err := repo.Reference(plumbing.NewTagReferenceName(name), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag: %w", err)
+	}
+	return tagFromReference(repo, ref)
+}
+
+// signingArgs returns the -c overrides that supply signing's format and key
+// where git config does not set them.
+func (s *Service) signingArgs(repoPath string, signing SigningConfig) []string {
+	var args []string
+	if signing.Format != "" && s.gitConfigValue(repoPath, "gpg.format") == "" {
+		args = append(args, "-c", "gpg.format="+signing.Format)
+	}
+	if signing.Key != "" && s.gitConfigValue(repoPath, "user.signingkey") == "" {
+		args = append(args, "-c", "user.signingkey="+signing.Key)
+	}
+	return args
+}
+
+// gitConfigValue reads key from the effective git config (repository, global
+// and system), returning "" when it is unset.
+func (s *Service) gitConfigValue(repoPath, key string) string {
+	output, err := s.runGitCommand(repoPath, "config", "--get", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// commitSignature verifies a commit's signature with git, which checks OpenPGP
+// signatures against the gpg keyring and SSH signatures against
+// gpg.ssh.allowedSignersFile.
+func (s *Service) commitSignature(repoPath string, commit *object.Commit) models.CommitSignature {
+	if commit.PGPSignature == "" {
+		return models.CommitSignature{Status: SignatureUnsigned}
+	}
+
+	signature := models.CommitSignature{
+		Status: SignatureUnknownKey,
+		Format: signatureFormat(commit.PGPSignature),
+	}
+
+	output, err := s.runGitCommand(repoPath, "show", "-s", "--format=%G?%x00%GK%x00%GS", commit.Hash.String())
+	if err != nil {
+		return signature
+	}
+	fields := strings.SplitN(strings.TrimRight(output, "\n"), "\x00", 3)
+	if len(fields) != 3 {
+		return signature
+	}
+	signature.Key = fields[1]
+	signature.Signer = fields[2]
+
+	switch fields[0] {
+	case "G":
+		signature.Status = SignatureGood
+	case "U":
+		// A valid OpenPGP signature from a key of unknown trust is still good;
+		// for SSH it means the key is not in the allowed signers file.
+		if signature.Format != SigningFormatSSH {
+			signature.Status = SignatureGood
+		}
+	case "B", "R":
+		signature.Status = SignatureBad
+	case "X", "Y":
+		signature.Status = SignatureExpired
+	}
+	// "E" (key missing) and "N" (verification not configured, e.g. no allowed
+	// signers file) leave the signature as made by an unknown key.
+
+	return signature
+}
+
+// signatureFormat identifies the signature scheme from its armor header.
+func signatureFormat(signature string) string {
+	switch {
+	case strings.Contains(signature, "BEGIN SSH SIGNATURE"):
+		return SigningFormatSSH
+	case strings.Contains(signature, "BEGIN SIGNED MESSAGE"):
+		return "x509"
+	default:
+		return SigningFormatOpenPGP
+	}
+}
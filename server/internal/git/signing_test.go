@@ -0,0 +1,232 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+// newSSHSigningKey generates an unencrypted ed25519 key and returns the path
+// of the private key and of an allowed signers file trusting it.
+func newSSHSigningKey(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "test@example.com", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %v: %s", err, output)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := filepath.Join(dir, "allowed_signers")
+	if err := os.WriteFile(allowed, []byte("test@example.com "+string(pub)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return key, allowed
+}
+
+func TestCreateSignedCommit_SSHSignatureStatus(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	key, allowed := newSSHSigningKey(t)
+	signing := SigningConfig{Format: SigningFormatSSH, Key: key}
+
+	writeTestFile(t, dir, "signed.txt", "signed\n")
+	err := service.CreateSignedCommit(dir, models.CommitRequest{
+		Message: "Signed change",
+		Files:   []string{"signed.txt"},
+		Author:  models.Author{Name: "Signer", Email: "test@example.com"},
+	}, signing)
+	if err != nil {
+		t.Fatalf("CreateSignedCommit failed: %v", err)
+	}
+	head := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	detail, err := service.GetCommitDetails(dir, head, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Message != "Signed change" || detail.Author.Name != "Signer" {
+		t.Fatalf("unexpected commit: %+v", detail)
+	}
+	if detail.Signature.Status != SignatureUnknownKey || detail.Signature.Format != SigningFormatSSH {
+		t.Fatalf("expected unknown-key without allowed signers, got %+v", detail.Signature)
+	}
+
+	runGit(t, dir, "config", "gpg.ssh.allowedSignersFile", allowed)
+	detail, err = service.GetCommitDetails(dir, head, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Signature.Status != SignatureGood || detail.Signature.Signer != "test@example.com" || detail.Signature.Key == "" {
+		t.Fatalf("expected good signature, got %+v", detail.Signature)
+	}
+
+	// Rewriting the message keeps the signature but invalidates it.
+	raw := runGitOutput(t, dir, "cat-file", "commit", head)
+	cmd := exec.Command("git", "hash-object", "-t", "commit", "-w", "--stdin")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(strings.Replace(raw, "Signed change", "Tampered change", 1))
+	tampered, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	detail, err = service.GetCommitDetails(dir, strings.TrimSpace(string(tampered)), DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Signature.Status != SignatureBad {
+		t.Fatalf("expected bad signature, got %+v", detail.Signature)
+	}
+
+	detail, err = service.GetCommitDetails(dir, strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD~1")), DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Signature.Status != SignatureUnsigned {
+		t.Fatalf("expected unsigned commit, got %+v", detail.Signature)
+	}
+}
+
+func TestCreateSignedCommit_MatchesUnsignedCommit(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	key, _ := newSSHSigningKey(t)
+
+	writeTestFile(t, dir, "notes.txt", "one\r\ntwo\r\n")
+	req := models.CommitRequest{
+		Message: "Keep this\n\n# not a comment\n  indented  \n",
+		Files:   []string{"notes.txt"},
+		Author:  models.Author{Name: "Signer", Email: "test@example.com"},
+	}
+	if err := service.CreateCommit(dir, req); err != nil {
+		t.Fatalf("CreateCommit failed: %v", err)
+	}
+	unsigned := runGitOutput(t, dir, "show", "-s", "--format=%T%n%an%n%cn%n%B", "HEAD")
+
+	runGit(t, dir, "reset", "-q", "HEAD~1")
+	if err := service.CreateSignedCommit(dir, req, SigningConfig{Format: SigningFormatSSH, Key: key}); err != nil {
+		t.Fatalf("CreateSignedCommit failed: %v", err)
+	}
+	signed := runGitOutput(t, dir, "show", "-s", "--format=%T%n%an%n%cn%n%B", "HEAD")
+
+	if signed != unsigned {
+		t.Errorf("expected the signed commit to match the unsigned one, got %q, want %q", signed, unsigned)
+	}
+	if !strings.Contains(unsigned, req.Message) {
+		t.Errorf("expected the message to be kept verbatim, got %q", unsigned)
+	}
+}
+
+func TestCreateSignedCommit_GitConfigKeyWins(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	key, _ := newSSHSigningKey(t)
+	runGit(t, dir, "config", "gpg.format", "ssh")
+	runGit(t, dir, "config", "user.signingkey", key)
+
+	writeTestFile(t, dir, "signed.txt", "signed\n")
+	err := service.CreateSignedCommit(dir, models.CommitRequest{
+		Message: "Signed with git config",
+		Files:   []string{"signed.txt"},
+	}, SigningConfig{Format: SigningFormatOpenPGP, Key: "missing-key"})
+	if err != nil {
+		t.Fatalf("CreateSignedCommit failed: %v", err)
+	}
+	if raw := runGitOutput(t, dir, "cat-file", "commit", "HEAD"); !strings.Contains(raw, "BEGIN SSH SIGNATURE") {
+		t.Fatalf("expected commit signed with the git config key, got:\n%s", raw)
+	}
+}
+
+func TestCreateSignedTag_SignsAnnotatedTags(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	key, _ := newSSHSigningKey(t)
+	signing := SigningConfig{Format: SigningFormatSSH, Key: key}
+
+	tag, err := service.CreateSignedTag(dir, models.CreateTagRequest{
+		Name:    "v1.0.0",
+		Message: "First release",
+		Tagger:  &models.Author{Name: "Release Bot", Email: "release@example.com"},
+	}, signing)
+	if err != nil {
+		t.Fatalf("CreateSignedTag failed: %v", err)
+	}
+	if !tag.Annotated || tag.Message != "First release" || tag.Tagger == nil || tag.Tagger.Name != "Release Bot" {
+		t.Fatalf("unexpected tag: %+v", tag)
+	}
+	if raw := runGitOutput(t, dir, "cat-file", "tag", "v1.0.0"); !strings.Contains(raw, "BEGIN SSH SIGNATURE") {
+		t.Fatalf("expected signed tag object, got:\n%s", raw)
+	}
+
+	if _, err := service.CreateSignedTag(dir, models.CreateTagRequest{Name: "v1.0.0", Message: "Again"}, signing); !errors.Is(err, ErrTagExists) {
+		t.Fatalf("expected tag already exists, got %v", err)
+	}
+
+	light, err := service.CreateSignedTag(dir, models.CreateTagRequest{Name: "latest"}, signing)
+	if err != nil {
+		t.Fatalf("CreateSignedTag lightweight failed: %v", err)
+	}
+	if light.Annotated {
+		t.Fatalf("expected lightweight tag without a message, got %+v", light)
+	}
+}
+
+func TestCreateSignedCommit_OpenPGPSignatureStatus(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not available")
+	}
+
+	home, err := os.MkdirTemp("", "gnupg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+		os.RemoveAll(home)
+	})
+	t.Setenv("GNUPGHOME", home)
+	gen := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-gen-key", "Test User <test@example.com>", "ed25519", "sign", "never")
+	if output, err := gen.CombinedOutput(); err != nil {
+		t.Fatalf("gpg key generation failed: %v: %s", err, output)
+	}
+
+	service := NewService()
+	dir := newCLITestRepo(t)
+	writeTestFile(t, dir, "signed.txt", "signed\n")
+	err = service.CreateSignedCommit(dir, models.CommitRequest{
+		Message: "Signed change",
+		Files:   []string{"signed.txt"},
+	}, SigningConfig{Key: "test@example.com"})
+	if err != nil {
+		t.Fatalf("CreateSignedCommit failed: %v", err)
+	}
+	head := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+
+	detail, err := service.GetCommitDetails(dir, head, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Signature.Status != SignatureGood || detail.Signature.Format != SigningFormatOpenPGP {
+		t.Fatalf("expected good OpenPGP signature, got %+v", detail.Signature)
+	}
+
+	t.Setenv("GNUPGHOME", t.TempDir())
+	detail, err = service.GetCommitDetails(dir, head, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Signature.Status != SignatureUnknownKey {
+		t.Fatalf("expected unknown-key without the public key, got %+v", detail.Signature)
+	}
+}
@@ -0,0 +1,243 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitweb/server/internal/models"
+)
+
+// Stash apply statuses.
+const (
+	StashStatusApplied    = "applied"
+	StashStatusConflicted = "conflicted"
+)
+
+// stashRef formats the reflog selector for a stash index.
+func stashRef(index int) string {
+	return fmt.Sprintf("stash@{%d}", index)
+}
+
+// ListStashes returns the stash entries, most recent first.
+func (s *Service) ListStashes(repoPath string) ([]models.StashEntry, error) {
+	output, err := s.runGitCommand(repoPath, "stash", "list", "--format=%gd%x00%H%x00%ct%x00%gs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stashes: %w", err)
+	}
+
+	stashes := []models.StashEntry{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+
+		index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(fields[0], "stash@{"), "}"))
+		if err != nil {
+			continue
+		}
+
+		entry := models.StashEntry{
+			Index:   index,
+			Ref:     fields[0],
+			Hash:    fields[1],
+			Message: fields[3],
+		}
+		if seconds, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			entry.Date = time.Unix(seconds, 0)
+		}
+
+		// Reflog subjects look like "On main: message" for named stashes and
+		// "WIP on main: abc1234 subject" for unnamed ones.
+		subject := fields[3]
+		for _, prefix := range []string{"WIP on ", "On "} {
+			subject = strings.TrimPrefix(subject, prefix)
+		}
+		if branch, message, ok := strings.Cut(subject, ": "); ok {
+			entry.Branch = branch
+			entry.Message = message
+		}
+
+		stashes = append(stashes, entry)
+	}
+
+	return stashes, nil
+}
+
+// getStash looks up a stash entry by index.
+func (s *Service) getStash(repoPath string, index int) (*models.StashEntry, error) {
+	stashes, err := s.ListStashes(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stashes {
+		if stashes[i].Index == index {
+			return &stashes[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrStashNotFound, index)
+}
+
+// PushStash saves local changes to a new stash entry and returns it.
+func (s *Service) PushStash(repoPath string, req models.StashRequest) (*models.StashEntry, error) {
+	args := []string{"stash", "push"}
+	if req.IncludeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if message := strings.TrimSpace(req.Message); message != "" {
+		args = append(args, "--message", message)
+	}
+
+	output, err := s.runGitCommand(repoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stash changes: %w", err)
+	}
+	// git exits successfully without creating an entry when there is nothing to save.
+	if strings.Contains(output, "No local changes to save") {
+		return nil, ErrNothingToStash
+	}
+
+	return s.getStash(repoPath, 0)
+}
+
+// GetStashDetail returns a stash entry with the tokenized diff of every file it
+// changes, including untracked files saved with include-untracked.
+func (s *Service) GetStashDetail(repoPath string, index int) (*models.StashDetail, error) {
+	entry, err := s.getStash(repoPath, index)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := s.runGitCommand(repoPath, "-c", "core.quotePath=false", "stash", "show", "--patch", "--include-untracked", entry.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to show stash: %w", err)
+	}
+
+	detail := &models.StashDetail{
+		Stash: *entry,
+		Files: []models.TokenizedFileDiff{},
+	}
+
+	for _, file := range splitPatchByFile(patch) {
+		tokenized := s.TokenizeDiff(file.patch, file.path, 0, 9999) // stashes are shown whole, like commit diffs
+		detail.Files = append(detail.Files, models.TokenizedFileDiff{
+			Path:       file.path,
+			ChangeType: file.changeType,
+			Diff:       *tokenized,
+		})
+		detail.Stats.Additions += tokenized.Additions
+		detail.Stats.Deletions += tokenized.Deletions
+	}
+	detail.Stats.FilesChanged = len(detail.Files)
+
+	return detail, nil
+}
+
+// ApplyStash applies a stash entry to the working tree. When pop is true the
+// entry is dropped afterwards, unless applying it produced conflicts, in which
+// case git keeps the entry and the conflicted paths are reported.
+func (s *Service) ApplyStash(repoPath string, index int, pop bool) (*models.StashApplyResult, error) {
+	entry, err := s.getStash(repoPath, index)
+	if err != nil {
+		return nil, err
+	}
+
+	command := "apply"
+	if pop {
+		command = "pop"
+	}
+
+	if _, err := s.runGitCommand(repoPath, "stash", command, entry.Ref); err != nil {
+		conflicts, conflictErr := s.GetConflicts(repoPath)
+		if conflictErr == nil && len(conflicts) > 0 {
+			return &models.StashApplyResult{
+				Status:    StashStatusConflicted,
+				Conflicts: conflicts,
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to %s stash: %w", command, withLocalChanges(err))
+	}
+
+	return &models.StashApplyResult{
+		Status:    StashStatusApplied,
+		Dropped:   pop,
+		Conflicts: []models.ConflictFile{},
+	}, nil
+}
+
+// DropStash removes a stash entry without applying it.
+func (s *Service) DropStash(repoPath string, index int) error {
+	entry, err := s.getStash(repoPath, index)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.runGitCommand(repoPath, "stash", "drop", entry.Ref); err != nil {
+		return fmt.Errorf("failed to drop stash: %w", err)
+	}
+
+	return nil
+}
+
+// filePatch is the portion of a multi-file patch that belongs to one file.
+type filePatch struct {
+	path       string
+	changeType string // "added" | "modified" | "deleted"
+	patch      string
+}
+
+// splitPatchByFile splits git diff output into one patch per file. Paths are
+// taken from the ---/+++ lines, which git does not quote when core.quotePath
+// is disabled, but ends with a tab when the path contains a space.
+func splitPatchByFile(patch string) []filePatch {
+	var files []filePatch
+	var current *filePatch
+	var body strings.Builder
+	inHeader := false
+
+	flush := func() {
+		if current != nil {
+			current.patch = body.String()
+			files = append(files, *current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimRight(line, "\n")
+
+		switch {
+		case strings.HasPrefix(trimmed, "diff --git "):
+			flush()
+			current = &filePatch{changeType: "modified"}
+			inHeader = true
+			// Fallback for patches without ---/+++ lines (binary or mode-only changes).
+			if idx := strings.Index(trimmed, " b/"); idx >= 0 {
+				current.path = trimmed[idx+3:]
+			}
+		case !inHeader || current == nil:
+		case strings.HasPrefix(trimmed, "@@"):
+			inHeader = false
+		case strings.HasPrefix(trimmed, "new file mode"):
+			current.changeType = "added"
+		case strings.HasPrefix(trimmed, "deleted file mode"):
+			current.changeType = "deleted"
+		case strings.HasPrefix(trimmed, "--- a/"):
+			current.path = strings.TrimSuffix(strings.TrimPrefix(trimmed, "--- a/"), "\t")
+		case strings.HasPrefix(trimmed, "+++ b/"):
+			current.path = strings.TrimSuffix(strings.TrimPrefix(trimmed, "+++ b/"), "\t")
+		}
+
+		body.WriteString(line)
+	}
+	flush()
+
+	return files
+}
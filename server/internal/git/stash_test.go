@@ -0,0 +1,186 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestStash_PushListShowAndPop(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("untracked\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := service.PushStash(dir, models.StashRequest{Message: "wip readme", IncludeUntracked: true})
+	if err != nil {
+		t.Fatalf("PushStash failed: %v", err)
+	}
+	if entry.Index != 0 || entry.Ref != "stash@{0}" || entry.Branch != "main" || entry.Message != "wip readme" {
+		t.Fatalf("unexpected stash entry: %+v", entry)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected untracked file to be stashed")
+	}
+
+	stashes, err := service.ListStashes(dir)
+	if err != nil {
+		t.Fatalf("ListStashes failed: %v", err)
+	}
+	if len(stashes) != 1 {
+		t.Fatalf("expected 1 stash, got %d", len(stashes))
+	}
+
+	detail, err := service.GetStashDetail(dir, 0)
+	if err != nil {
+		t.Fatalf("GetStashDetail failed: %v", err)
+	}
+	changes := map[string]string{}
+	for _, f := range detail.Files {
+		changes[f.Path] = f.ChangeType
+	}
+	if changes["README.md"] != "modified" || changes["notes.txt"] != "added" {
+		t.Fatalf("unexpected stash files: %v", changes)
+	}
+	if detail.Stats.FilesChanged != 2 || detail.Stats.Additions != 2 || detail.Stats.Deletions != 1 {
+		t.Fatalf("unexpected stash stats: %+v", detail.Stats)
+	}
+
+	result, err := service.ApplyStash(dir, 0, true)
+	if err != nil {
+		t.Fatalf("ApplyStash failed: %v", err)
+	}
+	if result.Status != StashStatusApplied || !result.Dropped {
+		t.Fatalf("unexpected pop result: %+v", result)
+	}
+	if stashes, _ := service.ListStashes(dir); len(stashes) != 0 {
+		t.Fatalf("expected stash list to be empty after pop, got %d", len(stashes))
+	}
+}
+
+func TestStash_NothingToStash(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	if _, err := service.PushStash(dir, models.StashRequest{}); err == nil || !strings.Contains(err.Error(), "no local changes") {
+		t.Fatalf("expected no local changes error, got %v", err)
+	}
+}
+
+func TestStash_ApplyReportsConflicts(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Stashed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.PushStash(dir, models.StashRequest{}); err != nil {
+		t.Fatalf("PushStash failed: %v", err)
+	}
+	commitFile(t, dir, "README.md", "# Committed\n", "Edit README")
+
+	result, err := service.ApplyStash(dir, 0, true)
+	if err != nil {
+		t.Fatalf("ApplyStash failed: %v", err)
+	}
+	if result.Status != StashStatusConflicted || result.Dropped {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "README.md" || result.Conflicts[0].Type != ConflictBothModified {
+		t.Fatalf("unexpected conflicts: %v", result.Conflicts)
+	}
+	if stashes, _ := service.ListStashes(dir); len(stashes) != 1 {
+		t.Fatal("expected conflicted pop to keep the stash entry")
+	}
+}
+
+func TestStash_ApplyOverLocalChanges(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	writeTestFile(t, dir, "README.md", "# Stashed\n")
+	if _, err := service.PushStash(dir, models.StashRequest{}); err != nil {
+		t.Fatalf("PushStash failed: %v", err)
+	}
+	writeTestFile(t, dir, "README.md", "# Local\n")
+
+	if _, err := service.ApplyStash(dir, 0, false); !errors.Is(err, ErrLocalChanges) {
+		t.Fatalf("expected local changes error, got %v", err)
+	}
+}
+
+func TestStash_DropUnknownIndex(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+
+	if err := service.DropStash(dir, 3); err == nil || !strings.Contains(err.Error(), "stash not found") {
+		t.Fatalf("expected stash not found error, got %v", err)
+	}
+}
+
+func TestSplitPatchByFile(t *testing.T) {
+	patch := "diff --git a/a.txt b/a.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/a.txt\n" +
+		"+++ b/a.txt\n" +
+		"@@ -1 +1 @@\n" +
+		"--- a/not-a-header\n" +
+		"+new\n" +
+		"diff --git a/b.txt b/b.txt\n" +
+		"deleted file mode 100644\n" +
+		"--- a/b.txt\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"-gone\n"
+
+	files := splitPatchByFile(patch)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	if files[0].path != "a.txt" || files[0].changeType != "modified" {
+		t.Fatalf("unexpected first file: %+v", files[0])
+	}
+	if files[1].path != "b.txt" || files[1].changeType != "deleted" {
+		t.Fatalf("unexpected second file: %+v", files[1])
+	}
+	if !strings.HasSuffix(files[0].patch, "+new\n") || !strings.HasPrefix(files[1].patch, "diff --git a/b.txt") {
+		t.Fatal("patch text was not split at file boundaries")
+	}
+}
+
+func TestSplitPatchByFile_SpacedFileName(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "my notes.txt", "one\n", "Add notes")
+	writeTestFile(t, dir, "my notes.txt", "two\n")
+
+	if _, err := service.PushStash(dir, models.StashRequest{}); err != nil {
+		t.Fatalf("PushStash failed: %v", err)
+	}
+	detail, err := service.GetStashDetail(dir, 0)
+	if err != nil {
+		t.Fatalf("GetStashDetail failed: %v", err)
+	}
+	if len(detail.Files) != 1 || detail.Files[0].Path != "my notes.txt" || detail.Files[0].Diff.Additions != 1 {
+		t.Fatalf("expected the patch of the spaced file, got %+v", detail.Files)
+	}
+
+	runGit(t, dir, "stash", "pop", "-q")
+	runGit(t, dir, "commit", "-q", "-am", "Edit notes")
+	commit, err := service.GetCommitDetails(dir, strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD")), DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetCommitDetails failed: %v", err)
+	}
+	if len(commit.Changes) != 1 || !strings.Contains(commit.Changes[0].Patch, "+two") {
+		t.Fatalf("expected the commit patch of the spaced file, got %+v", commit.Changes)
+	}
+}
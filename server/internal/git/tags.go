@@ -0,0 +1,203 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gitweb/server/internal/models"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GetTags lists all tags, newest first, with the commit each one resolves to.
+func (s *Service) GetTags(repoPath string) ([]models.Tag, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	tagIter, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	tags := []models.Tag{}
+	err = tagIter.ForEach(func(ref *plumbing.Reference) error {
+		tag, err := tagFromReference(repo, ref)
+		if err != nil {
+			// Tags pointing at trees or blobs are rare and not shown.
+			return nil
+		}
+		tags = append(tags, *tag)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate tags: %w", err)
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].Date.Equal(tags[j].Date) {
+			return tags[i].Date.After(tags[j].Date)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+// tagFromReference resolves a refs/tags reference, peeling annotated tags down
+// to the commit they point at.
+func tagFromReference(repo *git.Repository, ref *plumbing.Reference) (*models.Tag, error) {
+	tag := &models.Tag{
+		Name: ref.Name().Short(),
+		Hash: ref.Hash().String(),
+	}
+
+	var commit *object.Commit
+	tagObj, err := repo.TagObject(ref.Hash())
+	switch {
+	case err == nil:
+		tag.Annotated = true
+		tag.Tagger = &models.Author{
+			Name:  tagObj.Tagger.Name,
+			Email: tagObj.Tagger.Email,
+		}
+		tag.Message = strings.TrimSpace(tagObj.Message)
+		tag.Date = tagObj.Tagger.When
+
+		commit, err = tagObj.Commit()
+		if err != nil {
+			return nil, err
+		}
+	case errors.Is(err, plumbing.ErrObjectNotFound):
+		commit, err = repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil, err
+		}
+		tag.Date = commit.Committer.When
+	default:
+		return nil, err
+	}
+
+	tag.Target = commit.Hash.String()
+	return tag, nil
+}
+
+// CreateTag creates a tag at the given revision (HEAD when empty). A non-empty
+// message creates an annotated tag; otherwise the tag is lightweight.
+func (s *Service) CreateTag(repoPath string, req models.CreateTagRequest) (*models.Tag, error) {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	name, hash, err := newTagTarget(repo, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts *git.CreateTagOptions
+	if strings.TrimSpace(req.Message) != "" {
+		opts = &git.CreateTagOptions{Message: req.Message}
+		if req.Tagger != nil && req.Tagger.Name != "" {
+			opts.Tagger = &object.Signature{
+				Name:  req.Tagger.Name,
+				Email: req.Tagger.Email,
+				When:  time.Now(),
+			}
+		}
+	}
+
+	ref, err := repo.CreateTag(name, hash, opts)
+	if err != nil {
+		if errors.Is(err, git.ErrTagExists) {
+			return nil, fmt.Errorf("%w: %s", ErrTagExists, name)
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return tagFromReference(repo, ref)
+}
+
+// newTagTarget validates the name of a tag to create and resolves the commit
+// it should point at.
+func newTagTarget(repo *git.Repository, req models.CreateTagRequest) (string, plumbing.Hash, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := plumbing.NewTagReferenceName(name).Validate(); err != nil || name == "" {
+		return "", plumbing.ZeroHash, fmt.Errorf("%w: %q", ErrInvalidTagName, req.Name)
+	}
+
+	target := strings.TrimSpace(req.Target)
+	if target == "" {
+		target = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(target))
+	if err != nil {
+		return "", plumbing.ZeroHash, fmt.Errorf("%w: %s", ErrTagTargetNotFound, target)
+	}
+
+	return name, *hash, nil
+}
+
+// DeleteTag removes a local tag.
+func (s *Service) DeleteTag(repoPath, name string) error {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if err := repo.DeleteTag(name); err != nil {
+		if errors.Is(err, git.ErrTagNotFound) {
+			return fmt.Errorf("%w: %s", ErrTagNotFound, name)
+		}
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	return nil
+}
+
+// PushTags pushes a single tag, or all tags when tag is empty, to a remote
+// (origin when empty).
+func (s *Service) PushTags(repoPath, remote, tag string) error {
+	repo, err := s.OpenRepository(repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if remote == "" {
+		remote = "origin"
+	}
+
+	refSpec := config.RefSpec("refs/tags/*:refs/tags/*")
+	if tag != "" {
+		tagRef := plumbing.NewTagReferenceName(tag)
+		if _, err := repo.Reference(tagRef, false); err != nil {
+			return fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+		}
+		refSpec = config.RefSpec(fmt.Sprintf("%s:%s", tagRef, tagRef))
+	}
+
+	auth, err := s.remoteAuth(repo, remote)
+	if err != nil {
+		return err
+	}
+	err = repo.Push(&git.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		if errors.Is(err, git.ErrRemoteNotFound) {
+			return fmt.Errorf("%w: %s", ErrRemoteNotFound, remote)
+		}
+		return fmt.Errorf("failed to push tags: %w", err)
+	}
+
+	return nil
+}
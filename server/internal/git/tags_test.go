@@ -0,0 +1,95 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"gitweb/server/internal/models"
+)
+
+func TestTags_CreateListAndDelete(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	first, err := service.headHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, "CHANGELOG.md", "changes\n", "Add changelog")
+
+	if _, err := service.CreateTag(dir, models.CreateTagRequest{Name: "latest"}); err != nil {
+		t.Fatalf("CreateTag lightweight failed: %v", err)
+	}
+	annotated, err := service.CreateTag(dir, models.CreateTagRequest{
+		Name:    "v1.0.0",
+		Target:  first,
+		Message: "First release",
+		Tagger:  &models.Author{Name: "Release Bot", Email: "release@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTag annotated failed: %v", err)
+	}
+	if !annotated.Annotated || annotated.Target != first || annotated.Hash == first {
+		t.Fatalf("unexpected annotated tag: %+v", annotated)
+	}
+	if annotated.Tagger == nil || annotated.Tagger.Name != "Release Bot" || annotated.Message != "First release" {
+		t.Fatalf("unexpected tagger or message: %+v", annotated)
+	}
+
+	tags, err := service.GetTags(dir)
+	if err != nil {
+		t.Fatalf("GetTags failed: %v", err)
+	}
+	byName := map[string]models.Tag{}
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+	}
+	if len(byName) != 2 || byName["latest"].Annotated || byName["latest"].Hash != byName["latest"].Target {
+		t.Fatalf("unexpected tags: %+v", tags)
+	}
+
+	if _, err := service.CreateTag(dir, models.CreateTagRequest{Name: "latest"}); err == nil || !strings.Contains(err.Error(), "tag already exists") {
+		t.Fatalf("expected tag already exists error, got %v", err)
+	}
+	if _, err := service.CreateTag(dir, models.CreateTagRequest{Name: "bad..name"}); err == nil || !strings.Contains(err.Error(), "invalid tag name") {
+		t.Fatalf("expected invalid tag name error, got %v", err)
+	}
+
+	if err := service.DeleteTag(dir, "latest"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	if err := service.DeleteTag(dir, "latest"); err == nil || !strings.Contains(err.Error(), "tag not found") {
+		t.Fatalf("expected tag not found error, got %v", err)
+	}
+}
+
+func TestPushTags(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	remoteDir := t.TempDir()
+	runGit(t, remoteDir, "init", "--bare")
+	runGit(t, dir, "remote", "add", "origin", remoteDir)
+
+	for _, name := range []string{"v1", "v2"} {
+		if _, err := service.CreateTag(dir, models.CreateTagRequest{Name: name, Message: "Release " + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := service.PushTags(dir, "", "v1"); err != nil {
+		t.Fatalf("PushTags single failed: %v", err)
+	}
+	if tags := strings.Fields(runGitOutput(t, remoteDir, "tag")); len(tags) != 1 || tags[0] != "v1" {
+		t.Fatalf("expected only v1 on remote, got %v", tags)
+	}
+
+	if err := service.PushTags(dir, "origin", ""); err != nil {
+		t.Fatalf("PushTags all failed: %v", err)
+	}
+	if tags := strings.Fields(runGitOutput(t, remoteDir, "tag")); len(tags) != 2 {
+		t.Fatalf("expected both tags on remote, got %v", tags)
+	}
+
+	if err := service.PushTags(dir, "origin", "missing"); err == nil || !strings.Contains(err.Error(), "tag not found") {
+		t.Fatalf("expected tag not found error, got %v", err)
+	}
+}
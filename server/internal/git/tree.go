@@ -0,0 +1,190 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitweb/server/internal/models"
+)
+
+// resolveCommit resolves a commit-ish, HEAD when empty, to a commit hash.
+func (s *Service) resolveCommit(repoPath, rev string) (string, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		rev = "HEAD"
+	}
+	if strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidRevision, rev)
+	}
+
+	hash, err := s.runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrRevisionNotFound, rev)
+	}
+	return strings.TrimSpace(hash), nil
+}
+
+// cleanTreePath turns a request path into a path inside a tree, "" for the
+// root.
+func cleanTreePath(p string) string {
+	p = path.Clean("/" + strings.Trim(p, "/"))
+	return strings.TrimPrefix(p, "/")
+}
+
+// ListTree reads a single directory level of the tree at a revision, with
+// the same ordering and pagination as BrowseDirectory.
+func (s *Service) ListTree(repoPath, rev, subPath string, offset, limit int) (*models.RevisionTree, error) {
+	hash, err := s.resolveCommit(repoPath, rev)
+	if err != nil {
+		return nil, err
+	}
+	subPath = cleanTreePath(subPath)
+
+	treeish := hash
+	if subPath != "" {
+		entry, err := s.lookupTreeEntry(repoPath, hash, subPath)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Type != "tree" {
+			return nil, fmt.Errorf("%w: %s", ErrNotDirectory, subPath)
+		}
+		treeish = entry.Hash
+	}
+
+	output, err := s.runGitCommand(repoPath, "ls-tree", "-l", "-z", treeish)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree: %w", err)
+	}
+	entries := parseTreeEntries(output)
+	for i := range entries {
+		entries[i].Path = path.Join(subPath, entries[i].Name)
+	}
+
+	// Sort: directories first, then alphabetically
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDirectory != entries[j].IsDirectory {
+			return entries[i].IsDirectory
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	if limit <= 0 {
+		limit = 500
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	totalCount := len(entries)
+	if offset > totalCount {
+		offset = totalCount
+	}
+	end := offset + limit
+	if end > totalCount {
+		end = totalCount
+	}
+
+	parentPath := ""
+	if subPath != "" {
+		parentPath = path.Dir(subPath)
+		if parentPath == "." {
+			parentPath = ""
+		}
+	}
+
+	return &models.RevisionTree{
+		Rev:        hash,
+		Path:       subPath,
+		ParentPath: parentPath,
+		Entries:    entries[offset:end],
+		TotalCount: totalCount,
+		HasMore:    end < totalCount,
+		Offset:     offset,
+	}, nil
+}
+
+// ReadBlob reads a file at a revision. Content is only filled in for text
+// files; the raw bytes are returned either way.
+func (s *Service) ReadBlob(repoPath, rev, filePath string) (*models.RevisionBlob, []byte, error) {
+	hash, err := s.resolveCommit(repoPath, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	filePath = cleanTreePath(filePath)
+	if filePath == "" {
+		return nil, nil, fmt.Errorf("%w: the repository root is a directory", ErrNotFile)
+	}
+
+	entry, err := s.lookupTreeEntry(repoPath, hash, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch entry.Type {
+	case "tree":
+		return nil, nil, fmt.Errorf("%w: %s is a directory", ErrNotFile, filePath)
+	case "commit":
+		return nil, nil, fmt.Errorf("%w: %s is a submodule", ErrNotFile, filePath)
+	}
+
+	content, err := s.runGitCommand(repoPath, "cat-file", "blob", entry.Hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	data := []byte(content)
+
+	blob := &models.RevisionBlob{
+		Rev:    hash,
+		Path:   filePath,
+		Hash:   entry.Hash,
+		Mode:   entry.Mode,
+		Size:   entry.Size,
+		Binary: isBinaryContent(data),
+	}
+	if !blob.Binary {
+		blob.Content = content
+	}
+	return blob, data, nil
+}
+
+// lookupTreeEntry finds the entry for a path in the tree of a commit.
+func (s *Service) lookupTreeEntry(repoPath, commitHash, filePath string) (models.TreeEntry, error) {
+	output, err := s.runGitCommand(repoPath, "ls-tree", "-l", "-z", "--full-tree", commitHash, "--", filePath)
+	if err != nil {
+		return models.TreeEntry{}, fmt.Errorf("failed to read tree: %w", err)
+	}
+	for _, entry := range parseTreeEntries(output) {
+		if entry.Name == filePath {
+			entry.Path = filePath
+			entry.Name = path.Base(filePath)
+			return entry, nil
+		}
+	}
+	return models.TreeEntry{}, fmt.Errorf("%w at %s: %s", ErrPathNotFound, commitHash, filePath)
+}
+
+// parseTreeEntries parses the output of ls-tree -l -z. Names are as ls-tree
+// prints them, relative to the listed tree or, with --full-tree, the root.
+func parseTreeEntries(output string) []models.TreeEntry {
+	entries := []models.TreeEntry{}
+	for _, record := range strings.Split(output, "\x00") {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <name>
+		meta, name, ok := strings.Cut(record, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64) // "-" for trees and submodules
+		entries = append(entries, models.TreeEntry{
+			Name:        name,
+			Type:        fields[1],
+			IsDirectory: fields[1] == "tree",
+			Mode:        fields[0],
+			Size:        size,
+			Hash:        fields[2],
+		})
+	}
+	return entries
+}
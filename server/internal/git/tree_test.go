@@ -0,0 +1,105 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListTree_AtRevision(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	runGit(t, dir, "checkout", "-q", "-b", "agent/feature")
+	commitFile(t, dir, "src/main.go", "package main\n", "Add main")
+	commitFile(t, dir, "src/util/strings.go", "package util\n", "Add util")
+	runGit(t, dir, "checkout", "-q", "main")
+
+	// The working tree is on main and has none of the branch's files
+	if _, err := os.Stat(filepath.Join(dir, "src")); !os.IsNotExist(err) {
+		t.Fatalf("expected src to be absent from the working tree, got %v", err)
+	}
+
+	tree, err := service.ListTree(dir, "agent/feature", "", 0, 500)
+	if err != nil {
+		t.Fatalf("ListTree failed: %v", err)
+	}
+	if tree.TotalCount != 2 || tree.Entries[0].Name != "src" || !tree.Entries[0].IsDirectory || tree.Entries[0].Type != "tree" || tree.Entries[1].Name != "README.md" {
+		t.Fatalf("expected src before README.md, got %+v", tree.Entries)
+	}
+	if readme := tree.Entries[1]; readme.Mode != "100644" || readme.Size != int64(len("# Test\n")) || readme.Path != "README.md" {
+		t.Errorf("unexpected README.md entry: %+v", readme)
+	}
+
+	tree, err = service.ListTree(dir, "agent/feature", "/src/", 0, 1)
+	if err != nil {
+		t.Fatalf("ListTree of src failed: %v", err)
+	}
+	if tree.Path != "src" || tree.ParentPath != "" || tree.TotalCount != 2 || !tree.HasMore || tree.Entries[0].Path != "src/util" {
+		t.Errorf("unexpected src listing: %+v", tree)
+	}
+
+	tests := []struct {
+		rev, path string
+		want      error
+	}{
+		{"main", "src", ErrPathNotFound},
+		{"agent/feature", "README.md", ErrNotDirectory},
+		{"nope", "", ErrRevisionNotFound},
+		{"--all", "", ErrInvalidRevision},
+	}
+	for _, tt := range tests {
+		if _, err := service.ListTree(dir, tt.rev, tt.path, 0, 500); !errors.Is(err, tt.want) {
+			t.Errorf("ListTree(%s, %s) = %v, want %v", tt.rev, tt.path, err, tt.want)
+		}
+	}
+}
+
+func TestReadBlob_SizeModeAndBinary(t *testing.T) {
+	service := NewService()
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, "run.sh", "#!/bin/sh\necho hi\n", "Add script")
+	runGit(t, dir, "update-index", "--chmod=+x", "run.sh")
+	runGit(t, dir, "commit", "-q", "-m", "Make script executable")
+	commitFile(t, dir, "logo.png", "\x89PNG\x00\x01\x02", "Add logo")
+	tagged := strings.TrimSpace(runGitOutput(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "tag", "v1")
+	commitFile(t, dir, "run.sh", "#!/bin/sh\necho bye\n", "Say bye")
+
+	blob, data, err := service.ReadBlob(dir, "v1", "run.sh")
+	if err != nil {
+		t.Fatalf("ReadBlob failed: %v", err)
+	}
+	if blob.Rev != tagged || blob.Mode != "100755" || blob.Size != 18 || blob.Binary || blob.Content != "#!/bin/sh\necho hi\n" || string(data) != blob.Content {
+		t.Errorf("unexpected script blob: %+v", blob)
+	}
+
+	blob, data, err = service.ReadBlob(dir, "", "logo.png")
+	if err != nil {
+		t.Fatalf("ReadBlob of binary failed: %v", err)
+	}
+	if !blob.Binary || blob.Content != "" || blob.Size != 7 || string(data) != "\x89PNG\x00\x01\x02" {
+		t.Errorf("unexpected binary blob: %+v", blob)
+	}
+
+	runGit(t, dir, "update-index", "--add", "--cacheinfo", "160000,"+tagged+",vendor/lib")
+	runGit(t, dir, "commit", "-q", "-m", "Add submodule")
+	commitFile(t, dir, "docs/guide.md", "guide\n", "Add guide")
+
+	tests := []struct {
+		rev, path string
+		want      error
+	}{
+		{"HEAD", "docs", ErrNotFile},
+		{"HEAD", "vendor/lib", ErrNotFile},
+		{"HEAD", "missing.txt", ErrPathNotFound},
+		{"HEAD~3", "docs/guide.md", ErrPathNotFound},
+		{"nope", "run.sh", ErrRevisionNotFound},
+	}
+	for _, tt := range tests {
+		if _, _, err := service.ReadBlob(dir, tt.rev, tt.path); !errors.Is(err, tt.want) {
+			t.Errorf("ReadBlob(%s, %s) = %v, want %v", tt.rev, tt.path, err, tt.want)
+		}
+	}
+}
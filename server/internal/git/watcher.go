@@ -1,20 +1,75 @@
 package git
 
 import (
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+const (
+	// watchDebounce is how long a repository must stay quiet before a burst
+	// of changes is delivered as one notification.
+	watchDebounce = 100 * time.Millisecond
+	// watchMaxDelay bounds how long a steady stream of changes (an npm
+	// install, a large checkout) can hold a notification back.
+	watchMaxDelay = time.Second
+)
+
+// RepoChange classifies what a coalesced burst of file system activity in a
+// repository touched.
+type RepoChange struct {
+	Worktree bool // files outside .git that are not ignored
+	Index    bool // the index
+	Refs     bool // loose refs or packed-refs
+	Head     bool // HEAD
+	Config   bool // the repository config
+}
+
+// IsZero reports whether the change touched nothing.
+func (c RepoChange) IsZero() bool {
+	return c == RepoChange{}
+}
+
+// merge returns the union of two changes
+func (c RepoChange) merge(other RepoChange) RepoChange {
+	return RepoChange{
+		Worktree: c.Worktree || other.Worktree,
+		Index:    c.Index || other.Index,
+		Refs:     c.Refs || other.Refs,
+		Head:     c.Head || other.Head,
+		Config:   c.Config || other.Config,
+	}
+}
+
 // RepositoryWatcher manages file system watchers for git repositories
 type RepositoryWatcher struct {
-	watcher     *fsnotify.Watcher
-	subscribers map[string][]chan struct{}
-	mu          sync.RWMutex
-	watchedDirs map[string]bool
+	watcher *fsnotify.Watcher
+	repos   map[string]*watchedRepo
+	mu      sync.Mutex
+}
+
+// watchedRepo is the watch state of one repository: the directories being
+// watched, its subscribers and the change waiting to be delivered to them.
+// In a linked worktree gitDir holds HEAD and the index while commonDir, shared
+// with the main worktree, holds the config and refs; otherwise they are equal.
+type watchedRepo struct {
+	path        string
+	gitDir      string
+	commonDir   string
+	ignore      *GitIgnore
+	dirs        map[string]bool
+	subscribers []chan RepoChange
+
+	pending    RepoChange
+	scheduled  bool
+	firstEvent time.Time
+	lastEvent  time.Time
 }
 
 // NewRepositoryWatcher creates a new repository watcher
@@ -25,9 +80,8 @@ func NewRepositoryWatcher() (*RepositoryWatcher, error) {
 	}
 
 	rw := &RepositoryWatcher{
-		watcher:     watcher,
-		subscribers: make(map[string][]chan struct{}),
-		watchedDirs: make(map[string]bool),
+		watcher: watcher,
+		repos:   make(map[string]*watchedRepo),
 	}
 
 	go rw.watchLoop()
@@ -44,9 +98,8 @@ func (rw *RepositoryWatcher) watchLoop() {
 				return
 			}
 
-			// Notify all subscribers for this repository path
 			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
-				rw.notifySubscribers(event.Name)
+				rw.handleEvent(event)
 			}
 
 		case err, ok := <-rw.watcher.Errors:
@@ -58,76 +111,264 @@ func (rw *RepositoryWatcher) watchLoop() {
 	}
 }
 
-// notifySubscribers notifies all subscribers for a given repository
-func (rw *RepositoryWatcher) notifySubscribers(path string) {
-	rw.mu.RLock()
-	defer rw.mu.RUnlock()
+// handleEvent classifies an event for the repository it belongs to, starts
+// watching directories created in it, and schedules a notification.
+func (rw *RepositoryWatcher) handleEvent(event fsnotify.Event) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	for _, repo := range rw.repos {
+		if !isUnderPath(event.Name, repo.path) && !repo.inGitDir(event.Name) {
+			continue
+		}
 
-	// Find the repository path this file belongs to
-	for repoPath, subscribers := range rw.subscribers {
-		// Check if the changed file is under this repository
-		if isUnderPath(path, repoPath) {
-			// Notify all subscribers (non-blocking)
-			for _, ch := range subscribers {
-				select {
-				case ch <- struct{}{}:
-				default:
-					// Channel full or closed, skip
-				}
+		rel, _ := filepath.Rel(repo.path, event.Name)
+		isDir := repo.dirs[event.Name]
+		if event.Op&fsnotify.Create != 0 {
+			if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+				isDir = true
 			}
 		}
+
+		if isDir && event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			rw.unwatchTree(repo, event.Name)
+		}
+
+		change := repo.classify(event.Name, isDir)
+		if change.IsZero() {
+			continue
+		}
+
+		if rel == ".gitignore" {
+			repo.ignore = NewGitIgnore(repo.path)
+		}
+		if isDir && event.Op&fsnotify.Create != 0 {
+			// Files may already exist in a new directory (mkdir -p, an
+			// extracted archive) before its watch is in place.
+			rw.watchTree(repo, event.Name)
+		}
+
+		rw.schedule(repo, change)
+	}
+}
+
+// classify maps a path in the repository to the kind of change it represents.
+// Ignored files, lock files and the rest of the git directory are skipped.
+func (repo *watchedRepo) classify(path string, isDir bool) RepoChange {
+	if strings.HasSuffix(path, ".lock") {
+		// Git writes lock files and renames them into place; the rename is
+		// reported for the final name.
+		return RepoChange{}
+	}
+
+	switch path {
+	case filepath.Join(repo.gitDir, "index"):
+		return RepoChange{Index: true}
+	case filepath.Join(repo.gitDir, "HEAD"):
+		return RepoChange{Head: true}
+	case filepath.Join(repo.commonDir, "config"):
+		return RepoChange{Config: true}
+	case filepath.Join(repo.commonDir, "packed-refs"):
+		return RepoChange{Refs: true}
+	}
+	if isUnderPath(path, filepath.Join(repo.commonDir, "refs")) {
+		return RepoChange{Refs: true}
 	}
+	if repo.inGitDir(path) {
+		return RepoChange{}
+	}
+
+	rel, _ := filepath.Rel(repo.path, path)
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return RepoChange{}
+	}
+	if repo.ignore.IsIgnored(rel, isDir) {
+		return RepoChange{}
+	}
+	return RepoChange{Worktree: true}
+}
+
+// inGitDir reports whether path is the git directory or the common directory,
+// or lies below one of them.
+func (repo *watchedRepo) inGitDir(path string) bool {
+	for _, dir := range []string{repo.gitDir, repo.commonDir} {
+		if path == dir || isUnderPath(path, dir) {
+			return true
+		}
+	}
+	return false
 }
 
-// Subscribe creates a subscription for repository changes
-func (rw *RepositoryWatcher) Subscribe(repoPath string) (<-chan struct{}, func(), error) {
+// schedule merges change into the repository's pending notification, which is
+// delivered once the repository has been quiet for watchDebounce, or at the
+// latest watchMaxDelay after the first change of the burst.
+func (rw *RepositoryWatcher) schedule(repo *watchedRepo, change RepoChange) {
+	now := time.Now()
+	repo.pending = repo.pending.merge(change)
+	repo.lastEvent = now
+	if repo.scheduled {
+		return
+	}
+
+	repo.scheduled = true
+	repo.firstEvent = now
+	time.AfterFunc(watchDebounce, func() { rw.flush(repo) })
+}
+
+// flush delivers a repository's pending change to its subscribers, or waits
+// longer if changes are still arriving.
+func (rw *RepositoryWatcher) flush(repo *watchedRepo) {
 	rw.mu.Lock()
 	defer rw.mu.Unlock()
 
-	// Ensure the repository directory is being watched
-	if !rw.watchedDirs[repoPath] {
-		// Watch the repository root
+	if rw.repos[repo.path] != repo {
+		// Unsubscribed or closed while the notification was pending
+		return
+	}
+
+	due := repo.lastEvent.Add(watchDebounce)
+	if deadline := repo.firstEvent.Add(watchMaxDelay); deadline.Before(due) {
+		due = deadline
+	}
+	if wait := time.Until(due); wait > 0 {
+		time.AfterFunc(wait, func() { rw.flush(repo) })
+		return
+	}
+
+	change := repo.pending
+	repo.pending = RepoChange{}
+	repo.scheduled = false
+
+	for _, ch := range repo.subscribers {
+		// Fold a change the subscriber has not received yet into this one,
+		// so that nothing is lost when a subscriber is slow.
+		select {
+		case undelivered := <-ch:
+			ch <- undelivered.merge(change)
+		default:
+			ch <- change
+		}
+	}
+}
+
+// watchTree adds watches for dir and every directory below it, skipping the
+// git directory (except its refs) and ignored directories.
+func (rw *RepositoryWatcher) watchTree(repo *watchedRepo, dir string) {
+	refs := filepath.Join(repo.commonDir, "refs")
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+
+		inRefs := path == refs || isUnderPath(path, refs)
+		if !inRefs {
+			rel, _ := filepath.Rel(repo.path, path)
+			rel = filepath.ToSlash(rel)
+			if rel == ".git" || repo.inGitDir(path) {
+				return filepath.SkipDir
+			}
+			if rel != "." && repo.ignore.IsIgnored(rel, true) {
+				return filepath.SkipDir
+			}
+		}
+
+		if !repo.dirs[path] {
+			if err := rw.watcher.Add(path); err != nil {
+				log.Printf("Warning: Could not watch %s: %v", path, err)
+				return nil
+			}
+			repo.dirs[path] = true
+		}
+		return nil
+	})
+}
+
+// unwatchTree forgets the watches on dir and every directory below it, so a
+// directory that is moved away or deleted is watched again if it comes back.
+func (rw *RepositoryWatcher) unwatchTree(repo *watchedRepo, dir string) {
+	for path := range repo.dirs {
+		if path == dir || isUnderPath(path, dir) {
+			rw.unwatch(repo, path)
+		}
+	}
+}
+
+// unwatch forgets the repository's watch on dir. The watch itself stays in
+// place while another repository, such as a worktree sharing the same common
+// directory, still uses it.
+func (rw *RepositoryWatcher) unwatch(repo *watchedRepo, dir string) {
+	delete(repo.dirs, dir)
+	for _, other := range rw.repos {
+		if other != repo && other.dirs[dir] {
+			return
+		}
+	}
+	rw.watcher.Remove(dir)
+}
+
+// Subscribe creates a subscription for repository changes. The whole worktree
+// is watched recursively, along with HEAD, the index, config and refs in the
+// git directory; each notification classifies what a burst of changes touched.
+func (rw *RepositoryWatcher) Subscribe(repoPath string) (<-chan RepoChange, func(), error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	repo := rw.repos[repoPath]
+	if repo == nil {
+		// Watch the repository root first so a missing repository is an error
 		if err := rw.watcher.Add(repoPath); err != nil {
 			return nil, nil, err
 		}
 
-		// Watch the .git directory for ref changes, index updates, etc.
-		gitDir := filepath.Join(repoPath, ".git")
-		if err := rw.watcher.Add(gitDir); err != nil {
-			// If .git is not a directory (could be a file in submodules), ignore the error
-			log.Printf("Warning: Could not watch .git directory: %v", err)
+		repo = &watchedRepo{
+			path:      repoPath,
+			gitDir:    gitDir(repoPath),
+			commonDir: gitCommonDir(repoPath),
+			ignore:    NewGitIgnore(repoPath),
+			dirs:      map[string]bool{repoPath: true},
 		}
+		rw.repos[repoPath] = repo
+		rw.watchTree(repo, repoPath)
 
-		rw.watchedDirs[repoPath] = true
+		// Watch the git directory for HEAD moves and index updates, and the
+		// common directory for config and refs changes.
+		for _, dir := range []string{repo.gitDir, repo.commonDir} {
+			if repo.dirs[dir] {
+				continue
+			}
+			if err := rw.watcher.Add(dir); err != nil {
+				log.Printf("Warning: Could not watch git directory %s: %v", dir, err)
+				continue
+			}
+			repo.dirs[dir] = true
+		}
+		rw.watchTree(repo, filepath.Join(repo.commonDir, "refs"))
 	}
 
 	// Create a buffered channel to avoid blocking the notifier
-	ch := make(chan struct{}, 1)
-
-	// Add subscriber
-	rw.subscribers[repoPath] = append(rw.subscribers[repoPath], ch)
+	ch := make(chan RepoChange, 1)
+	repo.subscribers = append(repo.subscribers, ch)
 
 	// Return unsubscribe function
 	unsubscribe := func() {
 		rw.mu.Lock()
 		defer rw.mu.Unlock()
 
-		subscribers := rw.subscribers[repoPath]
-		for i, sub := range subscribers {
+		for i, sub := range repo.subscribers {
 			if sub == ch {
-				// Remove this subscriber
-				rw.subscribers[repoPath] = append(subscribers[:i], subscribers[i+1:]...)
+				repo.subscribers = append(repo.subscribers[:i], repo.subscribers[i+1:]...)
 				close(ch)
 				break
 			}
 		}
 
-		// If no more subscribers, stop watching this directory
-		if len(rw.subscribers[repoPath]) == 0 {
-			delete(rw.subscribers, repoPath)
-			rw.watcher.Remove(repoPath)
-			rw.watcher.Remove(filepath.Join(repoPath, ".git"))
-			delete(rw.watchedDirs, repoPath)
+		// If no more subscribers, stop watching this repository
+		if len(repo.subscribers) == 0 && rw.repos[repoPath] == repo {
+			for dir := range repo.dirs {
+				rw.unwatch(repo, dir)
+			}
+			delete(rw.repos, repoPath)
 		}
 	}
 
@@ -160,14 +401,14 @@ func (rw *RepositoryWatcher) Close() error {
 	defer rw.mu.Unlock()
 
 	// Close all subscriber channels
-	for _, subscribers := range rw.subscribers {
-		for _, ch := range subscribers {
+	for _, repo := range rw.repos {
+		for _, ch := range repo.subscribers {
 			close(ch)
 		}
+		repo.subscribers = nil
 	}
 
-	rw.subscribers = make(map[string][]chan struct{})
-	rw.watchedDirs = make(map[string]bool)
+	rw.repos = make(map[string]*watchedRepo)
 
 	return rw.watcher.Close()
 }
@@ -179,5 +420,5 @@ func isUnderPath(filePath, dirPath string) bool {
 		return false
 	}
 	// If the relative path starts with "..", the file is outside the directory
-	return len(rel) > 0 && rel[0] != '.' && rel[:2] != ".."
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
@@ -0,0 +1,176 @@
+package git
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIsUnderPath(t *testing.T) {
+	repo := filepath.Join("/tmp", "repo")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(repo, "a"), true},
+		{filepath.Join(repo, "src", "main.go"), true},
+		{filepath.Join(repo, ".git", "refs", "heads", "main"), true},
+		{filepath.Join(repo, "..file"), true},
+		{repo, false},
+		{filepath.Join("/tmp", "repo2", "a"), false},
+		{filepath.Join("/tmp", "other"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isUnderPath(tt.path, repo); got != tt.want {
+			t.Errorf("isUnderPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// nextChange waits up to timeout for a notification from the watcher.
+func nextChange(t *testing.T, changes <-chan RepoChange, timeout time.Duration) (RepoChange, bool) {
+	t.Helper()
+
+	select {
+	case change, ok := <-changes:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return change, true
+	case <-time.After(timeout):
+		return RepoChange{}, false
+	}
+}
+
+func subscribeTestRepo(t *testing.T, dir string) <-chan RepoChange {
+	t.Helper()
+
+	watcher, err := NewRepositoryWatcher()
+	if err != nil {
+		t.Skipf("file system watcher unavailable: %v", err)
+	}
+	t.Cleanup(func() { watcher.Close() })
+
+	changes, unsubscribe, err := watcher.Subscribe(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(unsubscribe)
+	return changes
+}
+
+func TestRepositoryWatcher_WatchesWorktreeRecursively(t *testing.T) {
+	dir := newCLITestRepo(t)
+	commitFile(t, dir, ".gitignore", "node_modules/\n*.log\n", "Ignore dependencies")
+	writeTestFile(t, dir, "src/pkg/main.go", "package main\n")
+	changes := subscribeTestRepo(t, dir)
+
+	writeTestFile(t, dir, "src/pkg/main.go", "package main\n\nfunc main() {}\n")
+	if change, ok := nextChange(t, changes, 2*time.Second); !ok || change != (RepoChange{Worktree: true}) {
+		t.Fatalf("expected a worktree change in an existing subdirectory, got %+v (%v)", change, ok)
+	}
+
+	// Directories created after subscribing are picked up, including files
+	// written into them before their watch was added.
+	writeTestFile(t, dir, "new/deeper/file.txt", "one\n")
+	if _, ok := nextChange(t, changes, 2*time.Second); !ok {
+		t.Fatal("expected a change for a new directory")
+	}
+	writeTestFile(t, dir, "new/deeper/file.txt", "two\n")
+	if change, ok := nextChange(t, changes, 2*time.Second); !ok || !change.Worktree {
+		t.Fatalf("expected new directories to be watched, got %+v (%v)", change, ok)
+	}
+
+	writeTestFile(t, dir, "node_modules/pkg/index.js", "module.exports = {}\n")
+	writeTestFile(t, dir, "debug.log", "noise\n")
+	if change, ok := nextChange(t, changes, 500*time.Millisecond); ok {
+		t.Fatalf("expected ignored paths to be skipped, got %+v", change)
+	}
+}
+
+func TestRepositoryWatcher_CoalescesBursts(t *testing.T) {
+	dir := newCLITestRepo(t)
+	changes := subscribeTestRepo(t, dir)
+
+	for i := 0; i < 50; i++ {
+		writeTestFile(t, dir, filepath.Join("burst", strconv.Itoa(i%5), "file"+strconv.Itoa(i)+".txt"), "x\n")
+	}
+
+	if _, ok := nextChange(t, changes, 2*time.Second); !ok {
+		t.Fatal("expected a notification for the burst")
+	}
+	if change, ok := nextChange(t, changes, 500*time.Millisecond); ok {
+		t.Fatalf("expected the burst to be coalesced into one notification, got another %+v", change)
+	}
+}
+
+func TestRepositoryWatcher_ClassifiesGitChanges(t *testing.T) {
+	dir := newCLITestRepo(t)
+	changes := subscribeTestRepo(t, dir)
+
+	tests := []struct {
+		name  string
+		run   func()
+		check func(RepoChange) bool
+	}{
+		{"branch created", func() { runGit(t, dir, "branch", "feature/nested") }, func(c RepoChange) bool { return c.Refs && !c.Head }},
+		{"HEAD switched", func() { runGit(t, dir, "symbolic-ref", "HEAD", "refs/heads/feature/nested") }, func(c RepoChange) bool { return c.Head && !c.Refs }},
+		{"file staged", func() {
+			writeTestFile(t, dir, "staged.txt", "staged\n")
+			runGit(t, dir, "add", "staged.txt")
+		}, func(c RepoChange) bool { return c.Index && c.Worktree }},
+		{"config edited", func() { runGit(t, dir, "config", "core.autocrlf", "false") }, func(c RepoChange) bool { return c == RepoChange{Config: true} }},
+		{"tag in packed refs", func() {
+			runGit(t, dir, "tag", "v1")
+			runGit(t, dir, "pack-refs", "--all")
+		}, func(c RepoChange) bool { return c.Refs }},
+	}
+
+	for _, tt := range tests {
+		tt.run()
+		change, ok := nextChange(t, changes, 2*time.Second)
+		if !ok || !tt.check(change) {
+			t.Errorf("%s: unexpected change %+v (%v)", tt.name, change, ok)
+		}
+	}
+}
+
+func TestRepositoryWatcher_ClassifiesLinkedWorktreeChanges(t *testing.T) {
+	dir := newCLITestRepo(t)
+	worktree := filepath.Join(t.TempDir(), "linked")
+	runGit(t, dir, "worktree", "add", "-b", "linked", worktree)
+	changes := subscribeTestRepo(t, worktree)
+
+	tests := []struct {
+		name  string
+		run   func()
+		check func(RepoChange) bool
+	}{
+		{"file staged", func() {
+			writeTestFile(t, worktree, "staged.txt", "staged\n")
+			runGit(t, worktree, "add", "staged.txt")
+		}, func(c RepoChange) bool { return c.Index && c.Worktree }},
+		{"HEAD switched", func() { runGit(t, worktree, "symbolic-ref", "HEAD", "refs/heads/other") }, func(c RepoChange) bool { return c.Head && !c.Refs }},
+		{"branch created in the main worktree", func() { runGit(t, dir, "branch", "shared") }, func(c RepoChange) bool { return c == RepoChange{Refs: true} }},
+		{"config edited", func() { runGit(t, dir, "config", "core.autocrlf", "false") }, func(c RepoChange) bool { return c == RepoChange{Config: true} }},
+	}
+
+	for _, tt := range tests {
+		tt.run()
+		change, ok := nextChange(t, changes, 2*time.Second)
+		if !ok || !tt.check(change) {
+			t.Errorf("%s: unexpected change %+v (%v)", tt.name, change, ok)
+		}
+	}
+
+	// The main worktree's own HEAD and index are not this worktree's.
+	writeTestFile(t, dir, "main.txt", "main\n")
+	runGit(t, dir, "add", "main.txt")
+	runGit(t, dir, "symbolic-ref", "HEAD", "refs/heads/shared")
+	if change, ok := nextChange(t, changes, 500*time.Millisecond); ok {
+		t.Fatalf("expected changes in the main worktree to be skipped, got %+v", change)
+	}
+}
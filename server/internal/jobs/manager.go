@@ -0,0 +1,321 @@
+// Package jobs runs long operations in the background, so that a request can
+// return at once and the work survives the client going away.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitweb/server/internal/models"
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// maxFinishedJobs is how many finished jobs are kept for polling.
+	maxFinishedJobs = 100
+	// progressInterval throttles progress notifications; status changes are
+	// always reported.
+	progressInterval = 250 * time.Millisecond
+)
+
+var percentPattern = regexp.MustCompile(`(\d{1,3})%`)
+
+var (
+	// ErrJobNotFound means there is no job with that ID, or it was dropped.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobFinished means the job already succeeded, failed or was cancelled.
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// Func is the work of a job. It should return when ctx is cancelled, and may
+// write progress output, as git prints it, to progress.
+type Func func(ctx context.Context, progress io.Writer) (any, error)
+
+// AdmitFunc blocks until a job may start, returning a func that releases its
+// slot when the job finishes.
+type AdmitFunc func(ctx context.Context) (func(), error)
+
+// Manager runs jobs and keeps their state for listing and polling.
+type Manager struct {
+	mu     sync.Mutex
+	jobs   map[string]*job
+	admit  AdmitFunc
+	notify func(models.Job)
+	closed bool
+}
+
+type job struct {
+	state      models.Job
+	cancel     context.CancelFunc
+	done       chan struct{}
+	notifiedAt time.Time
+	err        error // the error a failed job returned
+}
+
+// NewManager creates a job manager. admit, when set, gates the start of every
+// job; notify, when set, is called with a snapshot of a job whenever its
+// status or progress changes.
+func NewManager(admit AdmitFunc, notify func(models.Job)) *Manager {
+	return &Manager{
+		jobs:   make(map[string]*job),
+		admit:  admit,
+		notify: notify,
+	}
+}
+
+// Start queues fn as a new job and returns it. The job runs once admitted.
+func (m *Manager) Start(kind, repoID string, fn Func) models.Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		state: models.Job{
+			ID:        newJobID(),
+			Kind:      kind,
+			RepoID:    repoID,
+			Status:    StatusQueued,
+			CreatedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		cancel()
+	}
+	m.jobs[j.state.ID] = j
+	m.update(j, true, func(*models.Job) {})
+	snapshot := j.state
+	m.mu.Unlock()
+
+	go m.run(ctx, j, fn)
+	return snapshot
+}
+
+func (m *Manager) run(ctx context.Context, j *job, fn Func) {
+	defer close(j.done)
+	defer j.cancel()
+
+	if m.admit != nil {
+		release, err := m.admit(ctx)
+		if err != nil {
+			m.finish(ctx, j, nil, err)
+			return
+		}
+		defer release()
+	}
+
+	m.mu.Lock()
+	m.update(j, true, func(state *models.Job) {
+		now := time.Now().UTC()
+		state.Status = StatusRunning
+		state.StartedAt = &now
+	})
+	m.mu.Unlock()
+
+	result, err := fn(ctx, &progressWriter{manager: m, job: j})
+	m.finish(ctx, j, result, err)
+}
+
+// finish records a job's outcome and drops the oldest finished jobs beyond
+// maxFinishedJobs.
+func (m *Manager) finish(ctx context.Context, j *job, result any, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.update(j, true, func(state *models.Job) {
+		now := time.Now().UTC()
+		state.FinishedAt = &now
+		switch {
+		case err == nil:
+			state.Status = StatusSucceeded
+			state.Result = result
+		case ctx.Err() != nil:
+			state.Status = StatusCancelled
+			state.Error = "job cancelled"
+		default:
+			state.Status = StatusFailed
+			state.Error = err.Error()
+			j.err = err
+		}
+	})
+
+	var finished []*job
+	for _, other := range m.jobs {
+		if other.state.FinishedAt != nil {
+			finished = append(finished, other)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(a, b int) bool {
+		return finished[a].state.FinishedAt.Before(*finished[b].state.FinishedAt)
+	})
+	for _, old := range finished[:len(finished)-maxFinishedJobs] {
+		delete(m.jobs, old.state.ID)
+	}
+}
+
+// update applies change to a job and notifies about it. Progress-only
+// updates (force false) are throttled. m.mu must be held.
+func (m *Manager) update(j *job, force bool, change func(*models.Job)) {
+	change(&j.state)
+	if m.notify == nil {
+		return
+	}
+	if !force && time.Since(j.notifiedAt) < progressInterval {
+		return
+	}
+	j.notifiedAt = time.Now()
+	m.notify(j.state)
+}
+
+// Get returns a job by ID.
+func (m *Manager) Get(id string) (models.Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return models.Job{}, false
+	}
+	return j.state, true
+}
+
+// List returns the jobs for a repository (all jobs when repoID is empty),
+// newest first.
+func (m *Manager) List(repoID string) []models.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := []models.Job{}
+	for _, j := range m.jobs {
+		if repoID == "" || j.state.RepoID == repoID {
+			list = append(list, j.state)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].CreatedAt.After(list[b].CreatedAt)
+	})
+	return list
+}
+
+// Cancel asks a queued or running job to stop. The job reports cancelled once
+// its work has returned.
+func (m *Manager) Cancel(id string) (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return models.Job{}, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	if j.state.FinishedAt != nil {
+		return j.state, fmt.Errorf("%w: %s", ErrJobFinished, id)
+	}
+	j.cancel()
+	return j.state, nil
+}
+
+// Wait blocks until a job finishes or ctx is done, returning its final state.
+func (m *Manager) Wait(ctx context.Context, id string) (models.Job, error) {
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return models.Job{}, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		return models.Job{}, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return j.state, nil
+}
+
+// Err returns the error a failed job returned, unchanged so that callers can
+// inspect it with errors.Is. It is nil for jobs that did not fail.
+func (m *Manager) Err(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if j, ok := m.jobs[id]; ok {
+		return j.err
+	}
+	return nil
+}
+
+// Shutdown cancels every unfinished job and refuses to run new ones.
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	for _, j := range m.jobs {
+		j.cancel()
+	}
+}
+
+// progressWriter records the latest line of progress output as the job's
+// progress. git redraws progress lines with carriage returns.
+type progressWriter struct {
+	manager *Manager
+	job     *job
+	partial string
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	text := w.partial + string(p)
+	end := strings.LastIndexAny(text, "\r\n")
+	if end < 0 {
+		w.partial = text
+		return len(p), nil
+	}
+	w.partial = text[end+1:]
+
+	lines := strings.FieldsFunc(text[:end], func(r rune) bool { return r == '\r' || r == '\n' })
+	if len(lines) == 0 {
+		return len(p), nil
+	}
+	line := strings.TrimSpace(lines[len(lines)-1])
+
+	w.manager.mu.Lock()
+	defer w.manager.mu.Unlock()
+	w.manager.update(w.job, false, func(state *models.Job) {
+		state.Progress = line
+		if match := percentPattern.FindStringSubmatch(line); match != nil {
+			state.Percent, _ = strconv.Atoi(match[1])
+		}
+	})
+	return len(p), nil
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
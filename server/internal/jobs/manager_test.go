@@ -0,0 +1,171 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitweb/server/internal/models"
+)
+
+func waitFinished(t *testing.T, m *Manager, id string) models.Job {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("job %s did not finish: %v", id, err)
+	}
+	return job
+}
+
+func TestManager_RunsJobAndReportsProgress(t *testing.T) {
+	var mu sync.Mutex
+	var statuses []string
+	m := NewManager(nil, func(job models.Job) {
+		mu.Lock()
+		defer mu.Unlock()
+		statuses = append(statuses, job.Status)
+	})
+
+	job := m.Start("push", "repo", func(ctx context.Context, progress io.Writer) (any, error) {
+		io.WriteString(progress, "Counting objects:  45% (9/20)\rCounting obj")
+		io.WriteString(progress, "ects: 100% (20/20), done.\nWriting")
+		return "pushed", nil
+	})
+	if job.Status != StatusQueued || job.Kind != "push" || job.RepoID != "repo" || job.ID == "" {
+		t.Fatalf("unexpected new job: %+v", job)
+	}
+
+	job = waitFinished(t, m, job.ID)
+	if job.Status != StatusSucceeded || job.Result != "pushed" || job.StartedAt == nil || job.FinishedAt == nil {
+		t.Fatalf("unexpected finished job: %+v", job)
+	}
+	if job.Progress != "Counting objects: 100% (20/20), done." || job.Percent != 100 {
+		t.Errorf("unexpected progress %q (%d%%)", job.Progress, job.Percent)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// Progress written right after the job started is throttled
+	if strings.Join(statuses, ",") != "queued,running,succeeded" {
+		t.Errorf("unexpected notifications %v", statuses)
+	}
+}
+
+func TestManager_ReportsFailures(t *testing.T) {
+	errRemote := errors.New("remote not found")
+	m := NewManager(nil, nil)
+	job := m.Start("pull", "repo", func(context.Context, io.Writer) (any, error) {
+		return nil, fmt.Errorf("%w: origin", errRemote)
+	})
+
+	job = waitFinished(t, m, job.ID)
+	if job.Status != StatusFailed || job.Error != "remote not found: origin" || job.Result != nil {
+		t.Fatalf("unexpected failed job: %+v", job)
+	}
+	if err := m.Err(job.ID); !errors.Is(err, errRemote) {
+		t.Errorf("expected the job's own error, got %v", err)
+	}
+}
+
+func TestManager_CancelsQueuedAndRunningJobs(t *testing.T) {
+	gate := make(chan struct{})
+	m := NewManager(func(ctx context.Context) (func(), error) {
+		select {
+		case <-gate:
+			return func() {}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, nil)
+
+	queued := m.Start("clone", "", func(context.Context, io.Writer) (any, error) {
+		t.Error("cancelled job must not run")
+		return nil, nil
+	})
+	if _, err := m.Cancel(queued.ID); err != nil {
+		t.Fatal(err)
+	}
+	if job := waitFinished(t, m, queued.ID); job.Status != StatusCancelled || job.StartedAt != nil {
+		t.Fatalf("expected queued job to be cancelled before starting, got %+v", job)
+	}
+
+	close(gate)
+	started := make(chan struct{})
+	running := m.Start("clone", "", func(ctx context.Context, _ io.Writer) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+	if _, err := m.Cancel(running.ID); err != nil {
+		t.Fatal(err)
+	}
+	if job := waitFinished(t, m, running.ID); job.Status != StatusCancelled || job.Error != "job cancelled" {
+		t.Fatalf("expected running job to be cancelled, got %+v", job)
+	}
+
+	if _, err := m.Cancel(running.ID); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("expected already finished error, got %v", err)
+	}
+	if _, err := m.Cancel("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected job not found error, got %v", err)
+	}
+}
+
+func TestManager_ListsNewestFirstAndPrunesFinishedJobs(t *testing.T) {
+	m := NewManager(nil, nil)
+	done := func(context.Context, io.Writer) (any, error) { return nil, nil }
+
+	first := m.Start("push", "a", done)
+	waitFinished(t, m, first.ID)
+	time.Sleep(time.Millisecond)
+	second := m.Start("push", "b", done)
+	waitFinished(t, m, second.ID)
+
+	list := m.List("")
+	if len(list) != 2 || list[0].ID != second.ID || list[1].ID != first.ID {
+		t.Fatalf("expected newest first, got %+v", list)
+	}
+	if list := m.List("a"); len(list) != 1 || list[0].ID != first.ID {
+		t.Fatalf("expected only repository a's job, got %+v", list)
+	}
+
+	for i := 0; i < maxFinishedJobs; i++ {
+		waitFinished(t, m, m.Start("push", "a", done).ID)
+	}
+	if got := len(m.List("")); got != maxFinishedJobs {
+		t.Fatalf("expected %d retained jobs, got %d", maxFinishedJobs, got)
+	}
+	if _, ok := m.Get(first.ID); ok {
+		t.Error("expected the oldest finished job to be pruned")
+	}
+}
+
+func TestManager_ShutdownCancelsJobs(t *testing.T) {
+	m := NewManager(nil, nil)
+	started := make(chan struct{})
+	job := m.Start("pull", "repo", func(ctx context.Context, _ io.Writer) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+
+	m.Shutdown()
+	if job := waitFinished(t, m, job.ID); job.Status != StatusCancelled {
+		t.Fatalf("expected job to be cancelled on shutdown, got %+v", job)
+	}
+	if job := waitFinished(t, m, m.Start("pull", "repo", func(ctx context.Context, _ io.Writer) (any, error) {
+		return nil, ctx.Err()
+	}).ID); job.Status != StatusCancelled {
+		t.Fatalf("expected jobs started after shutdown to be cancelled, got %+v", job)
+	}
+}
@@ -13,19 +13,23 @@ type Repository struct {
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 	IsLocal       bool      `json:"is_local" example:"true"`
+	Bare          bool      `json:"bare,omitempty" example:"false"` // bare or mirror clone without a worktree
 	CurrentBranch string    `json:"current_branch,omitempty" example:"main"`
 }
 
 type RepositoryStatus struct {
-	RepositoryID string       `json:"repository_id" example:"repo-abc123"`
-	Branch       string       `json:"branch" example:"main"`
-	IsClean      bool         `json:"is_clean" example:"false"`
-	Ahead        int          `json:"ahead" example:"2"`
-	Behind       int          `json:"behind" example:"1"`
-	Staged       []FileChange `json:"staged"`
-	Modified     []FileChange `json:"modified"`
-	Untracked    []string     `json:"untracked"`
-	Conflicts    []string     `json:"conflicts"`
+	RepositoryID    string         `json:"repository_id" example:"repo-abc123"`
+	Branch          string         `json:"branch" example:"main"`
+	IsClean         bool           `json:"is_clean" example:"false"`
+	Ahead           int            `json:"ahead" example:"2"`
+	Behind          int            `json:"behind" example:"1"`
+	Staged          []FileChange   `json:"staged"`
+	Modified        []FileChange   `json:"modified"`
+	Untracked       []string       `json:"untracked"`
+	Conflicts       []string       `json:"conflicts"`
+	ConflictedFiles []ConflictFile `json:"conflicted_files"`
+	Operation       string         `json:"operation,omitempty" example:"merge"` // in-progress operation: "merge" | "rebase" | "cherry-pick" | "revert"
+	LastFetch       *FetchStatus   `json:"last_fetch,omitempty"`                // how fresh Ahead/Behind are; nil until the first fetch
 }
 
 type FileChange struct {
@@ -76,6 +80,19 @@ type CreateRepositoryRequest struct {
 	URL         string `json:"url,omitempty" example:"https://github.com/user/repo.git"`
 	Description string `json:"description,omitempty" example:"A new repository"`
 	IsLocal     bool   `json:"is_local" example:"true"`
+	CloneOptions
+}
+
+// CloneOptions - how a repository is cloned when CreateRepositoryRequest has a URL
+type CloneOptions struct {
+	Depth             int    `json:"depth,omitempty" example:"1"`                     // truncate history to this many commits
+	ShallowSince      string `json:"shallow_since,omitempty" example:"2024-01-01"`    // truncate history to commits after this date
+	SingleBranch      bool   `json:"single_branch,omitempty" example:"true"`          // fetch only the cloned branch
+	Ref               string `json:"ref,omitempty" example:"main"`                    // branch or tag to check out instead of the remote HEAD
+	RecurseSubmodules bool   `json:"recurse_submodules,omitempty" example:"false"`    // clone submodules recursively
+	Bare              bool   `json:"bare,omitempty" example:"false"`                  // clone without a worktree
+	Mirror            bool   `json:"mirror,omitempty" example:"false"`                // bare clone mirroring every ref of the remote
+	Credential        string `json:"credential,omitempty" example:"3f2a9c1e5b7d4a60"` // credential to clone with, kept as origin's credential
 }
 
 type DiffResult struct {
@@ -129,21 +146,32 @@ type RepoDirectoryListing struct {
 }
 
 type CommitDetail struct {
-	Hash       string     `json:"hash" example:"abc123def456..."`
-	Message    string     `json:"message" example:"Fix bug in authentication"`
-	Author     Author     `json:"author"`
-	Date       time.Time  `json:"date"`
-	ParentHash string     `json:"parent_hash,omitempty"`
-	Changes    []FileDiff `json:"changes"`
-	Stats      DiffStats  `json:"stats"`
+	Hash       string          `json:"hash" example:"abc123def456..."`
+	Message    string          `json:"message" example:"Fix bug in authentication"`
+	Author     Author          `json:"author"`
+	Date       time.Time       `json:"date"`
+	ParentHash string          `json:"parent_hash,omitempty"`
+	Signature  CommitSignature `json:"signature"`
+	Changes    []FileDiff      `json:"changes"`
+	Stats      DiffStats       `json:"stats"`
+}
+
+// CommitSignature - verification result for a commit's OpenPGP or SSH signature
+type CommitSignature struct {
+	Status string `json:"status" example:"good"`              // "good" | "bad" | "unknown-key" | "expired" | "unsigned"
+	Format string `json:"format,omitempty" example:"ssh"`     // "openpgp" | "ssh" | "x509"
+	Key    string `json:"key,omitempty" example:"SHA256:..."` // key ID or fingerprint
+	Signer string `json:"signer,omitempty" example:"john@example.com"`
 }
 
 type FileDiff struct {
 	Path       string `json:"path" example:"src/auth.go"`
+	OldPath    string `json:"old_path,omitempty"` // path before a rename or copy
 	ChangeType string `json:"change_type" example:"modified"`
 	Additions  int    `json:"additions" example:"5"`
 	Deletions  int    `json:"deletions" example:"2"`
 	Patch      string `json:"patch"`
+	EOLOnly    bool   `json:"eol_only,omitempty"` // only line endings changed
 }
 
 type DiffStats struct {
@@ -152,6 +180,205 @@ type DiffStats struct {
 	FilesChanged int `json:"files_changed" example:"2"`
 }
 
+type MergeRequest struct {
+	Source   string `json:"source" example:"feature/login"`
+	Strategy string `json:"strategy,omitempty" example:"no-ff"` // "ff-only" | "no-ff" | "squash"
+}
+
+type MergeResult struct {
+	Status    string   `json:"status" example:"merged"` // "up-to-date" | "fast-forward" | "merged" | "squashed" | "conflicted"
+	Hash      string   `json:"hash,omitempty" example:"abc123def456..."`
+	Conflicts []string `json:"conflicts"`
+}
+
+// ApplyCommitsRequest - commits to cherry-pick or revert, in order
+type ApplyCommitsRequest struct {
+	Commits  []string `json:"commits"`
+	NoCommit bool     `json:"no_commit,omitempty" example:"false"` // apply the changes to the index and working tree only
+	Mainline int      `json:"mainline,omitempty" example:"1"`      // parent number to diff against for merge commits
+}
+
+// ApplyCommitsResult - outcome of a cherry-pick or revert
+type ApplyCommitsResult struct {
+	Status    string         `json:"status" example:"completed"` // "completed" | "applied" | "conflicted"
+	Hash      string         `json:"hash,omitempty" example:"abc123def456..."`
+	Conflicts []ConflictFile `json:"conflicts"`
+}
+
+// DiscardRequest - discard working tree changes to a single path
+type DiscardRequest struct {
+	Source  string `json:"source,omitempty" example:"index"` // "index" (default) or "head"
+	Confirm bool   `json:"confirm"`                          // must be true; discarded changes cannot be recovered
+}
+
+// CleanRequest - delete untracked files that are not ignored
+type CleanRequest struct {
+	Paths   []string `json:"paths,omitempty"`   // limit to these files or directories (default: whole repository)
+	DryRun  bool     `json:"dry_run,omitempty"` // list what would be removed without deleting anything
+	Confirm bool     `json:"confirm"`           // must be true unless DryRun is set
+}
+
+// CleanResult - untracked files removed (or that would be removed) by a clean
+type CleanResult struct {
+	Removed []string `json:"removed"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+// ResetRequest - move the current branch to another commit
+type ResetRequest struct {
+	Target  string `json:"target,omitempty" example:"HEAD~1"` // default HEAD
+	Mode    string `json:"mode,omitempty" example:"mixed"`    // "soft" | "mixed" (default) | "hard"
+	Confirm bool   `json:"confirm"`                           // required for hard resets
+}
+
+// ResetResult - the commit HEAD points to after a reset
+type ResetResult struct {
+	Mode string `json:"mode" example:"mixed"`
+	Hash string `json:"hash" example:"abc123def456..."`
+}
+
+// ─── CONFLICT MODELS ───
+
+// ConflictFile - an unmerged path and how it conflicts
+type ConflictFile struct {
+	Path string `json:"path" example:"src/main.go"`
+	Type string `json:"type" example:"both-modified"` // "both-modified" | "both-added" | "both-deleted" | "added-by-us" | "added-by-them" | "deleted-by-us" | "deleted-by-them"
+}
+
+// ConflictVersion - one side of a conflict as stored in the index
+type ConflictVersion struct {
+	Hash    string `json:"hash" example:"abc123def456..."`
+	Mode    string `json:"mode" example:"0100644"`
+	Content string `json:"content"`
+	Binary  bool   `json:"binary"`
+}
+
+// ConflictHunk - a region delimited by conflict markers in the working tree file
+type ConflictHunk struct {
+	Index       int      `json:"index"`
+	StartLine   int      `json:"start_line"` // line of the <<<<<<< marker (1-based)
+	EndLine     int      `json:"end_line"`   // line of the >>>>>>> marker (1-based)
+	OursLabel   string   `json:"ours_label,omitempty" example:"HEAD"`
+	TheirsLabel string   `json:"theirs_label,omitempty" example:"feature"`
+	Ours        []string `json:"ours"`
+	Base        []string `json:"base,omitempty"` // only present with diff3-style markers
+	Theirs      []string `json:"theirs"`
+}
+
+// ConflictDetail - base, ours and theirs content plus parsed hunks for one path
+type ConflictDetail struct {
+	Path     string           `json:"path" example:"src/main.go"`
+	Type     string           `json:"type" example:"both-modified"`
+	Base     *ConflictVersion `json:"base,omitempty"`
+	Ours     *ConflictVersion `json:"ours,omitempty"`
+	Theirs   *ConflictVersion `json:"theirs,omitempty"`
+	Worktree string           `json:"worktree"`
+	Hunks    []ConflictHunk   `json:"hunks"`
+}
+
+// ResolveConflictRequest - how to resolve a conflicted path
+type ResolveConflictRequest struct {
+	// Resolution is one of "ours", "theirs", "base", "manual", "hunks" or "delete".
+	Resolution string `json:"resolution" example:"manual"`
+	// Content is the resolved file content when Resolution is "manual".
+	Content string `json:"content,omitempty"`
+	// Hunks holds one choice per conflict hunk ("ours", "theirs", "base", "both")
+	// when Resolution is "hunks".
+	Hunks []string `json:"hunks,omitempty"`
+}
+
+// ─── STASH MODELS ───
+
+// StashEntry - one entry of the stash list
+type StashEntry struct {
+	Index   int       `json:"index" example:"0"`
+	Ref     string    `json:"ref" example:"stash@{0}"`
+	Hash    string    `json:"hash" example:"abc123def456..."`
+	Branch  string    `json:"branch,omitempty" example:"main"`
+	Message string    `json:"message" example:"WIP: login form"`
+	Date    time.Time `json:"date"`
+}
+
+// StashRequest - options for saving the working tree to the stash
+type StashRequest struct {
+	Message          string `json:"message,omitempty" example:"WIP: login form"`
+	IncludeUntracked bool   `json:"include_untracked,omitempty" example:"false"`
+}
+
+// StashApplyResult - outcome of applying or popping a stash entry
+type StashApplyResult struct {
+	Status    string         `json:"status" example:"applied"` // "applied" | "conflicted"
+	Dropped   bool           `json:"dropped"`                  // true when pop removed the entry
+	Conflicts []ConflictFile `json:"conflicts"`
+}
+
+// StashDetail - a stash entry with its tokenized changes
+type StashDetail struct {
+	Stash StashEntry          `json:"stash"`
+	Files []TokenizedFileDiff `json:"files"`
+	Stats DiffStats           `json:"stats"`
+}
+
+// ─── TAG MODELS ───
+
+// Tag - a lightweight or annotated tag and the commit it points to
+type Tag struct {
+	Name      string    `json:"name" example:"v1.2.0"`
+	Hash      string    `json:"hash" example:"abc123def456..."`   // tag object for annotated tags, commit otherwise
+	Target    string    `json:"target" example:"abc123def456..."` // commit the tag resolves to
+	Annotated bool      `json:"annotated" example:"true"`
+	Tagger    *Author   `json:"tagger,omitempty"`
+	Message   string    `json:"message,omitempty" example:"Release 1.2.0"`
+	Date      time.Time `json:"date"` // tagger date for annotated tags, commit date otherwise
+}
+
+// CreateTagRequest - a tag to create. A non-empty message creates an annotated tag.
+type CreateTagRequest struct {
+	Name    string  `json:"name" example:"v1.2.0"`
+	Target  string  `json:"target,omitempty" example:"main"` // commit, branch or other revision (default HEAD)
+	Message string  `json:"message,omitempty" example:"Release 1.2.0"`
+	Tagger  *Author `json:"tagger,omitempty"` // defaults to the repository's configured identity
+}
+
+// PushTagsRequest - push a single tag, or every tag when Tag is empty
+type PushTagsRequest struct {
+	Remote string `json:"remote,omitempty" example:"origin"`
+	Tag    string `json:"tag,omitempty" example:"v1.2.0"`
+}
+
+// ─── REBASE MODELS ───
+
+// RebaseStep - one line of an interactive rebase plan
+type RebaseStep struct {
+	Action  string `json:"action" example:"pick"` // "pick" | "reword" | "squash" | "fixup" | "drop"
+	Hash    string `json:"hash" example:"abc123def456..."`
+	Message string `json:"message,omitempty"` // new message for reword (required), or for the combined commit after squash/fixup
+}
+
+// RebaseRequest - replay Steps, in order, onto Onto
+type RebaseRequest struct {
+	Onto  string       `json:"onto" example:"main"`
+	Steps []RebaseStep `json:"steps"`
+}
+
+// RebaseProgress - position of an in-progress rebase
+type RebaseProgress struct {
+	Branch  string `json:"branch,omitempty" example:"feature/login"`
+	Onto    string `json:"onto" example:"abc123def456..."`
+	Done    int    `json:"done" example:"2"`
+	Total   int    `json:"total" example:"5"`
+	Current string `json:"current,omitempty" example:"abc123def456..."` // commit the rebase stopped at
+}
+
+// RebaseResult - outcome of starting, continuing, skipping or aborting a rebase
+type RebaseResult struct {
+	Status    string          `json:"status" example:"completed"` // "completed" | "conflicted" | "stopped" | "aborted"
+	Hash      string          `json:"hash,omitempty" example:"abc123def456..."`
+	Message   string          `json:"message,omitempty"` // why the rebase stopped, when it did not stop on conflicts
+	Conflicts []ConflictFile  `json:"conflicts"`
+	Progress  *RebaseProgress `json:"progress,omitempty"`
+}
+
 type GenerateCommitMessageResponse struct {
 	Message string `json:"message" example:"Fix: Handle null pointer in auth flow"`
 }
@@ -162,8 +389,77 @@ type GitConfig struct {
 }
 
 type RepoRemote struct {
-	Name string `json:"name" example:"origin"`
-	URL  string `json:"url" example:"https://github.com/user/repo.git"`
+	Name       string `json:"name" example:"origin"`
+	URL        string `json:"url" example:"https://github.com/user/repo.git"`
+	Credential string `json:"credential,omitempty" example:"3f2a9c1e5b7d4a60"` // credential chosen for this remote; otherwise the one stored for its host is used
+}
+
+// ─── REMOTE MODELS ───
+
+// AddRemoteRequest - register a new remote
+type AddRemoteRequest struct {
+	Name       string `json:"name" example:"upstream"`
+	URL        string `json:"url" example:"https://github.com/upstream/repo.git"`
+	Credential string `json:"credential,omitempty" example:"3f2a9c1e5b7d4a60"`
+}
+
+// UpdateRemoteRequest - rename a remote, change its URL and/or choose its credential; empty fields are left unchanged, and an empty credential clears the choice
+type UpdateRemoteRequest struct {
+	Name       string  `json:"name,omitempty" example:"fork"`
+	URL        string  `json:"url,omitempty" example:"https://github.com/user/fork.git"`
+	Credential *string `json:"credential,omitempty" example:"3f2a9c1e5b7d4a60"`
+}
+
+// FetchRequest - fetch one remote, or all remotes when Remote is empty
+type FetchRequest struct {
+	Remote string `json:"remote,omitempty" example:"upstream"`
+	Prune  bool   `json:"prune,omitempty" example:"true"`
+}
+
+// RemoteFetchResult - outcome of fetching a single remote
+type RemoteFetchResult struct {
+	Remote   string `json:"remote" example:"upstream"`
+	UpToDate bool   `json:"up_to_date" example:"false"`
+	Error    string `json:"error,omitempty"` // why the remote could not be fetched, when fetching every remote
+}
+
+// FetchResult - outcome of a fetch, one entry per remote fetched
+type FetchResult struct {
+	Remotes []RemoteFetchResult `json:"remotes"`
+}
+
+// FetchStatus - when a repository was last fetched, and how it went
+type FetchStatus struct {
+	LastFetchAt         time.Time  `json:"last_fetch_at"`
+	Succeeded           bool       `json:"succeeded" example:"true"`
+	Error               string     `json:"error,omitempty"`
+	Automatic           bool       `json:"automatic" example:"true"` // true when run by the auto-fetch scheduler
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty" example:"0"`
+	NextFetchAt         *time.Time `json:"next_fetch_at,omitempty"` // next scheduled auto-fetch, when enabled
+}
+
+// PushRequest - push to Remote (default origin); RefSpec accepts "branch", "src:dst" or a full refspec
+type PushRequest struct {
+	Remote  string `json:"remote,omitempty" example:"fork"`
+	RefSpec string `json:"refspec,omitempty" example:"feature:feature"`
+}
+
+// PullRequest - pull from Remote (default: the branch's upstream remote, then origin); RefSpec names the remote branch to integrate
+type PullRequest struct {
+	Remote   string `json:"remote,omitempty" example:"upstream"`
+	RefSpec  string `json:"refspec,omitempty" example:"main"`
+	Strategy string `json:"strategy,omitempty" example:"rebase"` // overrides the repository's PullStrategy setting
+}
+
+// PullResult - outcome of a pull; Ahead and Behind are counted before integrating
+type PullResult struct {
+	Status    string   `json:"status" example:"merged"` // "up-to-date" | "fast-forward" | "merged" | "rebased" | "conflicted"
+	Strategy  string   `json:"strategy" example:"merge"`
+	Upstream  string   `json:"upstream" example:"origin/main"`
+	Hash      string   `json:"hash,omitempty" example:"abc123def456..."`
+	Ahead     int      `json:"ahead" example:"1"`
+	Behind    int      `json:"behind" example:"2"`
+	Conflicts []string `json:"conflicts"`
 }
 
 type RepoIdentitySettings struct {
@@ -180,6 +476,8 @@ type RepoSyncSettings struct {
 type RepoCommitSettings struct {
 	DefaultBranch  string `json:"defaultBranch" example:"main"`
 	SigningEnabled bool   `json:"signingEnabled" example:"false"`
+	SigningFormat  string `json:"signingFormat,omitempty" example:"ssh"`                // "openpgp" | "ssh"; used when git config has no gpg.format
+	SigningKey     string `json:"signingKey,omitempty" example:"~/.ssh/id_ed25519.pub"` // used when git config has no user.signingkey
 	LineEndings    string `json:"lineEndings" example:"lf"`
 }
 
@@ -190,6 +488,88 @@ type RepoSettings struct {
 	Remotes  []RepoRemote         `json:"remotes"`
 }
 
+// ─── CREDENTIAL MODELS ───
+// Secrets are write-only: they are stored encrypted and never returned
+
+// Credential - a stored HTTPS token, SSH private key or ssh-agent passthrough
+type Credential struct {
+	ID        string    `json:"id" example:"3f2a9c1e5b7d4a60"`
+	Name      string    `json:"name" example:"GitHub token"`
+	Kind      string    `json:"kind" example:"https-token"`          // "https-token" | "ssh-key" | "ssh-agent"
+	Host      string    `json:"host,omitempty" example:"github.com"` // remotes on this host use the credential unless they choose another
+	Username  string    `json:"username,omitempty" example:"git"`
+	PublicKey string    `json:"public_key,omitempty" example:"ssh-ed25519 AAAA..."` // for ssh-key, to register with the git host
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CredentialRequest - create or replace a credential; on update, an empty secret keeps the stored one
+type CredentialRequest struct {
+	Name       string `json:"name" example:"GitHub token"`
+	Kind       string `json:"kind" example:"https-token"`
+	Host       string `json:"host,omitempty" example:"github.com"`
+	Username   string `json:"username,omitempty" example:"git"`
+	Token      string `json:"token,omitempty"`       // https-token
+	PrivateKey string `json:"private_key,omitempty"` // ssh-key, PEM or OpenSSH format
+	Passphrase string `json:"passphrase,omitempty"`  // ssh-key, when the private key is encrypted
+}
+
+// KnownHost - an SSH host key in the managed known_hosts file, or one awaiting confirmation
+type KnownHost struct {
+	Host        string `json:"host" example:"github.com"` // [host]:port when the port is not 22
+	KeyType     string `json:"key_type" example:"ssh-ed25519"`
+	Fingerprint string `json:"fingerprint" example:"SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU"`
+}
+
+// KnownHosts - trusted host keys, and keys seen on first connection that wait to be trusted
+type KnownHosts struct {
+	Trusted []KnownHost `json:"trusted"`
+	Pending []KnownHost `json:"pending"`
+}
+
+// TrustHostRequest - trust a pending host key; the fingerprint must match the key that was seen
+type TrustHostRequest struct {
+	Host        string `json:"host" example:"github.com"`
+	Fingerprint string `json:"fingerprint" example:"SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU"`
+}
+
+// ─── EVENT STREAM MODELS ───
+// Sent on the /api/events server-sent event stream
+
+// Event - a typed notification about one repository; clients resume a stream from the last ID they saw
+type Event struct {
+	ID     uint64    `json:"id" example:"1718000000000001"`
+	Type   string    `json:"type" example:"head.moved"` // "status.changed" | "head.moved" | "ref.created" | "ref.updated" | "ref.deleted" | "index.updated" | "fetch.completed" | "job.progress" | "resync"
+	RepoID string    `json:"repo_id,omitempty" example:"my-repo"`
+	Time   time.Time `json:"time"`
+	Data   any       `json:"data,omitempty"` // RefEvent for head.moved and ref.*, FetchStatus for fetch.completed
+}
+
+// RefEvent - a ref or HEAD moving; Hash is empty for deleted refs and OldHash for created ones
+type RefEvent struct {
+	Ref     string `json:"ref" example:"refs/heads/main"`                  // for head.moved, the branch HEAD points at ("" when detached)
+	OldRef  string `json:"old_ref,omitempty" example:"refs/heads/feature"` // head.moved only, when HEAD switched branches
+	Hash    string `json:"hash,omitempty" example:"abc123def456..."`
+	OldHash string `json:"old_hash,omitempty" example:"def456abc123..."`
+}
+
+// ─── JOB MODELS ───
+
+// Job - a long-running operation run in the background; Result holds the operation's response once it succeeded
+type Job struct {
+	ID         string     `json:"id" example:"9f86d081884c7d65"`
+	Kind       string     `json:"kind" example:"push"` // "clone" | "push" | "force-push" | "pull" | "generate-commit-message"
+	RepoID     string     `json:"repo_id,omitempty" example:"my-repo"`
+	Status     string     `json:"status" example:"running"`                                    // "queued" | "running" | "succeeded" | "failed" | "cancelled"
+	Progress   string     `json:"progress,omitempty" example:"Receiving objects:  45% (9/20)"` // latest progress line reported by git
+	Percent    int        `json:"percent,omitempty" example:"45"`
+	Result     any        `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
 // ─── TOKENIZED DIFF MODELS ───
 // Used for syntax-highlighted diffs sent to the mobile client
 
@@ -201,10 +581,18 @@ type Token struct {
 
 // DiffLineTokenized - single line in a diff hunk
 type DiffLineTokenized struct {
-	Type   string  `json:"type"`   // "added" | "deleted" | "context"
-	Tokens []Token `json:"tokens"` // syntax-highlighted fragments
-	OldNum int     `json:"oldNum,omitempty"`
-	NewNum int     `json:"newNum,omitempty"`
+	Type    string     `json:"type"`   // "added" | "deleted" | "context"
+	Tokens  []Token    `json:"tokens"` // syntax-highlighted fragments
+	OldNum  int        `json:"oldNum,omitempty"`
+	NewNum  int        `json:"newNum,omitempty"`
+	Changes []DiffSpan `json:"changes,omitempty"` // what changed, on deleted and added lines paired with a line on the other side
+}
+
+// DiffSpan - changed range of a line's text (its tokens joined), in UTF-16
+// code units as JavaScript indexes strings
+type DiffSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"` // exclusive
 }
 
 // DiffBlock - a group of consecutive lines of the same type
@@ -226,19 +614,22 @@ type DiffHunkTokenized struct {
 
 // TokenizedDiff - complete tokenized diff for a single file
 type TokenizedDiff struct {
-	Filename   string              `json:"filename"`
-	Hunks      []DiffHunkTokenized `json:"hunks"`
-	Additions  int                 `json:"additions"`
-	Deletions  int                 `json:"deletions"`
-	HasMore    bool                `json:"has_more"`
-	NextCursor int                 `json:"next_cursor,omitempty"`
-	TotalHunks int                 `json:"total_hunks"`
+	Filename    string              `json:"filename"`
+	OldFilename string              `json:"old_filename,omitempty"` // path before a rename or copy
+	Hunks       []DiffHunkTokenized `json:"hunks"`
+	Additions   int                 `json:"additions"`
+	Deletions   int                 `json:"deletions"`
+	HasMore     bool                `json:"has_more"`
+	NextCursor  int                 `json:"next_cursor,omitempty"`
+	TotalHunks  int                 `json:"total_hunks"`
+	Fingerprint string              `json:"fingerprint,omitempty"` // identifies the diff text for partial staging; default diff options only
+	EOLOnly     bool                `json:"eol_only,omitempty"`    // only line endings changed
 }
 
 // TokenizedFileDiff - wraps tokenized diff with file metadata
 type TokenizedFileDiff struct {
 	Path       string        `json:"path"`
-	ChangeType string        `json:"changeType"` // "added" | "modified" | "deleted"
+	ChangeType string        `json:"changeType"` // "added" | "modified" | "deleted", and "renamed" | "copied" | "type-changed" in compares and commit diffs
 	Diff       TokenizedDiff `json:"diff"`
 }
 
@@ -251,3 +642,124 @@ type TokenizedCommitDiff struct {
 	Files   []TokenizedFileDiff `json:"files"`
 	Stats   DiffStats           `json:"stats"`
 }
+
+// DiffLineRange - inclusive range of changed lines on one side of a diff
+type DiffLineRange struct {
+	Side  string `json:"side"`  // "old" selects deleted lines by oldNum, "new" selects added lines by newNum
+	Start int    `json:"start"` // first line number
+	End   int    `json:"end"`   // last line number (defaults to start)
+}
+
+// PartialStageRequest - hunks and line ranges of a tokenized diff to stage or unstage
+type PartialStageRequest struct {
+	Fingerprint string          `json:"fingerprint"`     // fingerprint of the tokenized diff the selection was made against
+	Hunks       []int           `json:"hunks,omitempty"` // hunk indexes across the whole diff (cursor-based)
+	Lines       []DiffLineRange `json:"lines,omitempty"`
+}
+
+// ─── BLAME MODELS ───
+
+// BlameLine - one line of the blamed file
+type BlameLine struct {
+	Number         int     `json:"number"`          // line number in the file at the blamed revision
+	OriginalNumber int     `json:"original_number"` // line number in the commit that last changed it
+	Tokens         []Token `json:"tokens"`          // syntax-highlighted fragments
+}
+
+// BlameRun - consecutive lines last changed by the same commit
+type BlameRun struct {
+	Hash         string      `json:"hash"`
+	Author       Author      `json:"author"`
+	Date         time.Time   `json:"date"`
+	Summary      string      `json:"summary"`
+	OriginalPath string      `json:"original_path,omitempty"` // path in that commit, when the file has since been renamed
+	Boundary     bool        `json:"boundary,omitempty"`      // the commit is the root of the history, or a shallow boundary
+	StartLine    int         `json:"start_line"`
+	EndLine      int         `json:"end_line"`
+	Lines        []BlameLine `json:"lines"`
+}
+
+// Blame - line-by-line attribution of a file at a revision
+type Blame struct {
+	Path       string     `json:"path"`
+	Rev        string     `json:"rev"` // the commit that was blamed
+	Runs       []BlameRun `json:"runs"`
+	TotalLines int        `json:"total_lines"`
+}
+
+// ─── FILE HISTORY MODELS ───
+
+// FileHistoryEntry - a commit that changed a file, with the file's change in it
+type FileHistoryEntry struct {
+	Commit     Commit `json:"commit"`
+	Path       string `json:"path"`                 // path of the file in this commit
+	OldPath    string `json:"old_path,omitempty"`   // path in the parent, for renames and copies
+	ChangeType string `json:"change_type"`          // "added" | "modified" | "deleted" | "renamed" | "copied" | "type-changed"
+	Similarity int    `json:"similarity,omitempty"` // percent, for renames and copies
+	Additions  int    `json:"additions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+// ─── REVISION TREE MODELS ───
+
+// TreeEntry - a file, directory or submodule in a tree at a revision
+type TreeEntry struct {
+	Path        string `json:"path" example:"src/main.go"`
+	Name        string `json:"name" example:"main.go"`
+	Type        string `json:"type" example:"blob"` // "blob" | "tree" | "commit" (submodule)
+	IsDirectory bool   `json:"is_directory" example:"false"`
+	Mode        string `json:"mode" example:"100644"` // git file mode in octal
+	Size        int64  `json:"size" example:"1024"`   // blob size in bytes; 0 for trees and submodules
+	Hash        string `json:"hash"`                  // object ID, or the submodule's commit
+}
+
+// RevisionTree - one directory level of a tree at a revision
+type RevisionTree struct {
+	Rev        string      `json:"rev"` // commit the tree was read from
+	Path       string      `json:"path" example:"src/components"`
+	ParentPath string      `json:"parent_path" example:"src"`
+	Entries    []TreeEntry `json:"entries"`
+	TotalCount int         `json:"total_count" example:"42"`
+	HasMore    bool        `json:"has_more" example:"true"`
+	Offset     int         `json:"offset" example:"0"`
+}
+
+// RevisionBlob - a file at a revision
+type RevisionBlob struct {
+	Rev     string `json:"rev"` // commit the file was read from
+	Path    string `json:"path" example:"src/main.go"`
+	Hash    string `json:"hash"`
+	Mode    string `json:"mode" example:"100644"`
+	Size    int64  `json:"size" example:"1024"`
+	Binary  bool   `json:"binary"`
+	Content string `json:"content,omitempty"` // omitted for binary files
+}
+
+// ─── COMPARE MODELS ───
+
+// ChangedFile - a file changed between two trees, with its line stats
+type ChangedFile struct {
+	Path       string `json:"path"`
+	OldPath    string `json:"old_path,omitempty"`   // path on the base side, for renames and copies
+	ChangeType string `json:"change_type"`          // "added" | "modified" | "deleted" | "renamed" | "copied" | "type-changed"
+	Similarity int    `json:"similarity,omitempty"` // percent, for renames and copies
+	Additions  int    `json:"additions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+// Compare - the commits and changes of head relative to base, like a pull request
+type Compare struct {
+	Base         string              `json:"base"`                 // resolved base commit
+	Head         string              `json:"head"`                 // resolved head commit
+	Mode         string              `json:"mode"`                 // "three-dot" diffs from the merge base, "two-dot" from base itself
+	MergeBase    string              `json:"merge_base,omitempty"` // three-dot only
+	Commits      []Commit            `json:"commits"`              // on head but not on base, newest first, at most 250
+	TotalCommits int                 `json:"total_commits"`
+	Stats        DiffStats           `json:"stats"`
+	Files        []ChangedFile       `json:"files"`       // every changed file
+	Diffs        []TokenizedFileDiff `json:"diffs"`       // tokenized diffs of the page of files starting at the cursor
+	HasMore      bool                `json:"has_more"`    // more files after this page
+	NextCursor   int                 `json:"next_cursor"` // index into files of the next page
+}
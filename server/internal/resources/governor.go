@@ -1,6 +1,7 @@
 package resources
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 )
@@ -29,6 +30,8 @@ type Governor struct {
 	mu                sync.Mutex
 	mode              atomic.Int32
 	expensiveInflight int
+	// changed is closed when a slot frees up or degraded mode ends
+	changed chan struct{}
 
 	testHookAfterPressureLock func()
 }
@@ -68,6 +71,7 @@ func (g *Governor) UpdatePressure(ratio float64) {
 	case ModeDegraded:
 		if ratio <= g.cfg.DegradeLowWatermark {
 			g.mode.Store(0)
+			g.signalLocked()
 		}
 	}
 }
@@ -103,7 +107,41 @@ func (g *Governor) AdmitExpensive() Admission {
 				if g.expensiveInflight > 0 {
 					g.expensiveInflight--
 				}
+				g.signalLocked()
 			})
 		},
 	}
 }
+
+// WaitExpensive is AdmitExpensive for work that can wait its turn: it blocks
+// until a slot is free and the governor is out of degraded mode, or until ctx
+// is done.
+func (g *Governor) WaitExpensive(ctx context.Context) (Admission, error) {
+	for {
+		g.mu.Lock()
+		if g.changed == nil {
+			g.changed = make(chan struct{})
+		}
+		changed := g.changed
+		g.mu.Unlock()
+
+		admission := g.AdmitExpensive()
+		if admission.Admitted {
+			return admission, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return admission, ctx.Err()
+		}
+	}
+}
+
+// signalLocked wakes every WaitExpensive caller to retry admission.
+func (g *Governor) signalLocked() {
+	if g.changed != nil {
+		close(g.changed)
+		g.changed = nil
+	}
+}
@@ -1,6 +1,7 @@
 package resources
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -188,6 +189,62 @@ func TestGovernor_RejectsAdmissionBlockedBehindDegradeTransition(t *testing.T) {
 	}
 }
 
+func TestGovernor_WaitExpensiveQueuesUntilReleaseOrRecovery(t *testing.T) {
+	g := NewGovernor(Config{
+		Enabled:              true,
+		MaxExpensiveInflight: 1,
+		DegradeHighWatermark: 0.85,
+		DegradeLowWatermark:  0.70,
+	})
+
+	held := g.AdmitExpensive()
+	if !held.Admitted {
+		t.Fatal("expected first admission")
+	}
+
+	admitted := make(chan Admission, 1)
+	go func() {
+		admission, err := g.WaitExpensive(context.Background())
+		if err != nil {
+			t.Errorf("WaitExpensive failed: %v", err)
+		}
+		admitted <- admission
+	}()
+
+	select {
+	case <-admitted:
+		t.Fatal("expected waiter to queue while the slot is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	g.UpdatePressure(0.90)
+	held.Release()
+	select {
+	case <-admitted:
+		t.Fatal("expected waiter to keep waiting while degraded")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	g.UpdatePressure(0.50)
+	select {
+	case admission := <-admitted:
+		if !admission.Admitted {
+			t.Fatalf("expected admission, got %+v", admission)
+		}
+		admission.Release()
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not admitted after recovery")
+	}
+
+	held = g.AdmitExpensive()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if admission, err := g.WaitExpensive(ctx); err == nil || admission.Admitted {
+		t.Fatalf("expected cancelled wait to fail, got %+v, %v", admission, err)
+	}
+	held.Release()
+}
+
 func TestGovernor_DisabledDoesNotThrottleOrRejectExpensiveRequests(t *testing.T) {
 	g := NewGovernor(Config{
 		Enabled:              false,
@@ -2,6 +2,8 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"gitweb/server/internal/config"
 	"os/exec"
@@ -21,7 +23,9 @@ func NewClaudeService(cfg *config.Config) *ClaudeService {
 	}
 }
 
-func (s *ClaudeService) GenerateCommitMessage(diffs []string, customPrompt string) (string, error) {
+// GenerateCommitMessage runs the claude CLI on the prompt. The command is
+// killed when ctx is done or the timeout passes.
+func (s *ClaudeService) GenerateCommitMessage(ctx context.Context, diffs []string, customPrompt string) (string, error) {
 	prompt := s.config.ClaudePromptValue()
 	if customPrompt != "" {
 		prompt = customPrompt
@@ -31,36 +35,28 @@ func (s *ClaudeService) GenerateCommitMessage(diffs []string, customPrompt strin
 	diffsStr := strings.Join(diffs, "\n")
 	prompt = strings.Replace(prompt, "{{diffs}}", diffsStr, 1)
 
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
 	// Create claude command
-	cmd := exec.Command("claude", prompt)
+	cmd := exec.CommandContext(ctx, "claude", prompt)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	// Execute with timeout
-	err := cmd.Start()
-	if err != nil {
-		return "", fmt.Errorf("failed to start claude command: %w", err)
-	}
-
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
-
-	select {
-	case err := <-done:
-		if err != nil {
-			stderrStr := stderr.String()
-			if stderrStr != "" {
-				return "", fmt.Errorf("claude command failed: %s", stderrStr)
-			}
-			return "", fmt.Errorf("claude command failed: %w", err)
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("claude command timed out after %v", s.timeout)
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		stderrStr := stderr.String()
+		if stderrStr != "" {
+			return "", fmt.Errorf("claude command failed: %s", stderrStr)
 		}
-	case <-time.After(s.timeout):
-		cmd.Process.Kill()
-		return "", fmt.Errorf("claude command timed out after %v", s.timeout)
+		return "", fmt.Errorf("claude command failed: %w", err)
 	}
 
 	message := strings.TrimSpace(stdout.String())
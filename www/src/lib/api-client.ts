@@ -12,6 +12,7 @@ import type {
   GenerateCommitMessageResponse,
   GitConfig,
   RepoDirectoryListing,
+  RepoEvent,
   TokenizedDiff,
 } from "../types/api";
 
@@ -352,6 +353,81 @@ class ApiClient {
   async getGitConfig(id: string): Promise<GitConfig> {
     return this.request<GitConfig>(`/repos/${id}/config/git`);
   }
+
+  // Server-sent events
+  //
+  // EventSource cannot send the Authorization header the API requires, so the
+  // stream is read with fetch instead. Resolves when the signal aborts;
+  // rejects on a failed response. Reconnecting with lastEventId replays
+  // missed events or sends a resync event.
+  async streamEvents(
+    onEvent: (event: RepoEvent) => void,
+    options: { repos?: string[]; lastEventId?: number; signal?: AbortSignal } = {},
+  ): Promise<void> {
+    const params = new URLSearchParams();
+    if (options.repos && options.repos.length > 0) {
+      params.append("repos", options.repos.join(","));
+    }
+    const query = params.toString() ? `?${params.toString()}` : "";
+    const token = getCookie("gitty_auth_token") || localStorage.getItem("gitty_auth_token");
+
+    let response: Response;
+    try {
+      response = await fetch(`${API_BASE_URL}/events${query}`, {
+        headers: {
+          Accept: "text/event-stream",
+          ...(token ? { Authorization: `Bearer ${token}` } : {}),
+          ...(options.lastEventId !== undefined
+            ? { "Last-Event-ID": String(options.lastEventId) }
+            : {}),
+        },
+        signal: options.signal,
+      });
+    } catch (error) {
+      if (options.signal?.aborted) {
+        return;
+      }
+      throw error;
+    }
+    if (!response.ok || !response.body) {
+      throw new ApiError({
+        message: `Failed to open event stream: ${response.statusText}`,
+        status: response.status,
+      });
+    }
+
+    const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = "";
+    let data = "";
+    try {
+      for (;;) {
+        const { value, done } = await reader.read();
+        if (done) {
+          return;
+        }
+        buffer += value;
+        const lines = buffer.split(/\r\n|\r|\n/);
+        buffer = lines.pop() ?? "";
+        for (const line of lines) {
+          // A blank line ends an event; only its JSON data is needed, as it
+          // repeats the id and type.
+          if (line === "") {
+            if (data) {
+              onEvent(JSON.parse(data) as RepoEvent);
+            }
+            data = "";
+          } else if (line.startsWith("data:")) {
+            data += line.slice(5).trimStart();
+          }
+        }
+      }
+    } catch (error) {
+      if (options.signal?.aborted) {
+        return;
+      }
+      throw error;
+    }
+  }
 }
 
 // Create API client instance
@@ -185,4 +185,21 @@ export interface TokenizedDiff {
   has_more: boolean;
   next_cursor?: number;
   total_hunks: number;
-}
\ No newline at end of file
+}
+// RepoEvent - event from the /api/events server-sent event stream
+export interface RepoEvent {
+  id: number;
+  type:
+    | 'status.changed'
+    | 'head.moved'
+    | 'ref.created'
+    | 'ref.updated'
+    | 'ref.deleted'
+    | 'index.updated'
+    | 'fetch.completed'
+    | 'job.progress'
+    | 'resync';
+  repo_id?: string;
+  time: string;
+  data?: unknown;
+}